@@ -5,15 +5,16 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
-	"github.com/mahendrapaipuri/authlib/authz"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
 )
 
@@ -36,15 +37,27 @@ type App struct {
 	grafanaSemVer string
 	httpClient    *http.Client
 
-	authzClient authz.EnforcementClient
-	mx          sync.Mutex
+	// authzClients are authz clients keyed by hash of their token. mx only
+	// guards the creation of clients
+	authzClients sync.Map
+	mx           sync.Mutex
 
-	saToken string
-	conf    config.Config
+	conf config.Config
 
-	workerPools    worker.Pools
-	chromeInstance chrome.Instance
-	ctxLogger      log.Logger
+	workerPools      worker.Pools
+	interactivePools worker.Pools
+	chromeInstance   chrome.Instance
+	ctxLogger        log.Logger
+
+	rateLimiter *rateLimiter
+
+	// circuitBreaker guards requests made to Grafana by httpClient
+	circuitBreaker *circuitBreaker
+
+	// Renderer detected at startup. It is detected in the background and
+	// hence, guarded by rendererMx
+	renderer   string
+	rendererMx sync.RWMutex
 }
 
 // NewDashboardReporterApp creates a new example *App instance.
@@ -72,6 +85,11 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 		return nil, fmt.Errorf("error loading config: %w", err)
 	}
 
+	// Redact URLs in logs, if enabled
+	if app.conf.LogRedaction {
+		app.ctxLogger = helpers.NewRedactingLogger(app.ctxLogger)
+	}
+
 	app.ctxLogger.Info("starting plugin with initial config: " + app.conf.String())
 
 	// Get current Grafana version
@@ -90,7 +108,35 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 		return nil, fmt.Errorf("error in httpclient new: %w", err)
 	}
 
-	// Create a new browser instance
+	// Guard requests to Grafana with a circuit breaker, if enabled
+	if app.conf.CircuitBreakerThreshold > 0 {
+		app.circuitBreaker = newCircuitBreaker(
+			app.conf.CircuitBreakerThreshold,
+			time.Duration(app.conf.CircuitBreakerCooldown)*time.Second,
+		)
+
+		transport := app.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		app.httpClient.Transport = &breakerTransport{next: transport, breaker: app.circuitBreaker}
+	}
+
+	// Check if panels can be rendered with the configured renderer. Detection
+	// makes requests to Grafana and hence, it is done in the background so that
+	// start up of plugin is not delayed
+	app.renderer = rendererUnknown
+
+	go app.updateRenderer(backend.GrafanaConfigFromContext(ctx))
+
+	// Create a rate limiter for report requests, if enabled
+	if app.conf.RateLimit > 0 {
+		app.rateLimiter = newRateLimiter(app.conf.RateLimit)
+	}
+
+	// Create a new browser instance with a pool of tabs that can serve all
+	// browser workers at the same time
 	var chromeInstance chrome.Instance
 
 	switch app.conf.RemoteChromeURL {
@@ -99,12 +145,15 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 			context.Background(),
 			app.ctxLogger,
 			app.conf.HTTPClientOptions.TLS.InsecureSkipVerify,
+			app.conf.TabPoolSize(),
 		)
 	default:
 		chromeInstance, err = chrome.NewRemoteBrowserInstance(
 			context.Background(),
 			app.ctxLogger,
 			app.conf.RemoteChromeURL,
+			app.conf.RemoteChromeMaxTabs,
+			app.conf.TabPoolSize(),
 		)
 	}
 
@@ -128,6 +177,25 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 		worker.Renderer: worker.New(context.Background(), app.conf.MaxRenderWorkers),
 	}
 
+	// Interactive requests get their own pools so that they are not queued
+	// behind large batch reports. Unset size falls back to the size of the
+	// shared pool
+	if app.conf.InteractiveBrowserWorkers > 0 || app.conf.InteractiveRenderWorkers > 0 {
+		browserWorkers, renderWorkers := app.conf.InteractiveBrowserWorkers, app.conf.InteractiveRenderWorkers
+		if browserWorkers == 0 {
+			browserWorkers = app.conf.MaxBrowserWorkers
+		}
+
+		if renderWorkers == 0 {
+			renderWorkers = app.conf.MaxRenderWorkers
+		}
+
+		app.interactivePools = worker.Pools{
+			worker.Browser:  worker.New(context.Background(), browserWorkers),
+			worker.Renderer: worker.New(context.Background(), renderWorkers),
+		}
+	}
+
 	return &app, nil
 }
 
@@ -143,6 +211,10 @@ func (app *App) Dispose() {
 		}
 	}
 
+	for _, pool := range app.interactivePools {
+		pool.Done()
+	}
+
 	if app.chromeInstance == nil {
 		return
 	}
@@ -153,9 +225,56 @@ func (app *App) Dispose() {
 }
 
 // CheckHealth handles health checks sent from Grafana to the plugin.
-func (app *App) CheckHealth(_ context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+func (app *App) CheckHealth(ctx context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	renderer := app.currentRenderer()
+
+	// Panels cannot be rendered without a renderer
+	if renderer == rendererUnavailable {
+		return &backend.CheckHealthResult{
+			Status: backend.HealthStatusError,
+			Message: "grafana-image-renderer is not available. Install grafana-image-renderer plugin, " +
+				"configure a remote rendering service or enable native rendering",
+		}, nil
+	}
+
+	// When using remote chrome, report its version and compatibility
+	if remote, ok := app.chromeInstance.(*chrome.RemoteInstance); ok {
+		version, err := remote.Version(ctx)
+		if err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: "failed to get version of remote chrome: " + err.Error(),
+			}, nil
+		}
+
+		if err := version.Check(); err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: err.Error(),
+			}, nil
+		}
+
+		return &backend.CheckHealthResult{
+			Status: backend.HealthStatusOk,
+			Message: fmt.Sprintf(
+				"ok; remote chrome %s (protocol %s); renderer %s%s",
+				version.Browser, version.ProtocolVersion, renderer, app.breakerHealth(),
+			),
+		}, nil
+	}
+
 	return &backend.CheckHealthResult{
 		Status:  backend.HealthStatusOk,
-		Message: "ok",
+		Message: "ok; renderer " + renderer + app.breakerHealth(),
 	}, nil
 }
+
+// breakerHealth returns the state of circuit breaker to be reported in health
+// checks, if enabled.
+func (app *App) breakerHealth() string {
+	if app.circuitBreaker == nil {
+		return ""
+	}
+
+	return "; circuit breaker " + app.circuitBreaker.State().String()
+}
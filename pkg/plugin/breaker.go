@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// breakerState is the state of circuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops sending requests to Grafana after a number of consecutive
+// failures. Once open, requests fail fast until cooldown has elapsed after which
+// a single trial request is let through. Breaker is closed again when the trial
+// request succeeds and opened for another cooldown period otherwise.
+type circuitBreaker struct {
+	mx        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	trial     bool // true when trial request of half-open breaker is in flight
+	now       func() time.Time
+}
+
+// newCircuitBreaker returns a new circuitBreaker that opens after threshold
+// consecutive failures for cooldown duration.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns an error when the request must not be made. Callers must report
+// the outcome of allowed requests using record.
+func (b *circuitBreaker) allow() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.state == breakerOpen {
+		if remaining := b.cooldown - b.now().Sub(b.openedAt); remaining > 0 {
+			return fmt.Errorf("%w: retry after %s", errCircuitOpen, remaining.Round(time.Second))
+		}
+
+		b.state = breakerHalfOpen
+	}
+
+	if b.state == breakerHalfOpen {
+		// Only one trial request at a time
+		if b.trial {
+			return fmt.Errorf("%w: waiting for trial request", errCircuitOpen)
+		}
+
+		b.trial = true
+	}
+
+	return nil
+}
+
+// record updates the state of the breaker based on the outcome of an allowed
+// request. Requests cancelled by the callers are neither failures nor successes.
+func (b *circuitBreaker) record(err error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		if err == nil {
+			b.failures = 0
+		} else if !isCancelled(err) {
+			b.failures++
+
+			if b.failures >= b.threshold {
+				b.open()
+			}
+		}
+	case breakerHalfOpen:
+		b.trial = false
+
+		switch {
+		case err == nil:
+			b.state = breakerClosed
+			b.failures = 0
+		case !isCancelled(err):
+			b.open()
+		}
+	case breakerOpen:
+		// Outcome of requests made before the breaker was opened
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *circuitBreaker) State() breakerState {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.state == breakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return breakerHalfOpen
+	}
+
+	return b.state
+}
+
+// open opens the breaker. Callers must hold the lock.
+func (b *circuitBreaker) open() {
+	b.state = breakerOpen
+	b.openedAt = b.now()
+}
+
+// isCancelled returns true when the request was cancelled by the caller.
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// breakerTransport is a http.RoundTripper that guards requests with a circuit
+// breaker. Transport errors and server errors are considered as failures.
+type breakerTransport struct {
+	next    http.RoundTripper
+	breaker *circuitBreaker
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	switch {
+	case err != nil:
+		t.breaker.record(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.record(fmt.Errorf("server error: %s", resp.Status))
+	default:
+		t.breaker.record(nil)
+	}
+
+	return resp, err
+}
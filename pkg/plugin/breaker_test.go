@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	Convey("When guarding requests with a circuit breaker", t, func() {
+		now := time.Now()
+		errFailed := errors.New("failed")
+
+		breaker := newCircuitBreaker(2, 30*time.Second)
+		breaker.now = func() time.Time { return now }
+
+		Convey("Breaker should be closed initially", func() {
+			So(breaker.State(), ShouldEqual, breakerClosed)
+			So(breaker.allow(), ShouldBeNil)
+		})
+
+		Convey("Breaker should stay closed when failures are not consecutive", func() {
+			breaker.record(errFailed)
+			breaker.record(nil)
+			breaker.record(errFailed)
+
+			So(breaker.State(), ShouldEqual, breakerClosed)
+		})
+
+		Convey("Cancelled requests should not be counted as failures", func() {
+			breaker.record(errFailed)
+			breaker.record(context.Canceled)
+
+			So(breaker.State(), ShouldEqual, breakerClosed)
+		})
+
+		Convey("Breaker should open after consecutive failures", func() {
+			breaker.record(errFailed)
+			breaker.record(errFailed)
+
+			So(breaker.State(), ShouldEqual, breakerOpen)
+			So(breaker.allow(), ShouldWrap, errCircuitOpen)
+
+			Convey("Breaker should be half-open after cooldown", func() {
+				now = now.Add(30 * time.Second)
+
+				So(breaker.State(), ShouldEqual, breakerHalfOpen)
+
+				Convey("Only one trial request should be allowed", func() {
+					So(breaker.allow(), ShouldBeNil)
+					So(breaker.allow(), ShouldWrap, errCircuitOpen)
+
+					Convey("Successful trial request should close the breaker", func() {
+						breaker.record(nil)
+
+						So(breaker.State(), ShouldEqual, breakerClosed)
+						So(breaker.allow(), ShouldBeNil)
+					})
+
+					Convey("Failed trial request should open the breaker again", func() {
+						breaker.record(errFailed)
+
+						So(breaker.State(), ShouldEqual, breakerOpen)
+						So(breaker.allow(), ShouldWrap, errCircuitOpen)
+					})
+
+					Convey("Cancelled trial request should allow another trial request", func() {
+						breaker.record(context.Canceled)
+
+						So(breaker.State(), ShouldEqual, breakerHalfOpen)
+						So(breaker.allow(), ShouldBeNil)
+					})
+				})
+			})
+		})
+	})
+
+	Convey("When making requests with circuit breaker transport", t, func() {
+		var (
+			requests atomic.Int32
+			status   atomic.Int32
+		)
+
+		status.Store(http.StatusInternalServerError)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(int(status.Load()))
+		}))
+		defer srv.Close()
+
+		now := time.Now()
+
+		breaker := newCircuitBreaker(2, 30*time.Second)
+		breaker.now = func() time.Time { return now }
+
+		client := &http.Client{Transport: &breakerTransport{next: http.DefaultTransport, breaker: breaker}}
+
+		Convey("Server errors should open the breaker and fail fast", func() {
+			for range 2 {
+				resp, err := client.Get(srv.URL)
+				So(err, ShouldBeNil)
+				resp.Body.Close()
+			}
+
+			_, err := client.Get(srv.URL)
+			So(err, ShouldWrap, errCircuitOpen)
+			So(requests.Load(), ShouldEqual, 2)
+
+			Convey("Successful request after cooldown should close the breaker", func() {
+				status.Store(http.StatusNotFound)
+				now = now.Add(time.Minute)
+
+				resp, err := client.Get(srv.URL)
+				So(err, ShouldBeNil)
+				resp.Body.Close()
+
+				So(requests.Load(), ShouldEqual, 3)
+				So(breaker.State(), ShouldEqual, breakerClosed)
+			})
+		})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/chromedp/chromedp"
@@ -16,6 +17,9 @@ import (
 	"golang.org/x/net/context"
 )
 
+// Timeout of health check of local browser.
+const healthCheckTimeout = 5 * time.Second
+
 // Path to chrome executable.
 var (
 	chromeExec    string
@@ -84,12 +88,44 @@ func init() {
 
 // LocalInstance is a locally running browser instance.
 type LocalInstance struct {
-	allocCtx   context.Context
-	browserCtx context.Context
+	allocCtx       context.Context
+	allocCtxCancel context.CancelFunc
+	browserCtx     context.Context
+
+	// Pool of reusable tabs
+	pool *tabPool
+
+	// Arguments of the instance used to restart the browser after a crash
+	parentCtx          context.Context
+	logger             log.Logger
+	insecureSkipVerify bool
+
+	// Guards the browser contexts while browser is being restarted
+	mx sync.RWMutex
 }
 
-// NewLocalBrowserInstance creates a new local browser instance.
-func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSkipVerify bool) (*LocalInstance, error) {
+// NewLocalBrowserInstance creates a new local browser instance. When poolSize is
+// positive, a pool of poolSize reusable tabs is made for the instance.
+func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSkipVerify bool, poolSize int) (*LocalInstance, error) {
+	instance := &LocalInstance{
+		parentCtx:          ctx,
+		logger:             logger,
+		insecureSkipVerify: insecureSkipVerify,
+	}
+
+	if err := instance.start(); err != nil {
+		return nil, err
+	}
+
+	if poolSize > 0 {
+		instance.pool = newTabPool(poolSize, instance.newTab)
+	}
+
+	return instance, nil
+}
+
+// start allocates a new browser and starts it.
+func (i *LocalInstance) start() error {
 	// go-staticcheck was keep complaining about unused var
 	// preallocate options
 	// chromeOptions := make([]func(*chromedp.ExecAllocator), 0, len(chromedp.DefaultExecAllocatorOptions)+3)
@@ -101,7 +137,7 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 
 	// If we managed to create a home for chrome in a "writable" location, set it to chrome options
 	if chromeHomeDir != "" {
-		logger.Debug("created home directory for chromium process", "home", chromeHomeDir)
+		i.logger.Debug("created home directory for chromium process", "home", chromeHomeDir)
 
 		// Seems like on windows using headless chrome distributed by grafana-image-renderer
 		// produces a debug log of chrome in the same folder which violates the list of
@@ -117,12 +153,12 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 
 		// If we managed to make chrome home dir and find chrom exec from `grafana-image-renderer` use it.
 		if chromeExec != "" {
-			logger.Info("chrome executable provided by grafana-image-renderer will be used", "chrome", chromeExec)
+			i.logger.Info("chrome executable provided by grafana-image-renderer will be used", "chrome", chromeExec)
 			chromeOptions = append(chromeOptions, chromedp.ExecPath(chromeExec))
 		}
 	}
 
-	if insecureSkipVerify {
+	if i.insecureSkipVerify {
 		// Seems like this is critical. When it is not turned on there are no errors
 		// and plugin will exit without rendering any panels. Not sure why the error
 		// handling is failing here. So, add this option as default just to avoid
@@ -147,23 +183,25 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 		it is not normal that these will be updated regularly. So, we can live with
 		this side-effect without running into deep issues.
 	*/
-	allocCtx, _ := chromedp.NewExecAllocator(ctx, chromeOptions...)
+	allocCtx, allocCtxCancel := chromedp.NewExecAllocator(i.parentCtx, chromeOptions...)
 
 	// start a browser (and an empty tab) so we can add more tabs to the browser
-	chromeLogger := logger.With("subsystem", "chromium")
+	chromeLogger := i.logger.With("subsystem", "chromium")
 	browserCtx, _ := chromedp.NewContext(allocCtx,
 		chromedp.WithErrorf(chromeLogger.Error),
 		chromedp.WithLogf(chromeLogger.Debug),
 	)
 
 	if err := chromedp.Run(browserCtx); err != nil {
-		return nil, fmt.Errorf("couldn't create browser context: %w", err)
+		allocCtxCancel()
+
+		return fmt.Errorf("couldn't create browser context: %w", err)
 	}
 
-	return &LocalInstance{
-		allocCtx,
-		browserCtx,
-	}, nil
+	i.allocCtx, i.allocCtxCancel, i.browserCtx = allocCtx, allocCtxCancel, browserCtx
+
+	return nil
+
 }
 
 // Name returns the kind of browser instance.
@@ -171,19 +209,155 @@ func (i *LocalInstance) Name() string {
 	return "local"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *LocalInstance) NewTab(_ log.Logger, _ *config.Config) *Tab {
-	ctx, _ := chromedp.NewContext(i.browserCtx)
+// NewTab starts and returns a new tab on current browser instance. Local chrome
+// has no limit on open tabs and hence, it never waits for a free tab.
+func (i *LocalInstance) NewTab(_ context.Context, logger log.Logger, conf *config.Config) (*Tab, error) {
+	return i.newTab(logger, conf), nil
+}
+
+// newTab starts and returns a new tab on current browser instance. When tabs
+// are isolated, each tab runs in a new incognito-like browser context so that
+// tabs do not share cookies.
+func (i *LocalInstance) newTab(_ log.Logger, conf *config.Config) *Tab {
+	var opts []chromedp.ContextOption
+	if isolateTabs(conf) {
+		opts = append(opts, chromedp.WithNewBrowserContext())
+	}
+
+	i.mx.RLock()
+	browserCtx := i.browserCtx
+	i.mx.RUnlock()
+
+	ctx, _ := chromedp.NewContext(browserCtx, opts...)
 
 	return &Tab{
-		ctx: ctx,
+		ctx:          ctx,
+		blockedURLs:  blockedURLs(conf),
+		authHosts:    authHeaderHosts(conf),
+		clearCookies: !isolateTabs(conf),
+	}
+}
+
+// AcquireTab returns a tab from the pool of the instance. If all tabs of the pool
+// are in use, it blocks until one of them is released or ctx is done. Without a
+// pool, a new tab is returned.
+func (i *LocalInstance) AcquireTab(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error) {
+	if i.pool == nil {
+		return i.NewTab(ctx, logger, conf)
+	}
+
+	return i.pool.acquire(ctx, logger, conf)
+}
+
+// ReleaseTab resets the tab and gives it back to the pool of the instance.
+// Without a pool, the tab is closed.
+func (i *LocalInstance) ReleaseTab(logger log.Logger, tab *Tab) {
+	if i.pool == nil {
+		tab.Close(logger)
+
+		return
+	}
+
+	i.pool.release(logger, tab)
+}
+
+// CheckHealth checks that the browser is alive by navigating its initial tab to
+// a blank page. When the connection to the browser is lost, the browser has most
+// likely crashed and a new one is started in its place. Idle tabs of the pool
+// belonging to crashed browser are discarded. Browsers that are only slow to
+// respond are kept.
+func (i *LocalInstance) CheckHealth(logger log.Logger) error {
+	i.mx.RLock()
+
+	browserCtx := i.browserCtx
+	lost := disconnected(browserCtx)
+
+	if !lost {
+		ctx, cancel := context.WithTimeout(browserCtx, healthCheckTimeout)
+		err := chromedp.Run(ctx, chromedp.Navigate("about:blank"))
+
+		cancel()
+
+		if err != nil {
+			lost = disconnected(browserCtx)
+
+			if !lost {
+				logger.Warn("browser is slow to respond", "err", err)
+			}
+		}
+	}
+
+	i.mx.RUnlock()
+
+	if !lost {
+		return nil
+	}
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	// Browser might have been restarted by a concurrent request in the meantime
+	if i.browserCtx != browserCtx {
+		return nil
+	}
+
+	logger.Warn("lost connection to browser. Restarting it")
+
+	if i.pool != nil {
+		i.pool.close(logger)
+	}
+
+	i.stop(logger)
+
+	if err := i.start(); err != nil {
+		return fmt.Errorf("failed to restart browser: %w", err)
+	}
+
+	logger.Info("browser restarted")
+
+	return nil
+}
+
+// disconnected returns true when the browser of ctx is closed or the connection
+// to it is lost.
+func disconnected(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	c := chromedp.FromContext(ctx)
+	if c == nil || c.Browser == nil {
+		return true
+	}
+
+	select {
+	case <-c.Browser.LostConnection:
+		return true
+	default:
+		return false
 	}
 }
 
 func (i *LocalInstance) Close(logger log.Logger) {
+	if i.pool != nil {
+		i.pool.close(logger)
+	}
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	i.stop(logger)
+}
+
+// stop cancels the browser context and releases the resources of the browser.
+func (i *LocalInstance) stop(logger log.Logger) {
 	if i.browserCtx != nil {
 		if err := chromedp.Cancel(i.browserCtx); err != nil {
 			logger.Error("got error from cancel browser context", "error", err)
 		}
 	}
+
+	if i.allocCtxCancel != nil {
+		i.allocCtxCancel()
+	}
 }
@@ -0,0 +1,75 @@
+package chrome
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+)
+
+func TestLocalCheckHealth(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When checking health of a local browser", t, func() {
+		chromeInstance, err := NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 1)
+		So(err, ShouldBeNil)
+
+		defer chromeInstance.Close(log.NewNullLogger())
+
+		conf := &config.Config{}
+
+		tab, err := chromeInstance.AcquireTab(context.Background(), log.NewNullLogger(), conf)
+		So(err, ShouldBeNil)
+		So(tab.Run(chromedp.Navigate("about:blank")), ShouldBeNil)
+		chromeInstance.ReleaseTab(log.NewNullLogger(), tab)
+
+		Convey("Healthy browser should be kept", func() {
+			browserCtx := chromeInstance.browserCtx
+
+			So(chromeInstance.CheckHealth(log.NewNullLogger()), ShouldBeNil)
+			So(chromeInstance.browserCtx, ShouldEqual, browserCtx)
+		})
+
+		Convey("Crashed browser should be restarted", func() {
+			// Kill the browser to emulate a crash
+			So(chromedp.Cancel(chromeInstance.browserCtx), ShouldBeNil)
+
+			So(chromeInstance.CheckHealth(log.NewNullLogger()), ShouldBeNil)
+
+			tab, err := chromeInstance.AcquireTab(context.Background(), log.NewNullLogger(), conf)
+			So(err, ShouldBeNil)
+
+			defer chromeInstance.ReleaseTab(log.NewNullLogger(), tab)
+
+			So(tab.Run(chromedp.Navigate("about:blank")), ShouldBeNil)
+		})
+	})
+}
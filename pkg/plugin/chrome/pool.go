@@ -0,0 +1,210 @@
+package chrome
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"golang.org/x/net/context"
+)
+
+// Timeout to reset the state of a pooled tab while releasing it.
+const resetTimeout = 10 * time.Second
+
+// tabPool is a pool of reusable browser tabs. The buffered channel holds one
+// entry per tab of the pool and acquiring a tab blocks until one of the entries
+// is available. Tabs are started on their first use and a nil entry is the slot
+// of a tab that is not started yet.
+type tabPool struct {
+	tabs   chan *Tab
+	newTab func(logger log.Logger, conf *config.Config) *Tab
+}
+
+// newTabPool returns a new pool of size tabs made using newTab.
+func newTabPool(size int, newTab func(log.Logger, *config.Config) *Tab) *tabPool {
+	p := &tabPool{
+		tabs:   make(chan *Tab, size),
+		newTab: newTab,
+	}
+
+	for range size {
+		p.tabs <- nil
+	}
+
+	return p
+}
+
+// acquire returns an idle tab of the pool. If all tabs are in use, it blocks
+// until one of them is released or ctx is done.
+func (p *tabPool) acquire(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error) {
+	var tab *Tab
+
+	select {
+	case tab = <-p.tabs:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for a free tab of the pool: %w", ctx.Err())
+	}
+
+	if tab == nil {
+		tab = p.newTab(logger, conf)
+		tab.targetCtx = tab.ctx
+
+		// Start the tab with its own context so that the target is not closed
+		// when the context of a use of the tab is cancelled. If it fails, the
+		// error will surface in the first action of the tab and the tab will
+		// be discarded when it is released
+		if err := chromedp.Run(tab.targetCtx); err != nil {
+			logger.Warn("failed to start pooled tab", "err", err)
+		}
+	}
+
+	tab.use(conf)
+
+	return tab, nil
+}
+
+// release resets the tab and puts it back in the pool. Tabs that cannot be
+// reset are closed and a new one will be started in their place.
+func (p *tabPool) release(logger log.Logger, tab *Tab) {
+	if err := tab.reset(); err != nil {
+		logger.Warn("failed to reset pooled tab. Discarding it", "err", err)
+
+		tab.Close(logger)
+		tab = nil
+	}
+
+	p.tabs <- tab
+}
+
+// close closes the idle tabs of the pool. Slots of the closed tabs are kept so
+// that new tabs are started in their place.
+func (p *tabPool) close(logger log.Logger) {
+	p.discard(logger, func(*Tab) bool { return true })
+}
+
+// discardLost closes the idle tabs of the pool that lost their connection to
+// the browser.
+func (p *tabPool) discardLost(logger log.Logger) {
+	p.discard(logger, (*Tab).lostConnection)
+}
+
+// discard closes the idle tabs of the pool matching match and replaces them by
+// empty slots.
+func (p *tabPool) discard(logger log.Logger, match func(*Tab) bool) {
+	for range len(p.tabs) {
+		select {
+		case tab := <-p.tabs:
+			if tab != nil && match(tab) {
+				tab.Close(logger)
+				tab = nil
+			}
+
+			p.tabs <- tab
+		default:
+			return
+		}
+	}
+}
+
+// use prepares the pooled tab for a new use with conf. Actions of the use run
+// in a child context of the tab that is cancelled when the tab is released.
+func (t *Tab) use(conf *config.Config) {
+	t.ctx, t.cancelUse = context.WithCancel(t.targetCtx)
+	t.blockedURLs = blockedURLs(conf)
+	t.authHosts = authHeaderHosts(conf)
+}
+
+// addOrigin records the origin of addr navigated by the current use of the
+// pooled tab.
+func (t *Tab) addOrigin(addr string) {
+	u, err := url.Parse(addr)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return
+	}
+
+	origin := u.Scheme + "://" + u.Host
+
+	t.mx.Lock()
+	if !slices.Contains(t.origins, origin) {
+		t.origins = append(t.origins, origin)
+	}
+	t.mx.Unlock()
+}
+
+// reset cancels the current use of the pooled tab and restores it to a blank
+// page without cookies, storage of navigated origins, HTTP cache, extra headers,
+// request interception and viewport emulation so that nothing leaks to the next
+// use of the tab.
+func (t *Tab) reset() error {
+	// Cancelling contexts of the use removes the listeners added during it
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	if t.cancelUse != nil {
+		t.cancelUse()
+		t.cancelUse = nil
+	}
+
+	t.ctx = t.targetCtx
+
+	t.mx.Lock()
+	intercepting := t.intercepting
+	t.intercepting = false
+	t.authOrigin = ""
+	t.authHeaders = nil
+	origins := t.origins
+	t.origins = nil
+	t.mx.Unlock()
+
+	ctx, cancel := context.WithTimeout(t.targetCtx, resetTimeout)
+	defer cancel()
+
+	tasks := chromedp.Tasks{
+		// Session storage belongs to the page and is cleared before leaving it
+		chromedp.Evaluate(`try { window.sessionStorage.clear() } catch (e) {}`, nil),
+		network.Enable(),
+		network.SetExtraHTTPHeaders(network.Headers{}),
+		network.ClearBrowserCookies(),
+		network.ClearBrowserCache(),
+		emulation.ClearDeviceMetricsOverride(),
+		chromedp.Navigate("about:blank"),
+	}
+
+	// Local storage, IndexedDB, etc of the origins are kept by the browser
+	// context of the tab and must be cleared explicitly
+	for _, origin := range origins {
+		tasks = append(tasks, storage.ClearDataForOrigin(origin, "all"))
+	}
+
+	if intercepting {
+		tasks = append(chromedp.Tasks{fetch.Disable()}, tasks...)
+	}
+
+	return chromedp.Run(ctx, tasks) //nolint:wrapcheck
+}
+
+// lostConnection returns true when the connection to the browser of the tab
+// is lost.
+func (t *Tab) lostConnection() bool {
+	c := chromedp.FromContext(t.ctx)
+	if c == nil || c.Browser == nil {
+		return false
+	}
+
+	select {
+	case <-c.Browser.LostConnection:
+		return true
+	default:
+		return false
+	}
+}
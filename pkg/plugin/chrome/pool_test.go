@@ -0,0 +1,93 @@
+package chrome
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+)
+
+func TestTabPool(t *testing.T) {
+	Convey("When acquiring tabs from a pool", t, func() {
+		var created, open atomic.Int32
+
+		// Tabs without browser cannot be reset and hence, they are closed and
+		// replaced by new ones when released
+		pool := newTabPool(2, func(_ log.Logger, _ *config.Config) *Tab {
+			created.Add(1)
+			open.Add(1)
+
+			return &Tab{ctx: context.Background(), release: func() { open.Add(-1) }}
+		})
+		defer pool.close(log.NewNullLogger())
+
+		first, _ := pool.acquire(context.Background(), log.NewNullLogger(), nil)
+		second, _ := pool.acquire(context.Background(), log.NewNullLogger(), nil)
+
+		acquired := make(chan *Tab)
+
+		go func() {
+			third, _ := pool.acquire(context.Background(), log.NewNullLogger(), nil)
+			acquired <- third
+		}()
+
+		Convey("Acquiring more tabs than pool size should block until a tab is released", func() {
+			select {
+			case <-acquired:
+				t.Fatal("tab acquired beyond pool size")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			So(created.Load(), ShouldEqual, 2)
+
+			pool.release(log.NewNullLogger(), first)
+
+			select {
+			case third := <-acquired:
+				So(third, ShouldNotBeNil)
+				So(open.Load(), ShouldEqual, 2)
+
+				pool.release(log.NewNullLogger(), third)
+			case <-time.After(5 * time.Second):
+				t.Fatal("tab not acquired after releasing a tab")
+			}
+
+			pool.release(log.NewNullLogger(), second)
+
+			So(open.Load(), ShouldEqual, 0)
+			So(len(pool.tabs), ShouldEqual, 2)
+		})
+
+		Convey("Acquiring a tab should fail when context is done while waiting", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := pool.acquire(ctx, log.NewNullLogger(), nil)
+			So(err, ShouldWrap, context.DeadlineExceeded)
+
+			pool.release(log.NewNullLogger(), first)
+			pool.release(log.NewNullLogger(), <-acquired)
+			pool.release(log.NewNullLogger(), second)
+		})
+
+		Convey("Closing pool should close idle tabs and keep their slots", func() {
+			pool.release(log.NewNullLogger(), first)
+			pool.release(log.NewNullLogger(), <-acquired)
+			pool.release(log.NewNullLogger(), second)
+
+			// Put an idle tab in the pool
+			<-pool.tabs
+			pool.tabs <- &Tab{ctx: context.Background(), release: func() { open.Add(-1) }}
+			open.Add(1)
+
+			pool.close(log.NewNullLogger())
+
+			So(open.Load(), ShouldEqual, 0)
+			So(len(pool.tabs), ShouldEqual, 2)
+		})
+	})
+}
@@ -1,23 +1,143 @@
 package chrome
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"golang.org/x/net/context"
 )
 
+const (
+	// DevTools protocol version that chromedp speaks.
+	supportedProtocolVersion = "1.3"
+
+	// Oldest Chrome major version known to work with chromedp.
+	minBrowserMajorVersion = 110
+
+	// Timeout for querying version endpoint of remote chrome.
+	versionTimeout = 5 * time.Second
+)
+
+// ErrIncompatibleBrowser is returned when remote chrome is not compatible with chromedp.
+var ErrIncompatibleBrowser = errors.New("incompatible remote chrome")
+
+// Version is the response of /json/version endpoint of remote chrome.
+type Version struct {
+	Browser         string `json:"Browser"`
+	ProtocolVersion string `json:"Protocol-Version"`
+	UserAgent       string `json:"User-Agent"`
+}
+
+// MajorVersion returns the major version of browser. Returns 0 when
+// version cannot be parsed.
+func (v Version) MajorVersion() int {
+	_, ver, found := strings.Cut(v.Browser, "/")
+	if !found {
+		return 0
+	}
+
+	major, _, _ := strings.Cut(ver, ".")
+
+	m, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+
+	return m
+}
+
+// Check returns an error when browser version is known to be incompatible.
+func (v Version) Check() error {
+	if v.ProtocolVersion != supportedProtocolVersion {
+		return fmt.Errorf(
+			"%w: protocol version %s is not supported, expected %s",
+			ErrIncompatibleBrowser, v.ProtocolVersion, supportedProtocolVersion,
+		)
+	}
+
+	if major := v.MajorVersion(); major > 0 && major < minBrowserMajorVersion {
+		return fmt.Errorf(
+			"%w: browser %s is older than minimum supported version %d",
+			ErrIncompatibleBrowser, v.Browser, minBrowserMajorVersion,
+		)
+	}
+
+	return nil
+}
+
 // RemoteInstance is a remotely running browser instance.
 type RemoteInstance struct {
 	allocCtx       context.Context
 	allocCtxCancel context.CancelFunc
+
+	remoteChromeURL string
+
+	// Semaphore to limit number of concurrently open tabs
+	tabs chan struct{}
+
+	// Pool of reusable tabs
+	pool *tabPool
 }
 
-// NewRemoteBrowserInstance creates a new remote browser instance.
-func NewRemoteBrowserInstance(ctx context.Context, _ log.Logger, remoteChromeURL string) (*RemoteInstance, error) {
+// NewRemoteBrowserInstance creates a new remote browser instance. When maxTabs is
+// positive, creation of new tabs blocks until number of open tabs is below maxTabs.
+// When poolSize is positive, a pool of poolSize reusable tabs is made for the
+// instance. Tabs of the pool are counted in the open tabs for their whole lifetime
+// and hence, pool size is limited so that at least one other tab can be opened.
+func NewRemoteBrowserInstance(ctx context.Context, logger log.Logger, remoteChromeURL string, maxTabs, poolSize int) (*RemoteInstance, error) {
 	allocCtx, allocCtxCancel := chromedp.NewRemoteAllocator(ctx, remoteChromeURL)
 
-	return &RemoteInstance{allocCtx, allocCtxCancel}, nil
+	instance := &RemoteInstance{
+		allocCtx:        allocCtx,
+		allocCtxCancel:  allocCtxCancel,
+		remoteChromeURL: remoteChromeURL,
+	}
+	if maxTabs > 0 {
+		instance.tabs = make(chan struct{}, maxTabs)
+
+		if poolSize >= maxTabs {
+			logger.Warn("reducing pool of tabs to leave room for other tabs on remote chrome", "pool_size", poolSize, "max_tabs", maxTabs)
+
+			poolSize = maxTabs - 1
+		}
+
+		// Reserve the slots of pooled tabs
+		for range poolSize {
+			instance.tabs <- struct{}{}
+		}
+	}
+
+	if poolSize > 0 {
+		instance.pool = newTabPool(poolSize, func(logger log.Logger, conf *config.Config) *Tab {
+			return instance.newTab(logger, conf, nil)
+		})
+	}
+
+	// Remote chrome might not be up yet. So, only log the version
+	// and compatibility issues and do not fail here
+	version, err := instance.Version(ctx)
+	if err != nil {
+		logger.Warn("failed to get version of remote chrome", "url", remoteChromeURL, "err", err)
+
+		return instance, nil
+	}
+
+	logger.Info("remote chrome version", "browser", version.Browser, "protocol", version.ProtocolVersion)
+
+	if err := version.Check(); err != nil {
+		logger.Warn("remote chrome might not work as expected", "err", err)
+	}
+
+	return instance, nil
 }
 
 // Name returns the kind of browser instance.
@@ -25,22 +145,172 @@ func (i *RemoteInstance) Name() string {
 	return "remote"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *RemoteInstance) NewTab(logger log.Logger, _ *config.Config) *Tab {
+// Version queries the /json/version endpoint of remote chrome.
+func (i *RemoteInstance) Version(ctx context.Context) (*Version, error) {
+	u, err := versionURL(i.remoteChromeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %w", u, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request for %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching %s: status code %d", u, resp.StatusCode)
+	}
+
+	var version Version
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return nil, fmt.Errorf("error decoding version of remote chrome: %w", err)
+	}
+
+	return &version, nil
+}
+
+// NewTab starts and returns a new tab on current browser instance. If the
+// maximum number of tabs are already open, it blocks until one of them is closed
+// or ctx is done.
+func (i *RemoteInstance) NewTab(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error) {
+	var release func()
+
+	if i.tabs != nil {
+		if err := i.waitForTab(ctx); err != nil {
+			return nil, err
+		}
+
+		release = func() { <-i.tabs }
+	}
+
+	return i.newTab(logger, conf, release), nil
+}
+
+// waitForTab blocks until number of open tabs is below the maximum and takes
+// a slot for a new tab. It returns an error when ctx is done in the meantime.
+func (i *RemoteInstance) waitForTab(ctx context.Context) error {
+	select {
+	case i.tabs <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for a free tab of remote chrome: %w", ctx.Err())
+	}
+}
+
+// newTab starts and returns a new tab on current browser instance. release is
+// called when the tab is closed.
+func (i *RemoteInstance) newTab(logger log.Logger, conf *config.Config, release func()) *Tab {
 	chromeLogger := logger.With("subsystem", "chromium")
 	browserCtx, _ := chromedp.NewContext(i.allocCtx,
 		chromedp.WithErrorf(chromeLogger.Error),
 		chromedp.WithLogf(chromeLogger.Debug),
 	)
 
+	if isolateTabs(conf) {
+		// A new browser context can only be created once connected to browser.
+		// If connection fails, fallback to a tab in default browser context
+		// and clear cookies when it is closed
+		if err := chromedp.Run(browserCtx); err != nil {
+			logger.Warn("failed to connect to remote chrome to isolate tab", "err", err)
+		} else {
+			ctx, _ := chromedp.NewContext(browserCtx, chromedp.WithNewBrowserContext())
+
+			return &Tab{
+				ctx:         ctx,
+				blockedURLs: blockedURLs(conf),
+				authHosts:   authHeaderHosts(conf),
+				release: func() {
+					// Close the connection to browser as well
+					_ = chromedp.Cancel(browserCtx)
+
+					if release != nil {
+						release()
+					}
+				},
+			}
+		}
+	}
+
 	return &Tab{
-		ctx: browserCtx,
+		ctx:          browserCtx,
+		blockedURLs:  blockedURLs(conf),
+		authHosts:    authHeaderHosts(conf),
+		release:      release,
+		clearCookies: true,
 	}
 }
 
+// AcquireTab returns a tab from the pool of the instance. If all tabs of the pool
+// are in use, it blocks until one of them is released or ctx is done. Without a
+// pool, a new tab is returned.
+func (i *RemoteInstance) AcquireTab(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error) {
+	if i.pool == nil {
+		return i.NewTab(ctx, logger, conf)
+	}
+
+	return i.pool.acquire(ctx, logger, conf)
+}
+
+// ReleaseTab resets the tab and gives it back to the pool of the instance.
+// Without a pool, the tab is closed.
+func (i *RemoteInstance) ReleaseTab(logger log.Logger, tab *Tab) {
+	if i.pool == nil {
+		tab.Close(logger)
+
+		return
+	}
+
+	i.pool.release(logger, tab)
+}
+
+// CheckHealth discards the idle tabs of the pool that lost their connection to
+// remote chrome, for instance, when it is restarted. Other tabs make a new
+// connection to remote chrome and hence, need no recovery.
+func (i *RemoteInstance) CheckHealth(logger log.Logger) error {
+	if i.pool != nil {
+		i.pool.discardLost(logger)
+	}
+
+	return nil
+}
+
 // Close releases the resources of browser instance.
-func (i *RemoteInstance) Close(_ log.Logger) {
+func (i *RemoteInstance) Close(logger log.Logger) {
+	if i.pool != nil {
+		i.pool.close(logger)
+	}
+
 	if i.allocCtxCancel != nil {
 		i.allocCtxCancel()
 	}
 }
+
+// versionURL returns the HTTP URL of version endpoint from the
+// websocket URL of remote chrome.
+func versionURL(remoteChromeURL string) (string, error) {
+	u, err := url.Parse(remoteChromeURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid remote chrome url %s: %w", remoteChromeURL, err)
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	u.Path = "/json/version"
+	u.RawQuery = ""
+
+	return u.String(), nil
+}
@@ -0,0 +1,159 @@
+package chrome
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+)
+
+func TestRemoteVersion(t *testing.T) {
+	Convey("When querying version of remote chrome", t, func() {
+		var versionJSON string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/json/version" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(versionJSON))
+		}))
+		defer ts.Close()
+
+		remoteChromeURL := strings.Replace(ts.URL, "http://", "ws://", 1)
+
+		Convey("Compatible version should pass the check", func() {
+			versionJSON = `{"Browser": "HeadlessChrome/131.0.6778.85", "Protocol-Version": "1.3"}`
+
+			instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), remoteChromeURL, 0, 0)
+			So(err, ShouldBeNil)
+
+			defer instance.Close(log.NewNullLogger())
+
+			version, err := instance.Version(context.Background())
+			So(err, ShouldBeNil)
+			So(version.Browser, ShouldEqual, "HeadlessChrome/131.0.6778.85")
+			So(version.MajorVersion(), ShouldEqual, 131)
+			So(version.Check(), ShouldBeNil)
+		})
+
+		Convey("Unsupported protocol version should fail the check", func() {
+			versionJSON = `{"Browser": "HeadlessChrome/131.0.6778.85", "Protocol-Version": "1.2"}`
+
+			instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), remoteChromeURL, 0, 0)
+			So(err, ShouldBeNil)
+
+			defer instance.Close(log.NewNullLogger())
+
+			version, err := instance.Version(context.Background())
+			So(err, ShouldBeNil)
+			So(version.Check(), ShouldWrap, ErrIncompatibleBrowser)
+		})
+
+		Convey("Old browser version should fail the check", func() {
+			versionJSON = `{"Browser": "Chrome/90.0.4430.93", "Protocol-Version": "1.3"}`
+
+			instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), remoteChromeURL, 0, 0)
+			So(err, ShouldBeNil)
+
+			defer instance.Close(log.NewNullLogger())
+
+			version, err := instance.Version(context.Background())
+			So(err, ShouldBeNil)
+			So(version.Check(), ShouldWrap, ErrIncompatibleBrowser)
+		})
+
+		Convey("Unreachable remote chrome should not fail creating instance", func() {
+			instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), "ws://127.0.0.1:1", 0, 0)
+			So(err, ShouldBeNil)
+
+			defer instance.Close(log.NewNullLogger())
+
+			_, err = instance.Version(context.Background())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRemoteMaxTabs(t *testing.T) {
+	Convey("When creating tabs on remote chrome with maximum tabs", t, func() {
+		instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), "ws://127.0.0.1:1", 1, 0)
+		So(err, ShouldBeNil)
+
+		defer instance.Close(log.NewNullLogger())
+
+		tab, err := instance.NewTab(context.Background(), log.NewNullLogger(), nil)
+		So(err, ShouldBeNil)
+
+		created := make(chan *Tab)
+
+		go func() {
+			second, _ := instance.NewTab(context.Background(), log.NewNullLogger(), nil)
+			created <- second
+		}()
+
+		Convey("New tab should block until an open tab is closed", func() {
+			select {
+			case <-created:
+				t.Fatal("tab created beyond maximum tabs")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			tab.Close(log.NewNullLogger())
+
+			select {
+			case second := <-created:
+				So(second, ShouldNotBeNil)
+				second.Close(log.NewNullLogger())
+			case <-time.After(5 * time.Second):
+				t.Fatal("tab not created after closing open tab")
+			}
+		})
+
+		Convey("New tab should fail when context is done while waiting", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := instance.NewTab(ctx, log.NewNullLogger(), nil)
+			So(err, ShouldWrap, context.DeadlineExceeded)
+
+			tab.Close(log.NewNullLogger())
+
+			second := <-created
+			second.Close(log.NewNullLogger())
+		})
+	})
+
+	Convey("When making a pool of tabs on remote chrome with maximum tabs", t, func() {
+		instance, err := NewRemoteBrowserInstance(context.Background(), log.NewNullLogger(), "ws://127.0.0.1:1", 3, 5)
+		So(err, ShouldBeNil)
+
+		defer instance.Close(log.NewNullLogger())
+
+		Convey("Pool should be limited to leave room for another tab", func() {
+			So(cap(instance.pool.tabs), ShouldEqual, 2)
+		})
+
+		Convey("Slots of pooled tabs should be reserved in open tabs", func() {
+			So(len(instance.tabs), ShouldEqual, 2)
+
+			tab, err := instance.NewTab(context.Background(), log.NewNullLogger(), nil)
+			So(err, ShouldBeNil)
+
+			defer tab.Close(log.NewNullLogger())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err = instance.NewTab(ctx, log.NewNullLogger(), nil)
+			So(err, ShouldWrap, context.DeadlineExceeded)
+		})
+	})
+}
@@ -4,14 +4,22 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"golang.org/x/net/context"
 )
 
@@ -19,10 +27,74 @@ var WithAwaitPromise = func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithAwaitPromise(true)
 }
 
+// defaultBlockedURLs are the URL patterns blocked in browser tabs to avoid
+// unnecessary requests.
+var defaultBlockedURLs = []string{"*/api/frontend-metrics", "*/api/live/ws", "*/api/user/*"}
+
 // Tab is container for a browser tab.
 type Tab struct {
-	ctx    context.Context
-	cancel context.CancelFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+	blockedURLs []string
+	release     func()
+
+	// When true, browser cookies are cleared while closing the tab. Tabs
+	// running in their own browser context do not need it
+	clearCookies bool
+
+	// Host patterns to which auth headers are forwarded along with the host
+	// of the page. When empty, auth headers are sent with all requests
+	authHosts []string
+
+	// State of request interception used to forward auth headers
+	mx           sync.Mutex
+	intercepting bool
+	authOrigin   string
+	authHeaders  map[string]any
+
+	// Context of the browser target of pooled tabs and cancel function of the
+	// context of their current use
+	targetCtx context.Context
+	cancelUse context.CancelFunc
+
+	// Origins navigated by the current use of pooled tabs whose storage is
+	// cleared when they are released
+	origins []string
+}
+
+// blockedURLs returns the URL patterns to block in browser tabs by merging
+// default blocked URLs and the ones configured by the user.
+func blockedURLs(conf *config.Config) []string {
+	if conf == nil {
+		return defaultBlockedURLs
+	}
+
+	urls := make([]string, 0, len(defaultBlockedURLs)+len(conf.BlockedURLs))
+
+	for _, u := range append(slices.Clone(defaultBlockedURLs), conf.BlockedURLs...) {
+		if slices.Contains(conf.UnblockedURLs, u) || slices.Contains(urls, u) {
+			continue
+		}
+
+		urls = append(urls, u)
+	}
+
+	return urls
+}
+
+// authHeaderHosts returns the host patterns to which auth headers are forwarded.
+func authHeaderHosts(conf *config.Config) []string {
+	if conf == nil {
+		return nil
+	}
+
+	return conf.AuthHeaderHosts
+}
+
+// isolateTabs returns true when tabs must run in their own browser context
+// instead of clearing cookies of the browser when they are closed.
+func isolateTabs(conf *config.Config) bool {
+	return conf != nil && !conf.ClearCookiesOnTabClose
 }
 
 // Close releases the resources of the current browser tab.
@@ -31,8 +103,10 @@ func (t *Tab) Close(logger log.Logger) {
 		var err error
 
 		// Clear browser cookies to ensure no session is left
-		if err = chromedp.Run(t.ctx, network.ClearBrowserCookies()); err != nil {
-			logger.Error("got error from clear browser cookies", "error", err)
+		if t.clearCookies {
+			if err = chromedp.Run(t.ctx, network.ClearBrowserCookies()); err != nil {
+				logger.Error("got error from clear browser cookies", "error", err)
+			}
 		}
 
 		if err = chromedp.Cancel(t.ctx); err != nil {
@@ -43,24 +117,39 @@ func (t *Tab) Close(logger log.Logger) {
 			t.cancel()
 		}
 	}
+
+	// Free the slot of the tab on browser instance
+	if t.release != nil {
+		t.release()
+		t.release = nil
+	}
 }
 
 // NavigateAndWaitFor navigates to the given address and waits for the given event to be fired on the page.
 func (t *Tab) NavigateAndWaitFor(addr string, headers map[string]any, eventName string) error {
 	if err := t.Run(
 		// block some URLs to avoid unnecessary requests
-		network.SetBlockedURLS([]string{"*/api/frontend-metrics", "*/api/live/ws", "*/api/user/*"}),
+		network.SetBlockedURLS(t.blockedURLs),
 		enableLifeCycleEvents(),
 	); err != nil {
 		return fmt.Errorf("error enable lifecycle events: %w", err)
 	}
 
-	if headers != nil {
+	switch {
+	case headers != nil && len(t.authHosts) > 0:
+		if err := t.Run(t.forwardHeaders(addr, headers)); err != nil {
+			return fmt.Errorf("error forward headers: %w", err)
+		}
+	case headers != nil:
 		if err := t.Run(setHeaders(headers)); err != nil {
 			return fmt.Errorf("error set headers: %w", err)
 		}
 	}
 
+	if t.targetCtx != nil {
+		t.addOrigin(addr)
+	}
+
 	resp, err := chromedp.RunResponse(t.ctx, chromedp.Navigate(addr))
 	if err != nil {
 		return fmt.Errorf("failed navigate to %s: %w", addr, err)
@@ -77,6 +166,110 @@ func (t *Tab) NavigateAndWaitFor(addr string, headers map[string]any, eventName
 	return nil
 }
 
+// forwardHeaders returns an action that intercepts the requests of the tab to add
+// headers only to the requests made to the host of addr or to the hosts matching
+// the auth host patterns of the tab. Interception is enabled only once per tab and
+// later calls update the headers and host.
+func (t *Tab) forwardHeaders(addr string, headers map[string]any) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address %s: %w", addr, err)
+		}
+
+		t.mx.Lock()
+		t.authOrigin = u.Host
+		t.authHeaders = headers
+		intercepting := t.intercepting
+		t.intercepting = true
+		t.mx.Unlock()
+
+		if intercepting {
+			return nil
+		}
+
+		tabCtx := t.ctx
+
+		chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+			if e, ok := ev.(*fetch.EventRequestPaused); ok {
+				// Listeners must not block and hence, continue requests in
+				// a separate goroutine
+				go t.continueRequest(tabCtx, e)
+			}
+		})
+
+		return fetch.Enable().Do(ctx)
+	}
+}
+
+// continueRequest continues the paused request with auth headers when its host
+// is allowed.
+func (t *Tab) continueRequest(tabCtx context.Context, e *fetch.EventRequestPaused) {
+	ctx := cdp.WithExecutor(tabCtx, chromedp.FromContext(tabCtx).Target)
+
+	params := fetch.ContinueRequest(e.RequestID)
+
+	t.mx.Lock()
+	if isAuthHost(e.Request.URL, t.authOrigin, t.authHosts) {
+		params = params.WithHeaders(mergeHeaders(e.Request.Headers, t.authHeaders))
+	}
+	t.mx.Unlock()
+
+	// Fails when tab is closed before request is continued
+	_ = params.Do(ctx)
+}
+
+// isAuthHost returns true if auth headers must be forwarded to the rawURL. Auth
+// headers are always forwarded to origin host and to the hosts matching any of
+// the patterns. Patterns are matched against host both with and without port.
+func isAuthHost(rawURL, origin string, patterns []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	if u.Host == origin {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, u.Host); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, u.Hostname()); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeHeaders returns request headers with headers added. Request headers with
+// the same names as headers are replaced.
+func mergeHeaders(reqHeaders network.Headers, headers map[string]any) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(reqHeaders)+len(headers))
+
+	names := make(map[string]bool, len(headers))
+	for name := range headers {
+		names[strings.ToLower(name)] = true
+	}
+
+	for name, value := range reqHeaders {
+		if names[strings.ToLower(name)] {
+			continue
+		}
+
+		entries = append(entries, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+	}
+
+	for name, value := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+	}
+
+	return entries
+}
+
 // WithTimeout set the timeout for the actions in the current tab.
 func (t *Tab) WithTimeout(timeout time.Duration) {
 	t.ctx, t.cancel = context.WithTimeout(t.ctx, timeout)
@@ -120,32 +313,8 @@ func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
 			return page.SetDocumentContent(frameTree.Frame.ID, options.Body).Do(ctx)
 		}),
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			var (
-				err        error
-				pageParams *page.PrintToPDFParams
-			)
-
-			// In CI mode do not add header and footer for visual comparison
-			if os.Getenv("__REPORTER_APP_CI_MODE") == "true" {
-				pageParams = page.PrintToPDF().
-					WithPreferCSSPageSize(true)
-			} else {
-				pageParams = page.PrintToPDF().
-					WithDisplayHeaderFooter(true).
-					WithHeaderTemplate(options.Header).
-					WithFooterTemplate(options.Footer).
-					WithPreferCSSPageSize(true)
-			}
-
-			pageParams = pageParams.WithTransferMode(page.PrintToPDFTransferModeReturnAsStream)
-
-			// If landscape add it to page params
-			if options.Orientation == "landscape" {
-				pageParams = pageParams.WithLandscape(true)
-			}
-
 			// Finally execute and get PDF buffer
-			_, stream, err := pageParams.Do(ctx)
+			_, stream, err := printToPDFParams(options).Do(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to print to PDF: %w", err)
 			}
@@ -166,3 +335,47 @@ func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
 
 	return nil
 }
+
+// printToPDFParams returns the parameters for printing the page into PDF.
+func printToPDFParams(options PDFOptions) *page.PrintToPDFParams {
+	var pageParams *page.PrintToPDFParams
+
+	// In CI mode do not add header and footer for visual comparison
+	if options.DisableHeaderFooter || os.Getenv("__REPORTER_APP_CI_MODE") == "true" {
+		pageParams = page.PrintToPDF().
+			WithPreferCSSPageSize(true)
+	} else {
+		pageParams = page.PrintToPDF().
+			WithDisplayHeaderFooter(true).
+			WithHeaderTemplate(options.Header).
+			WithFooterTemplate(options.Footer).
+			WithPreferCSSPageSize(true)
+	}
+
+	pageParams = pageParams.
+		WithTransferMode(page.PrintToPDFTransferModeReturnAsStream).
+		WithMarginTop(options.MarginTop).
+		WithMarginBottom(options.MarginBottom).
+		WithMarginLeft(options.MarginLeft).
+		WithMarginRight(options.MarginRight)
+
+	// Paper size takes precedence over page size of CSS
+	if options.PaperWidth > 0 && options.PaperHeight > 0 {
+		pageParams = pageParams.
+			WithPreferCSSPageSize(false).
+			WithPaperWidth(options.PaperWidth).
+			WithPaperHeight(options.PaperHeight)
+	}
+
+	// If landscape add it to page params
+	if options.Orientation == "landscape" {
+		pageParams = pageParams.WithLandscape(true)
+	}
+
+	// Outline is generated from the heading structure of tagged PDF
+	if options.GenerateOutline {
+		pageParams = pageParams.WithGenerateTaggedPDF(true).WithGenerateDocumentOutline(true)
+	}
+
+	return pageParams
+}
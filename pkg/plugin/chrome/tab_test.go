@@ -0,0 +1,401 @@
+package chrome
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+)
+
+func TestPrintToPDFParams(t *testing.T) {
+	Convey("When making parameters to print PDF", t, func() {
+		options := PDFOptions{
+			Header:      "<div>header</div>",
+			Body:        "<div>body</div>",
+			Footer:      "<div>footer</div>",
+			Orientation: "landscape",
+		}
+
+		Convey("Header and footer should be included by default", func() {
+			params := printToPDFParams(options)
+
+			So(params.DisplayHeaderFooter, ShouldBeTrue)
+			So(params.HeaderTemplate, ShouldEqual, "<div>header</div>")
+			So(params.FooterTemplate, ShouldEqual, "<div>footer</div>")
+			So(params.Landscape, ShouldBeTrue)
+		})
+
+		Convey("Header and footer should be omitted when disabled", func() {
+			options.DisableHeaderFooter = true
+			params := printToPDFParams(options)
+
+			So(params.DisplayHeaderFooter, ShouldBeFalse)
+			So(params.HeaderTemplate, ShouldBeEmpty)
+			So(params.FooterTemplate, ShouldBeEmpty)
+			So(params.Landscape, ShouldBeTrue)
+		})
+
+		Convey("Tagged PDF with outline should be generated when enabled", func() {
+			So(printToPDFParams(options).GenerateDocumentOutline, ShouldBeFalse)
+
+			options.GenerateOutline = true
+			params := printToPDFParams(options)
+
+			So(params.GenerateTaggedPDF, ShouldBeTrue)
+			So(params.GenerateDocumentOutline, ShouldBeTrue)
+		})
+
+		Convey("Page size of CSS should be used without paper size", func() {
+			params := printToPDFParams(options)
+
+			So(params.PreferCSSPageSize, ShouldBeTrue)
+			So(params.PaperWidth, ShouldBeZeroValue)
+		})
+
+		Convey("Page margins should be set", func() {
+			options.MarginTop, options.MarginBottom, options.MarginLeft, options.MarginRight = 1, 0.5, 0.25, 0.25
+			params := printToPDFParams(options)
+
+			So(params.MarginTop, ShouldEqual, 1)
+			So(params.MarginBottom, ShouldEqual, 0.5)
+			So(params.MarginLeft, ShouldEqual, 0.25)
+			So(params.MarginRight, ShouldEqual, 0.25)
+		})
+
+		Convey("Paper size should be used when set", func() {
+			options.PaperWidth, options.PaperHeight = 8.5, 14
+			params := printToPDFParams(options)
+
+			So(params.PreferCSSPageSize, ShouldBeFalse)
+			So(params.PaperWidth, ShouldEqual, 8.5)
+			So(params.PaperHeight, ShouldEqual, 14)
+			So(params.Landscape, ShouldBeTrue)
+		})
+
+		Convey("Header and footer should be omitted in CI mode", func() {
+			t.Setenv("__REPORTER_APP_CI_MODE", "true")
+
+			params := printToPDFParams(options)
+
+			So(params.DisplayHeaderFooter, ShouldBeFalse)
+			So(params.HeaderTemplate, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestBlockedURLs(t *testing.T) {
+	Convey("When merging blocked URLs of browser tabs", t, func() {
+		Convey("Default blocked URLs should be used without config", func() {
+			So(blockedURLs(nil), ShouldResemble, defaultBlockedURLs)
+			So(blockedURLs(&config.Config{}), ShouldResemble, defaultBlockedURLs)
+		})
+
+		Convey("Configured blocked URLs should be merged with defaults", func() {
+			conf := &config.Config{
+				BlockedURLs: []string{"*/api/annotations*", "*/api/user/*"},
+			}
+
+			So(blockedURLs(conf), ShouldResemble, []string{
+				"*/api/frontend-metrics", "*/api/live/ws", "*/api/user/*", "*/api/annotations*",
+			})
+		})
+
+		Convey("Unblocked URLs should be removed from blocked URLs", func() {
+			conf := &config.Config{
+				BlockedURLs:   []string{"*/api/annotations*"},
+				UnblockedURLs: []string{"*/api/live/ws"},
+			}
+
+			So(blockedURLs(conf), ShouldResemble, []string{
+				"*/api/frontend-metrics", "*/api/user/*", "*/api/annotations*",
+			})
+		})
+
+		Convey("Default blocked URLs should not be modified", func() {
+			blockedURLs(&config.Config{BlockedURLs: []string{"*/api/annotations*"}})
+
+			So(defaultBlockedURLs, ShouldHaveLength, 3)
+		})
+	})
+}
+
+func TestAuthHeaderHosts(t *testing.T) {
+	Convey("When forwarding auth headers to hosts", t, func() {
+		patterns := []string{"*.example.com", "images.internal:8443"}
+
+		Convey("Auth headers should be forwarded to origin host", func() {
+			So(isAuthHost("http://grafana:3000/d/abc", "grafana:3000", patterns), ShouldBeTrue)
+			So(isAuthHost("http://grafana:4000/d/abc", "grafana:3000", patterns), ShouldBeFalse)
+		})
+
+		Convey("Auth headers should be forwarded to hosts matching patterns", func() {
+			So(isAuthHost("https://cdn.example.com/logo.png", "grafana:3000", patterns), ShouldBeTrue)
+			So(isAuthHost("https://cdn.example.com:8080/logo.png", "grafana:3000", patterns), ShouldBeTrue)
+			So(isAuthHost("https://images.internal:8443/logo.png", "grafana:3000", patterns), ShouldBeTrue)
+			So(isAuthHost("https://images.internal/logo.png", "grafana:3000", patterns), ShouldBeFalse)
+			So(isAuthHost("https://example.com.evil.org/logo.png", "grafana:3000", patterns), ShouldBeFalse)
+		})
+
+		Convey("Auth headers should not be forwarded to URLs without host", func() {
+			So(isAuthHost("data:image/png;base64,abc", "grafana:3000", patterns), ShouldBeFalse)
+		})
+
+		Convey("Auth headers should replace request headers with same names", func() {
+			entries := mergeHeaders(
+				map[string]any{"authorization": "Bearer old", "Accept": "image/png"},
+				map[string]any{"Authorization": "Bearer new"},
+			)
+
+			headers := make(map[string]string)
+			for _, e := range entries {
+				headers[e.Name] = e.Value
+			}
+
+			So(headers, ShouldResemble, map[string]string{"Accept": "image/png", "Authorization": "Bearer new"})
+		})
+	})
+}
+
+func TestForwardAuthHeaders(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When loading a page with an image requiring authentication", t, func() {
+		chromeInstance, err := NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		So(err, ShouldBeNil)
+
+		var (
+			mx   sync.Mutex
+			auth []string
+		)
+
+		// External server serves the image only to authenticated requests
+		images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mx.Lock()
+			auth = append(auth, r.Header.Get("Authorization"))
+			mx.Unlock()
+
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+
+			w.Header().Set("Content-Type", "image/svg+xml")
+			fmt.Fprint(w, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"/>`)
+		}))
+		defer images.Close()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, `<html><body><img id="img" src="%s/logo.svg"></body></html>`, images.URL)
+		}))
+		defer ts.Close()
+
+		headers := map[string]any{"Authorization": "Bearer token"}
+
+		loaded := func(conf *config.Config) bool {
+			tab, _ := chromeInstance.NewTab(context.Background(), log.NewNullLogger(), conf)
+			defer tab.Close(log.NewNullLogger())
+
+			err := tab.NavigateAndWaitFor(ts.URL, headers, "load")
+			So(err, ShouldBeNil)
+
+			var complete bool
+
+			err = tab.Run(chromedp.Evaluate(`document.getElementById("img").naturalWidth > 0`, &complete))
+			So(err, ShouldBeNil)
+
+			return complete
+		}
+
+		Convey("Image should be loaded when its host matches auth header hosts", func() {
+			So(loaded(&config.Config{AuthHeaderHosts: []string{"127.0.0.1:*"}}), ShouldBeTrue)
+		})
+
+		Convey("Auth headers should not be sent to hosts not matching auth header hosts", func() {
+			So(loaded(&config.Config{AuthHeaderHosts: []string{"*.example.com"}}), ShouldBeFalse)
+
+			mx.Lock()
+			defer mx.Unlock()
+
+			So(auth, ShouldNotContain, "Bearer token")
+		})
+	})
+}
+
+func TestIsolatedTabs(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When running concurrent tabs with different credentials", t, func() {
+		chromeInstance, err := NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		So(err, ShouldBeNil)
+
+		// Server issues a session cookie for the user in Authorization header
+		// and echoes the user of the session cookie sent by browser
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := r.Header.Get("Authorization")
+			if c, err := r.Cookie("session"); err == nil {
+				user = c.Value
+			}
+
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: user, Path: "/"})
+			fmt.Fprintf(w, "<html><body>%s</body></html>", user)
+		}))
+		defer ts.Close()
+
+		conf := &config.Config{ClearCookiesOnTabClose: false}
+
+		users := []string{"alice", "bob"}
+		seen := make([]string, len(users))
+
+		var wg sync.WaitGroup
+
+		for i, user := range users {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				tab, _ := chromeInstance.NewTab(context.Background(), log.NewNullLogger(), conf)
+				defer tab.Close(log.NewNullLogger())
+
+				// Load the page twice so that second request is authenticated
+				// by the session cookie of the tab
+				for range 2 {
+					if err := tab.NavigateAndWaitFor(ts.URL, map[string]any{"Authorization": user}, "load"); err != nil {
+						return
+					}
+				}
+
+				_ = tab.Run(chromedp.Text("body", &seen[i], chromedp.ByQuery))
+			}()
+		}
+
+		wg.Wait()
+
+		Convey("Each tab should only see its own session", func() {
+			So(seen, ShouldResemble, users)
+		})
+	})
+}
+
+func TestPrintToPDFOutline(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When printing a page with headings to PDF", t, func() {
+		chromeInstance, err := NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		So(err, ShouldBeNil)
+
+		options := PDFOptions{
+			Body:                "<html><body><h1>Dashboard</h1><h2>Row</h2><h3>Panel</h3></body></html>",
+			DisableHeaderFooter: true,
+		}
+
+		printPDF := func() string {
+			tab, _ := chromeInstance.NewTab(context.Background(), log.NewNullLogger(), &config.Config{})
+			defer tab.Close(log.NewNullLogger())
+
+			var buf bytes.Buffer
+
+			So(tab.PrintToPDF(options, &buf), ShouldBeNil)
+
+			return buf.String()
+		}
+
+		Convey("PDF should not have outline by default", func() {
+			So(printPDF(), ShouldNotContainSubstring, "/Outlines")
+		})
+
+		Convey("PDF should have outline when enabled", func() {
+			options.GenerateOutline = true
+
+			So(printPDF(), ShouldContainSubstring, "/Outlines")
+		})
+	})
+}
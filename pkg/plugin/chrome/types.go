@@ -3,6 +3,7 @@ package chrome
 import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"golang.org/x/net/context"
 )
 
 // PDFOptions contains the templated HTML Body, Header and Footer strings.
@@ -11,12 +12,33 @@ type PDFOptions struct {
 	Body   string
 	Footer string
 
-	Orientation string
+	Orientation         string
+	DisableHeaderFooter bool
+
+	// Paper dimensions in inches. When unset, page size of CSS is used
+	PaperWidth  float64
+	PaperHeight float64
+
+	// Page margins in inches
+	MarginTop    float64
+	MarginBottom float64
+	MarginLeft   float64
+	MarginRight  float64
+
+	// Generate tagged PDF with an outline made from headings
+	GenerateOutline bool
 }
 
-// Instance is the interface remote and local chrome must implement.
+// Instance is the interface remote and local chrome must implement. NewTab
+// returns a one-off tab that must be closed after use whereas AcquireTab returns
+// a tab of the pool of the instance that must be given back using ReleaseTab.
+// Both return an error when ctx is done while waiting for a free tab. CheckHealth
+// recovers the instance when browser has crashed.
 type Instance interface {
-	NewTab(logger log.Logger, conf *config.Config) *Tab
+	NewTab(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error)
+	AcquireTab(ctx context.Context, logger log.Logger, conf *config.Config) (*Tab, error)
+	ReleaseTab(logger log.Logger, tab *Tab)
+	CheckHealth(logger log.Logger) error
 	Name() string
 	Close(logger log.Logger)
 }
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownProfile is returned when a report profile is not found in config.
+var ErrUnknownProfile = errors.New("unknown report profile")
+
+// ProfileConfig is a named preset of report settings. Empty fields of the
+// profile do not override the config.
+type ProfileConfig struct {
+	Theme               string            `json:"theme"`
+	Layout              string            `json:"layout"`
+	Orientation         string            `json:"orientation"`
+	DashboardMode       string            `json:"dashboardMode"`
+	IncludePanelIDs     []string          `json:"includePanelIds"`
+	ExcludePanelIDs     []string          `json:"excludePanelIds"`
+	IncludePanelDataIDs []string          `json:"includePanelDataIds"`
+	Variables           map[string]string `json:"variables"`
+}
+
+// validate checks the settings of the profile.
+func (p ProfileConfig) validate() error {
+	for _, s := range []struct {
+		name, value string
+		valid       []string
+	}{
+		{"theme", p.Theme, validThemes},
+		{"layout", p.Layout, validLayouts},
+		{"orientation", p.Orientation, validOrientations},
+		{"dashboard mode", p.DashboardMode, validModes},
+	} {
+		if s.value != "" && !slices.Contains(s.valid, s.value) {
+			return fmt.Errorf("%s: %s must be one of [%s]", s.name, s.value, strings.Join(s.valid, ","))
+		}
+	}
+
+	for name := range p.Variables {
+		if name == "" {
+			return errors.New("variables must have non empty names")
+		}
+	}
+
+	return nil
+}
+
+// ApplyProfile overrides the config with the settings of report profile name.
+func (c *Config) ApplyProfile(name string) error {
+	p, ok := c.ReportProfiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+
+	if p.Theme != "" {
+		c.Theme = p.Theme
+	}
+
+	if p.Layout != "" {
+		c.Layout = p.Layout
+	}
+
+	if p.Orientation != "" {
+		c.Orientation = p.Orientation
+	}
+
+	if p.DashboardMode != "" {
+		c.DashboardMode = p.DashboardMode
+	}
+
+	if len(p.IncludePanelIDs) > 0 {
+		c.IncludePanelIDs = p.IncludePanelIDs
+	}
+
+	if len(p.ExcludePanelIDs) > 0 {
+		c.ExcludePanelIDs = p.ExcludePanelIDs
+	}
+
+	if len(p.IncludePanelDataIDs) > 0 {
+		c.IncludePanelDataIDs = p.IncludePanelDataIDs
+	}
+
+	return nil
+}
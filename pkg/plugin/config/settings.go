@@ -1,10 +1,16 @@
 package config
 
 import (
+	"compress/flate"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -17,20 +23,55 @@ import (
 
 const SaToken = "saToken"
 
+// BasicAuthPasswordKey is the key of basic auth password in secure JSON data.
+const BasicAuthPasswordKey = "basicAuthPassword"
+
+// DefaultGridColumns is the number of columns of Grafana dashboard grid.
+const DefaultGridColumns = 24
+
+// Default number of pixels per grid unit of panel width and height when
+// rendering panels in grid layout.
+const (
+	DefaultGridWidthScale  = 100
+	DefaultGridHeightScale = 36
+)
+
+// Maximum device scale factor supported by grafana-image-renderer.
+const MaxDeviceScaleFactor = 4
+
+// Width and height of paper sizes in inches.
+var paperDimensions = map[string][2]float64{
+	"A4":     {8.27, 11.69},
+	"A3":     {11.69, 16.54},
+	"Letter": {8.5, 11},
+	"Legal":  {8.5, 14},
+}
+
 // Valid setting parameters.
 var (
-	validThemes       = []string{"light", "dark"}
-	validLayouts      = []string{"simple", "grid"}
-	validOrientations = []string{"portrait", "landscape"}
-	validModes        = []string{"default", "full"}
+	validThemes           = []string{"light", "dark"}
+	validLayouts          = []string{"simple", "grid"}
+	validOrientations     = []string{"portrait", "landscape"}
+	validPaperSizes       = []string{"A4", "A3", "Letter", "Legal"}
+	validModes            = []string{"default", "full"}
+	validErrorActions     = []string{"fail", "warn", "continue"}
+	validSources          = []string{"both", "api", "browser"}
+	validFilenamePolicies = []string{"none", "ascii", "underscore", "strict"}
+	validFailModes        = []string{"open", "closed"}
+	validWeekStarts       = map[string]time.Weekday{"sunday": time.Sunday, "monday": time.Monday, "saturday": time.Saturday}
+	validColorRegex       = regexp.MustCompile(`^(#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})|[a-zA-Z]+)$`)
+	validExtensionRegex   = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 )
 
 // Config contains plugin settings.
 type Config struct {
 	AppURL              string `env:"GF_REPORTER_PLUGIN_APP_URL, overwrite"                json:"appUrl"`
 	SkipTLSCheck        bool   `env:"GF_REPORTER_PLUGIN_SKIP_TLS_CHECK, overwrite"         json:"skipTlsCheck"`
+	ClientCertFile      string `env:"GF_REPORTER_PLUGIN_CLIENT_CERT_FILE, overwrite"       json:"clientCertFile"`
+	ClientKeyFile       string `env:"GF_REPORTER_PLUGIN_CLIENT_KEY_FILE, overwrite"        json:"clientKeyFile"`
 	Theme               string `env:"GF_REPORTER_PLUGIN_REPORT_THEME, overwrite"           json:"theme"`
 	Orientation         string `env:"GF_REPORTER_PLUGIN_REPORT_ORIENTATION, overwrite"     json:"orientation"`
+	PaperSize           string `env:"GF_REPORTER_PLUGIN_REPORT_PAPER_SIZE, overwrite"      json:"paperSize"`
 	Layout              string `env:"GF_REPORTER_PLUGIN_REPORT_LAYOUT, overwrite"          json:"layout"`
 	DashboardMode       string `env:"GF_REPORTER_PLUGIN_REPORT_DASHBOARD_MODE, overwrite"  json:"dashboardMode"`
 	TimeZone            string `env:"GF_REPORTER_PLUGIN_REPORT_TIMEZONE, overwrite"        json:"timeZone"`
@@ -41,20 +82,163 @@ type Config struct {
 	MaxBrowserWorkers   int    `env:"GF_REPORTER_PLUGIN_MAX_BROWSER_WORKERS, overwrite"    json:"maxBrowserWorkers"`
 	MaxRenderWorkers    int    `env:"GF_REPORTER_PLUGIN_MAX_RENDER_WORKERS, overwrite"     json:"maxRenderWorkers"`
 	RemoteChromeURL     string `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_URL, overwrite"      json:"remoteChromeUrl"`
+	RemoteChromeMaxTabs int    `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_MAX_TABS, overwrite" json:"remoteChromeMaxTabs"`
 	NativeRendering     bool   `env:"GF_REPORTER_PLUGIN_NATIVE_RENDERER, overwrite"        json:"nativeRenderer"`
 	AppVersion          string `json:"appVersion"`
 	IncludePanelIDs     []string
 	ExcludePanelIDs     []string
 	IncludePanelDataIDs []string
 
+	// Panel filters
+	IncludePanelTitleRegex string   `env:"GF_REPORTER_PLUGIN_INCLUDE_PANEL_TITLE_REGEX, overwrite" json:"includePanelTitleRegex"`
+	ExcludePanelTitleRegex string   `env:"GF_REPORTER_PLUGIN_EXCLUDE_PANEL_TITLE_REGEX, overwrite" json:"excludePanelTitleRegex"`
+	ExcludePanelTypes      []string `env:"GF_REPORTER_PLUGIN_EXCLUDE_PANEL_TYPES, overwrite"       json:"excludePanelTypes"`
+
+	// Authentication
+	AnonymousAccess         bool   `env:"GF_REPORTER_PLUGIN_ANONYMOUS_ACCESS, overwrite"           json:"anonymousAccess"`
+	PermissionCheckTimeout  int    `env:"GF_REPORTER_PLUGIN_PERMISSION_CHECK_TIMEOUT, overwrite"   json:"permissionCheckTimeout"`
+	PermissionCheckFailMode string `env:"GF_REPORTER_PLUGIN_PERMISSION_CHECK_FAIL_MODE, overwrite" json:"permissionCheckFailMode"`
+	BasicAuthUser           string `env:"GF_REPORTER_PLUGIN_BASIC_AUTH_USER, overwrite"            json:"basicAuthUser"`
+
+	// Rate limiting
+	RateLimit                      int  `env:"GF_REPORTER_PLUGIN_RATE_LIMIT, overwrite"                         json:"rateLimit"`
+	RateLimitExemptServiceAccounts bool `env:"GF_REPORTER_PLUGIN_RATE_LIMIT_EXEMPT_SERVICE_ACCOUNTS, overwrite" json:"rateLimitExemptServiceAccounts"`
+
+	// Circuit breaker
+	CircuitBreakerThreshold int `env:"GF_REPORTER_PLUGIN_CIRCUIT_BREAKER_THRESHOLD, overwrite" json:"circuitBreakerThreshold"`
+	CircuitBreakerCooldown  int `env:"GF_REPORTER_PLUGIN_CIRCUIT_BREAKER_COOLDOWN, overwrite"  json:"circuitBreakerCooldown"`
+
+	// Interactive requests
+	InteractiveBrowserWorkers int `env:"GF_REPORTER_PLUGIN_INTERACTIVE_BROWSER_WORKERS, overwrite" json:"interactiveBrowserWorkers"`
+	InteractiveRenderWorkers  int `env:"GF_REPORTER_PLUGIN_INTERACTIVE_RENDER_WORKERS, overwrite"  json:"interactiveRenderWorkers"`
+
+	// Repeated panels
+	OrderRepeatsByValue  bool `env:"GF_REPORTER_PLUGIN_REPORT_ORDER_REPEATS_BY_VALUE, overwrite" json:"orderRepeatsByValue"`
+	DedupeRepeatedPanels bool `env:"GF_REPORTER_PLUGIN_REPORT_DEDUPE_REPEATED_PANELS, overwrite" json:"dedupeRepeatedPanels"`
+
+	// Variables
+	ResolveDatasourceVariables bool `env:"GF_REPORTER_PLUGIN_RESOLVE_DATASOURCE_VARIABLES, overwrite" json:"resolveDatasourceVariables"`
+
+	// Report content
+	CoverPage             bool              `env:"GF_REPORTER_PLUGIN_REPORT_COVER_PAGE, overwrite"              json:"coverPage"`
+	TableOfContents       bool              `env:"GF_REPORTER_PLUGIN_REPORT_TABLE_OF_CONTENTS, overwrite"       json:"tableOfContents"`
+	VariableSummaryTable  bool              `env:"GF_REPORTER_PLUGIN_REPORT_VARIABLE_SUMMARY_TABLE, overwrite"  json:"variableSummaryTable"`
+	ExecutiveSummary      bool              `env:"GF_REPORTER_PLUGIN_REPORT_EXECUTIVE_SUMMARY, overwrite"       json:"executiveSummary"`
+	ShowTimeZoneInLabels  bool              `env:"GF_REPORTER_PLUGIN_REPORT_SHOW_TIMEZONE_IN_LABELS, overwrite" json:"showTimeZoneInLabels"`
+	DisableHeaderFooter   bool              `env:"GF_REPORTER_PLUGIN_REPORT_DISABLE_HEADER_FOOTER, overwrite"   json:"disableHeaderFooter"`
+	IncludePanelIndex     bool              `env:"GF_REPORTER_PLUGIN_REPORT_INCLUDE_PANEL_INDEX, overwrite"     json:"includePanelIndex"`
+	LinkPanelsToLive      bool              `env:"GF_REPORTER_PLUGIN_REPORT_LINK_PANELS_TO_LIVE, overwrite"     json:"linkPanelsToLive"`
+	GenerateOutline       bool              `env:"GF_REPORTER_PLUGIN_REPORT_GENERATE_OUTLINE, overwrite"        json:"generateOutline"`
+	ShowPageNumbers       bool              `env:"GF_REPORTER_PLUGIN_REPORT_SHOW_PAGE_NUMBERS, overwrite"       json:"showPageNumbers"`
+	SectionSeparatorPage  bool              `env:"GF_REPORTER_PLUGIN_REPORT_SECTION_SEPARATOR_PAGE, overwrite"  json:"sectionSeparatorPage"`
+	SectionSeparatorTitle bool              `env:"GF_REPORTER_PLUGIN_REPORT_SECTION_SEPARATOR_TITLE, overwrite" json:"sectionSeparatorTitle"`
+	Glossary              map[string]string `env:"GF_REPORTER_PLUGIN_REPORT_GLOSSARY, overwrite"                json:"glossary"`
+	ReportMetadata        map[string]string `env:"GF_REPORTER_PLUGIN_REPORT_METADATA, overwrite"                json:"reportMetadata"`
+	GroupByTitlePrefix    string            `env:"GF_REPORTER_PLUGIN_REPORT_GROUP_BY_TITLE_PREFIX, overwrite"   json:"groupByTitlePrefix"`
+
+	// Time range
+	DefaultTimeRange      []string `env:"GF_REPORTER_PLUGIN_REPORT_DEFAULT_TIME_RANGE, overwrite"       json:"defaultTimeRange"`
+	UseDashboardSavedTime bool     `env:"GF_REPORTER_PLUGIN_REPORT_USE_DASHBOARD_SAVED_TIME, overwrite" json:"useDashboardSavedTime"`
+	TimeRangeHeaders      bool     `env:"GF_REPORTER_PLUGIN_TIME_RANGE_HEADERS, overwrite"              json:"timeRangeHeaders"`
+	FirstDayOfWeek        string   `env:"GF_REPORTER_PLUGIN_REPORT_FIRST_DAY_OF_WEEK, overwrite"        json:"firstDayOfWeek"`
+	FiscalYearStartMonth  int      `env:"GF_REPORTER_PLUGIN_REPORT_FISCAL_YEAR_START_MONTH, overwrite"  json:"fiscalYearStartMonth"`
+
+	// Stat panels
+	StatPanelsAsText bool   `env:"GF_REPORTER_PLUGIN_REPORT_STAT_PANELS_AS_TEXT, overwrite" json:"statPanelsAsText"`
+	StatNumberFormat string `env:"GF_REPORTER_PLUGIN_REPORT_STAT_NUMBER_FORMAT, overwrite"  json:"statNumberFormat"`
+	StatUnits        bool   `env:"GF_REPORTER_PLUGIN_REPORT_STAT_UNITS, overwrite"          json:"statUnits"`
+
+	// Page margins in inches
+	MarginTop    float64 `env:"GF_REPORTER_PLUGIN_REPORT_MARGIN_TOP, overwrite"    json:"marginTop"`
+	MarginBottom float64 `env:"GF_REPORTER_PLUGIN_REPORT_MARGIN_BOTTOM, overwrite" json:"marginBottom"`
+	MarginLeft   float64 `env:"GF_REPORTER_PLUGIN_REPORT_MARGIN_LEFT, overwrite"   json:"marginLeft"`
+	MarginRight  float64 `env:"GF_REPORTER_PLUGIN_REPORT_MARGIN_RIGHT, overwrite"  json:"marginRight"`
+
+	// Panel style
+	PanelBorderWidth int    `env:"GF_REPORTER_PLUGIN_REPORT_PANEL_BORDER_WIDTH, overwrite" json:"panelBorderWidth"`
+	PanelBorderColor string `env:"GF_REPORTER_PLUGIN_REPORT_PANEL_BORDER_COLOR, overwrite" json:"panelBorderColor"`
+	PanelShadow      bool   `env:"GF_REPORTER_PLUGIN_REPORT_PANEL_SHADOW, overwrite"       json:"panelShadow"`
+	PanelBackground  string `env:"GF_REPORTER_PLUGIN_REPORT_PANEL_BACKGROUND, overwrite"   json:"panelBackground"`
+
+	// Panel rendering
+	AutoFallbackRenderer bool    `env:"GF_REPORTER_PLUGIN_AUTO_FALLBACK_RENDERER, overwrite" json:"autoFallbackRenderer"`
+	AutoDetectRenderer   bool    `env:"GF_REPORTER_PLUGIN_AUTO_DETECT_RENDERER, overwrite"   json:"autoDetectRenderer"`
+	RenderTimeout        int     `env:"GF_REPORTER_PLUGIN_RENDER_TIMEOUT, overwrite"         json:"renderTimeout"`
+	DeviceScaleFactor    float64 `env:"GF_REPORTER_PLUGIN_DEVICE_SCALE_FACTOR, overwrite"    json:"deviceScaleFactor"`
+	MaxDataPoints        int     `env:"GF_REPORTER_PLUGIN_MAX_DATA_POINTS, overwrite"        json:"maxDataPoints"`
+	MinImageBytes        int     `env:"GF_REPORTER_PLUGIN_MIN_IMAGE_BYTES, overwrite"        json:"minImageBytes"`
+	DisableAutoRefresh   bool    `env:"GF_REPORTER_PLUGIN_DISABLE_AUTO_REFRESH, overwrite"   json:"disableAutoRefresh"`
+	DefaultPanelWidth    int     `env:"GF_REPORTER_PLUGIN_DEFAULT_PANEL_WIDTH, overwrite"    json:"defaultPanelWidth"`
+	DefaultPanelHeight   int     `env:"GF_REPORTER_PLUGIN_DEFAULT_PANEL_HEIGHT, overwrite"   json:"defaultPanelHeight"`
+	PanelPNGCache        bool    `env:"GF_REPORTER_PLUGIN_PANEL_PNG_CACHE, overwrite"        json:"panelPngCache"`
+	DeterministicRender  bool    `env:"GF_REPORTER_PLUGIN_DETERMINISTIC_RENDER, overwrite"   json:"deterministicRender"`
+	FullPageScreenshot   bool    `env:"GF_REPORTER_PLUGIN_FULL_PAGE_SCREENSHOT, overwrite"   json:"fullPageScreenshot"`
+	AdaptiveConcurrency  bool    `env:"GF_REPORTER_PLUGIN_ADAPTIVE_CONCURRENCY, overwrite"   json:"adaptiveConcurrency"`
+	DisableSharedTooltip bool    `env:"GF_REPORTER_PLUGIN_DISABLE_SHARED_TOOLTIP, overwrite" json:"disableSharedTooltip"`
+
+	// Datasource concurrency
+	DatasourceConcurrency map[string]int `env:"GF_REPORTER_PLUGIN_DATASOURCE_CONCURRENCY, overwrite" json:"datasourceConcurrency"`
+
+	// Panel metadata
+	MetadataSource   string `env:"GF_REPORTER_PLUGIN_METADATA_SOURCE, overwrite"   json:"metadataSource"`
+	MetadataFallback bool   `env:"GF_REPORTER_PLUGIN_METADATA_FALLBACK, overwrite" json:"metadataFallback"`
+
+	// Dashboard errors
+	OnDashboardError     string  `env:"GF_REPORTER_PLUGIN_ON_DASHBOARD_ERROR, overwrite"      json:"onDashboardError"`
+	MaxPanelFailureRatio float64 `env:"GF_REPORTER_PLUGIN_MAX_PANEL_FAILURE_RATIO, overwrite" json:"maxPanelFailureRatio"`
+
+	// Grid layout
+	GridColumns     int     `env:"GF_REPORTER_PLUGIN_REPORT_GRID_COLUMNS, overwrite"      json:"gridColumns"`
+	PanelsPerPage   int     `env:"GF_REPORTER_PLUGIN_REPORT_PANELS_PER_PAGE, overwrite"   json:"panelsPerPage"`
+	GridWidthScale  float64 `env:"GF_REPORTER_PLUGIN_REPORT_GRID_WIDTH_SCALE, overwrite"  json:"gridWidthScale"`
+	GridHeightScale float64 `env:"GF_REPORTER_PLUGIN_REPORT_GRID_HEIGHT_SCALE, overwrite" json:"gridHeightScale"`
+
+	// Browser
+	BlockedURLs            []string `env:"GF_REPORTER_PLUGIN_BLOCKED_URLS, overwrite"               json:"blockedUrls"`
+	UnblockedURLs          []string `env:"GF_REPORTER_PLUGIN_UNBLOCKED_URLS, overwrite"             json:"unblockedUrls"`
+	ClearCookiesOnTabClose bool     `env:"GF_REPORTER_PLUGIN_CLEAR_COOKIES_ON_TAB_CLOSE, overwrite" json:"clearCookiesOnTabClose"`
+	AuthHeaderHosts        []string `env:"GF_REPORTER_PLUGIN_AUTH_HEADER_HOSTS, overwrite"          json:"authHeaderHosts"`
+	MaxRequestTimeout      int      `env:"GF_REPORTER_PLUGIN_MAX_REQUEST_TIMEOUT, overwrite"        json:"maxRequestTimeout"`
+
+	// Panel data
+	CSVKioskMode        bool              `env:"GF_REPORTER_PLUGIN_CSV_KIOSK_MODE, overwrite"            json:"csvKioskMode"`
+	TableColumnStats    bool              `env:"GF_REPORTER_PLUGIN_REPORT_TABLE_COLUMN_STATS, overwrite" json:"tableColumnStats"`
+	CSVHeaderRenames    map[string]string `env:"GF_REPORTER_PLUGIN_CSV_HEADER_RENAMES, overwrite"        json:"csvHeaderRenames"`
+	ApplyFieldOverrides bool              `env:"GF_REPORTER_PLUGIN_APPLY_FIELD_OVERRIDES, overwrite"     json:"applyFieldOverrides"`
+	NDJSONParseNumbers  bool              `env:"GF_REPORTER_PLUGIN_NDJSON_PARSE_NUMBERS, overwrite"      json:"ndjsonParseNumbers"`
+	CombinedPanels      []string          `env:"GF_REPORTER_PLUGIN_COMBINED_PANELS, overwrite"           json:"combinedPanels"`
+
+	// Exports
+	IncludeManifest   bool   `env:"GF_REPORTER_PLUGIN_INCLUDE_MANIFEST, overwrite"   json:"includeManifest"`
+	FilenamePolicy    string `env:"GF_REPORTER_PLUGIN_FILENAME_POLICY, overwrite"    json:"filenamePolicy"`
+	FilenameExtension string `env:"GF_REPORTER_PLUGIN_FILENAME_EXTENSION, overwrite" json:"filenameExtension"`
+	AttachPanelData   bool   `env:"GF_REPORTER_PLUGIN_ATTACH_PANEL_DATA, overwrite"  json:"attachPanelData"`
+	CompressionLevel  int    `env:"GF_REPORTER_PLUGIN_COMPRESSION_LEVEL, overwrite"  json:"compressionLevel"`
+
+	// Report profiles
+	ReportProfiles map[string]ProfileConfig `json:"reportProfiles"`
+
+	// Logging and tracing
+	LogRedaction   bool `env:"GF_REPORTER_PLUGIN_LOG_REDACTION, overwrite"   json:"logRedaction"`
+	TracingEnabled bool `env:"GF_REPORTER_PLUGIN_TRACING_ENABLED, overwrite" json:"tracingEnabled"`
+
 	// Time location
-	Location *time.Location
+	Location  *time.Location
+	WeekStart time.Weekday
+
+	// Timeout of browser tabs set by timeout query parameter of request
+	RequestTimeout time.Duration `json:"-"`
+
+	// Compiled panel title regexes
+	IncludePanelTitle *regexp.Regexp `json:"-"`
+	ExcludePanelTitle *regexp.Regexp `json:"-"`
 
 	// HTTP Client
 	HTTPClientOptions httpclient.Options
 
 	// Secrets
-	Token string
+	Token             string
+	BasicAuthPassword string
 }
 
 // Validate checks current settings and sets them to defaults for invalid ones.
@@ -74,11 +258,46 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("orientation: %s must be one of [%s]", c.Orientation, strings.Join(validOrientations, ","))
 	}
 
+	// Check paper size
+	if !slices.Contains(validPaperSizes, c.PaperSize) {
+		return fmt.Errorf("paper size: %s must be one of [%s]", c.PaperSize, strings.Join(validPaperSizes, ","))
+	}
+
 	// Check Mode
 	if !slices.Contains(validModes, c.DashboardMode) {
 		return fmt.Errorf("dashboard mode: %s must be one of [%s]", c.DashboardMode, strings.Join(validModes, ","))
 	}
 
+	// Check panel metadata source
+	if !slices.Contains(validSources, c.MetadataSource) {
+		return fmt.Errorf("metadata source: %s must be one of [%s]", c.MetadataSource, strings.Join(validSources, ","))
+	}
+
+	// Check dashboard error action
+	if !slices.Contains(validErrorActions, c.OnDashboardError) {
+		return fmt.Errorf("on dashboard error: %s must be one of [%s]", c.OnDashboardError, strings.Join(validErrorActions, ","))
+	}
+
+	// Check panel failure ratio
+	if c.MaxPanelFailureRatio < 0 || c.MaxPanelFailureRatio > 1 {
+		return fmt.Errorf("max panel failure ratio: %v must be between 0 and 1", c.MaxPanelFailureRatio)
+	}
+
+	// Check filename policy
+	if !slices.Contains(validFilenamePolicies, c.FilenamePolicy) {
+		return fmt.Errorf("filename policy: %s must be one of [%s]", c.FilenamePolicy, strings.Join(validFilenamePolicies, ","))
+	}
+
+	// Check filename extension
+	if !validExtensionRegex.MatchString(c.FilenameExtension) {
+		return fmt.Errorf("filename extension: %s must contain only alphanumeric characters", c.FilenameExtension)
+	}
+
+	// Check compression level of ZIP and XLSX exports
+	if c.CompressionLevel < flate.DefaultCompression || c.CompressionLevel > flate.BestCompression {
+		return fmt.Errorf("compression level: %d must be between %d and %d", c.CompressionLevel, flate.DefaultCompression, flate.BestCompression)
+	}
+
 	// Set time zone to current server time zone if empty
 	if loc, err := time.LoadLocation(c.TimeZone); err != nil || c.TimeZone == "" {
 		c.Location = time.Now().Local().Location()
@@ -88,12 +307,204 @@ func (c *Config) Validate() error {
 		c.TimeZone = loc.String()
 	}
 
+	// Check default time range
+	if len(c.DefaultTimeRange) != 2 || slices.Contains(c.DefaultTimeRange, "") {
+		return fmt.Errorf("default time range: %v must contain non empty from and to times", c.DefaultTimeRange)
+	}
+
+	// Check first day of week
+	weekStart, ok := validWeekStarts[strings.ToLower(c.FirstDayOfWeek)]
+	if !ok {
+		return fmt.Errorf("first day of week: %s must be one of [sunday,monday,saturday]", c.FirstDayOfWeek)
+	}
+
+	c.WeekStart = weekStart
+
+	// Check first month of fiscal year
+	if c.FiscalYearStartMonth < 1 || c.FiscalYearStartMonth > 12 {
+		return fmt.Errorf("fiscal year start month: %d must be between 1 and 12", c.FiscalYearStartMonth)
+	}
+
 	// Set time format to time.UnixDate if the provided one is invalid
 	t := time.Now().Format(c.TimeFormat)
 	if parsedTime, err := time.Parse(c.TimeFormat, t); err != nil || parsedTime.Unix() <= 0 {
 		c.TimeFormat = time.UnixDate
 	}
 
+	// Check stat number format is a valid format for a single float
+	if c.StatNumberFormat != "" {
+		if v := fmt.Sprintf(c.StatNumberFormat, 1.0); strings.Contains(v, "%!") {
+			return fmt.Errorf("stat number format: %s is not a valid number format", c.StatNumberFormat)
+		}
+	}
+
+	// Check grid columns
+	if c.GridColumns <= 0 {
+		return fmt.Errorf("grid columns: %d must be a positive number", c.GridColumns)
+	}
+
+	// Check grid scales
+	if c.GridWidthScale <= 0 || c.GridHeightScale <= 0 {
+		return fmt.Errorf("grid scales: %v and %v must be positive numbers", c.GridWidthScale, c.GridHeightScale)
+	}
+
+	// Check client certificate
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return errors.New("client certificate: both certificate and key files must be set")
+	}
+
+	// Check basic auth
+	if c.BasicAuthPassword != "" && c.BasicAuthUser == "" {
+		return errors.New("basic auth: user must be set when password is configured")
+	}
+
+	// Check panels per page
+	if c.PanelsPerPage < 0 {
+		return fmt.Errorf("panels per page: %d must be a positive number", c.PanelsPerPage)
+	}
+
+	// Check interactive workers
+	if c.InteractiveBrowserWorkers < 0 || c.InteractiveRenderWorkers < 0 {
+		return fmt.Errorf(
+			"interactive workers: %d and %d must be positive numbers",
+			c.InteractiveBrowserWorkers, c.InteractiveRenderWorkers,
+		)
+	}
+
+	// Check remote chrome tabs
+	if c.RemoteChromeMaxTabs < 0 {
+		return fmt.Errorf("remote chrome max tabs: %d must be a positive number", c.RemoteChromeMaxTabs)
+	}
+
+	// Check page margins
+	if c.MarginTop < 0 || c.MarginBottom < 0 || c.MarginLeft < 0 || c.MarginRight < 0 {
+		return fmt.Errorf(
+			"page margins: %v, %v, %v and %v must be non-negative numbers of inches",
+			c.MarginTop, c.MarginRight, c.MarginBottom, c.MarginLeft,
+		)
+	}
+
+	// Check panel border
+	if c.PanelBorderWidth < 0 {
+		return fmt.Errorf("panel border width: %d must be a positive number", c.PanelBorderWidth)
+	}
+
+	if c.PanelBorderColor != "" && !validColorRegex.MatchString(c.PanelBorderColor) {
+		return fmt.Errorf("panel border color: %s must be a hex color code or a color name", c.PanelBorderColor)
+	}
+
+	if c.PanelBackground != "" && !validColorRegex.MatchString(c.PanelBackground) {
+		return fmt.Errorf("panel background: %s must be a hex color code or a color name", c.PanelBackground)
+	}
+
+	// Check device scale factor
+	if c.DeviceScaleFactor < 0 || c.DeviceScaleFactor > MaxDeviceScaleFactor {
+		return fmt.Errorf("device scale factor: %v must be between 0 and %d", c.DeviceScaleFactor, MaxDeviceScaleFactor)
+	}
+
+	// Check max data points
+	if c.MaxDataPoints < 0 {
+		return fmt.Errorf("max data points: %d must be a positive number", c.MaxDataPoints)
+	}
+
+	// Check minimum image size
+	if c.MinImageBytes < 0 {
+		return fmt.Errorf("min image bytes: %d must be a positive number", c.MinImageBytes)
+	}
+
+	// Check excluded panel types
+	if slices.Contains(c.ExcludePanelTypes, "") {
+		return fmt.Errorf("exclude panel types: %v must not contain empty types", c.ExcludePanelTypes)
+	}
+
+	// Compile panel title regexes
+	var err error
+
+	if c.IncludePanelTitle, err = compileRegex(c.IncludePanelTitleRegex); err != nil {
+		return fmt.Errorf("include panel title regex: %s is not a valid regex: %w", c.IncludePanelTitleRegex, err)
+	}
+
+	if c.ExcludePanelTitle, err = compileRegex(c.ExcludePanelTitleRegex); err != nil {
+		return fmt.Errorf("exclude panel title regex: %s is not a valid regex: %w", c.ExcludePanelTitleRegex, err)
+	}
+
+	// Check CSV header rename patterns
+	for pattern := range c.CSVHeaderRenames {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("csv header rename: %s is not a valid regex: %w", pattern, err)
+		}
+	}
+
+	// Check default panel dimensions
+	if c.DefaultPanelWidth < 0 || c.DefaultPanelHeight < 0 {
+		return fmt.Errorf("default panel dimensions: %dx%d must be positive numbers", c.DefaultPanelWidth, c.DefaultPanelHeight)
+	}
+
+	// Check rate limit
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate limit: %d must be a positive number of requests per minute", c.RateLimit)
+	}
+
+	// Check circuit breaker
+	if c.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("circuit breaker threshold: %d must be a positive number of failures", c.CircuitBreakerThreshold)
+	}
+
+	if c.CircuitBreakerCooldown < 0 || (c.CircuitBreakerThreshold > 0 && c.CircuitBreakerCooldown == 0) {
+		return fmt.Errorf("circuit breaker cooldown: %d must be a positive number of seconds", c.CircuitBreakerCooldown)
+	}
+
+	// Check datasource concurrency limits
+	for dsType, limit := range c.DatasourceConcurrency {
+		if limit <= 0 {
+			return fmt.Errorf("datasource concurrency: limit %d of %s must be a positive number", limit, dsType)
+		}
+	}
+
+	// Check permission check timeout and fail mode
+	if c.PermissionCheckTimeout < 0 {
+		return fmt.Errorf("permission check timeout: %d must be a positive number of seconds", c.PermissionCheckTimeout)
+	}
+
+	if !slices.Contains(validFailModes, c.PermissionCheckFailMode) {
+		return fmt.Errorf("permission check fail mode: %s must be one of [%s]", c.PermissionCheckFailMode, strings.Join(validFailModes, ","))
+	}
+
+	// Check render timeout
+	if c.RenderTimeout < 0 {
+		return fmt.Errorf("render timeout: %d must be a positive number of seconds", c.RenderTimeout)
+	}
+
+	// Check maximum request timeout
+	if c.MaxRequestTimeout <= 0 {
+		return fmt.Errorf("max request timeout: %d must be a positive number of seconds", c.MaxRequestTimeout)
+	}
+
+	// Check blocked and unblocked URL patterns
+	for _, pattern := range append(slices.Clone(c.BlockedURLs), c.UnblockedURLs...) {
+		if pattern == "" || strings.ContainsAny(pattern, " \t\n") {
+			return fmt.Errorf("url pattern: %q must be a non empty pattern without spaces", pattern)
+		}
+	}
+
+	// Check host patterns of auth headers
+	for _, pattern := range c.AuthHeaderHosts {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" || strings.ContainsAny(pattern, " \t\n/") {
+			return fmt.Errorf("auth header host: %q must be a valid host pattern", pattern)
+		}
+	}
+
+	// Check report profiles
+	for name, profile := range c.ReportProfiles {
+		if name == "" {
+			return errors.New("report profile: profiles must have non empty names")
+		}
+
+		if err := profile.validate(); err != nil {
+			return fmt.Errorf("report profile %s: %w", name, err)
+		}
+	}
+
 	// Verify RemoteChromeURL
 	// url.Parse almost allows all the URLs. Need to check Scheme and Host
 	if c.RemoteChromeURL != "" {
@@ -114,6 +525,84 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// compileRegex compiles pattern. Empty patterns return nil regex.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// FiscalYearStart returns the first month of the fiscal year. Fiscal year starts
+// in January when month is unset.
+func (c *Config) FiscalYearStart() time.Month {
+	if c.FiscalYearStartMonth < 1 || c.FiscalYearStartMonth > 12 {
+		return time.January
+	}
+
+	return time.Month(c.FiscalYearStartMonth)
+}
+
+// PaperDimensions returns the width and height of the paper in inches. Paper
+// size defaults to A4 when unset.
+func (c *Config) PaperDimensions() (float64, float64) {
+	dims, ok := paperDimensions[c.PaperSize]
+	if !ok {
+		dims = paperDimensions["A4"]
+	}
+
+	return dims[0], dims[1]
+}
+
+// TabTimeout returns the timeout of browser tabs. Timeout set by the request
+// takes precedence over the timeout of HTTP client.
+func (c *Config) TabTimeout() time.Duration {
+	if c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+
+	return c.HTTPClientOptions.Timeouts.Timeout
+}
+
+// TabPoolSize returns the number of reusable browser tabs. There is one tab per
+// browser worker including the workers of the pool for interactive requests.
+func (c *Config) TabPoolSize() int {
+	size := c.MaxBrowserWorkers
+
+	switch {
+	case c.InteractiveBrowserWorkers > 0:
+		size += c.InteractiveBrowserWorkers
+	case c.InteractiveRenderWorkers > 0:
+		size += c.MaxBrowserWorkers
+	}
+
+	return size
+}
+
+// SetBasicAuth sets basic auth credentials in header, if configured. Credentials
+// are set in Authorization header unless it is already used to forward Grafana
+// credentials, in which case Proxy-Authorization header is used.
+func (c *Config) SetBasicAuth(header http.Header) {
+	if c.BasicAuthUser == "" {
+		return
+	}
+
+	// Let http.Request format the credentials
+	req := http.Request{Header: http.Header{}}
+	req.SetBasicAuth(c.BasicAuthUser, c.BasicAuthPassword)
+	credentials := req.Header.Get("Authorization")
+
+	if header.Get("Authorization") == "" || header.Get("Authorization") == credentials {
+		header.Set("Authorization", credentials)
+		header.Del("Proxy-Authorization")
+
+		return
+	}
+
+	header.Set("Proxy-Authorization", credentials)
+}
+
 // String implements the stringer interface of Config.
 func (c *Config) String() string {
 	var encodedLogo string
@@ -162,17 +651,41 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 	// Always start with a default config so that when the plugin is not provisioned
 	// with a config, we will still have "non-null" config to work with
 	config := Config{
-		Theme:             "light",
-		Orientation:       "portrait",
-		Layout:            "simple",
-		DashboardMode:     "default",
-		TimeZone:          "",
-		TimeFormat:        "",
-		EncodedLogo:       "",
-		HeaderTemplate:    "",
-		FooterTemplate:    "",
-		MaxBrowserWorkers: 2,
-		MaxRenderWorkers:  2,
+		Theme:                   "light",
+		Orientation:             "portrait",
+		PaperSize:               "A4",
+		MarginTop:               1.18,
+		MarginBottom:            0.39,
+		MarginLeft:              0.02,
+		MarginRight:             0.02,
+		Layout:                  "simple",
+		DashboardMode:           "default",
+		TimeZone:                "",
+		TimeFormat:              "",
+		EncodedLogo:             "",
+		HeaderTemplate:          "",
+		FooterTemplate:          "",
+		MaxBrowserWorkers:       2,
+		MaxRenderWorkers:        2,
+		GridColumns:             DefaultGridColumns,
+		GridWidthScale:          DefaultGridWidthScale,
+		GridHeightScale:         DefaultGridHeightScale,
+		ShowPageNumbers:         true,
+		FirstDayOfWeek:          "sunday",
+		FiscalYearStartMonth:    1,
+		DefaultTimeRange:        []string{"now-1h", "now"},
+		PanelPNGCache:           false,
+		DisableAutoRefresh:      true,
+		ClearCookiesOnTabClose:  true,
+		OnDashboardError:        "continue",
+		MetadataSource:          "both",
+		FilenamePolicy:          "none",
+		FilenameExtension:       "pdf",
+		CompressionLevel:        flate.DefaultCompression,
+		PermissionCheckTimeout:  30,
+		MaxRequestTimeout:       600,
+		PermissionCheckFailMode: "closed",
+		CircuitBreakerCooldown:  30,
 		HTTPClientOptions: httpclient.Options{
 			TLS: &httpclient.TLSOptions{
 				InsecureSkipVerify: false,
@@ -187,6 +700,10 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 		if saToken, ok := settings.DecryptedSecureJSONData[SaToken]; ok && saToken != "" {
 			config.Token = saToken
 		}
+
+		if password, ok := settings.DecryptedSecureJSONData[BasicAuthPasswordKey]; ok && password != "" {
+			config.BasicAuthPassword = password
+		}
 	}
 
 	// Update plugin settings defaults
@@ -213,5 +730,36 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 
 	config.HTTPClientOptions.TLS = &httpclient.TLSOptions{InsecureSkipVerify: config.SkipTLSCheck}
 
+	// Present client certificate to Grafana, if configured
+	if config.ClientCertFile != "" {
+		cert, key, err := loadClientCertificate(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("error in client certificate: %w", err)
+		}
+
+		config.HTTPClientOptions.TLS.ClientCertificate = cert
+		config.HTTPClientOptions.TLS.ClientKey = key
+	}
+
 	return config, nil
 }
+
+// loadClientCertificate reads PEM encoded client certificate and key from files
+// and checks that they form a valid key pair.
+func loadClientCertificate(certFile, keyFile string) (string, string, error) {
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return "", "", err
+	}
+
+	return string(cert), string(key), nil
+}
@@ -2,8 +2,20 @@ package config
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	. "github.com/smartystreets/goconvey/convey"
@@ -21,6 +33,32 @@ func TestSettings(t *testing.T) {
 			So(config.Layout, ShouldEqual, "simple")
 			So(config.MaxBrowserWorkers, ShouldEqual, 2)
 			So(config.MaxRenderWorkers, ShouldEqual, 2)
+			So(config.GridColumns, ShouldEqual, DefaultGridColumns)
+			So(config.WeekStart, ShouldEqual, time.Sunday)
+			So(config.FiscalYearStart(), ShouldEqual, time.January)
+			So(config.PaperSize, ShouldEqual, "A4")
+		})
+
+		Convey("Paper dimensions should default to A4", func() {
+			width, height := config.PaperDimensions()
+			So(width, ShouldEqual, 8.27)
+			So(height, ShouldEqual, 11.69)
+
+			config.PaperSize = "Letter"
+			width, height = config.PaperDimensions()
+			So(width, ShouldEqual, 8.5)
+			So(height, ShouldEqual, 11)
+		})
+
+		Convey("Tab pool should have a tab per browser worker", func() {
+			So(config.TabPoolSize(), ShouldEqual, 2)
+
+			config.InteractiveBrowserWorkers = 3
+			So(config.TabPoolSize(), ShouldEqual, 5)
+
+			config.InteractiveBrowserWorkers = 0
+			config.InteractiveRenderWorkers = 1
+			So(config.TabPoolSize(), ShouldEqual, 4)
 		})
 	})
 
@@ -39,10 +77,11 @@ func TestSettings(t *testing.T) {
 	})
 
 	Convey("When creating a new config from provisioned JSONData and secrets", t, func() {
-		const configJSON = `{"layout": "grid"}`
+		const configJSON = `{"layout": "grid", "basicAuthUser": "proxy"}`
 		configData := json.RawMessage(configJSON)
 		secretsMap := map[string]string{
-			"saToken": "supersecrettoken",
+			"saToken":           "supersecrettoken",
+			"basicAuthPassword": "proxypassword",
 		}
 		config, err := Load(
 			context.Background(),
@@ -56,6 +95,7 @@ func TestSettings(t *testing.T) {
 			So(config.MaxBrowserWorkers, ShouldEqual, 2)
 			So(config.MaxRenderWorkers, ShouldEqual, 2)
 			So(config.Token, ShouldEqual, "supersecrettoken")
+			So(config.BasicAuthPassword, ShouldEqual, "proxypassword")
 		})
 	})
 }
@@ -90,6 +130,7 @@ func TestSettingsUsingEnvVars(t *testing.T) {
 			So(config.MaxBrowserWorkers, ShouldEqual, 2)
 			So(config.MaxRenderWorkers, ShouldEqual, 2)
 			So(config.RemoteChromeURL, ShouldEqual, "ws://localhost:5333")
+			So(config.ClearCookiesOnTabClose, ShouldBeTrue)
 		})
 	})
 }
@@ -158,3 +199,148 @@ func TestSettingsUsingConfigAndOverridingEnvVars(t *testing.T) {
 		})
 	})
 }
+
+func TestSettingsValidation(t *testing.T) {
+	Convey("When validating config with invalid values", t, func() {
+		cases := map[string]string{
+			"stat_number_format":         `{"statNumberFormat": "%d %s"}`,
+			"render_timeout":             `{"renderTimeout": -10}`,
+			"grid_columns":               `{"gridColumns": 0}`,
+			"panels_per_page":            `{"panelsPerPage": -1}`,
+			"max_request_timeout":        `{"maxRequestTimeout": 0}`,
+			"client_cert_without_key":    `{"clientCertFile": "/etc/grafana/client.crt"}`,
+			"grid_width_scale":           `{"gridWidthScale": 0}`,
+			"grid_height_scale":          `{"gridHeightScale": -36}`,
+			"panel_border_width":         `{"panelBorderWidth": -1}`,
+			"panel_border_color":         `{"panelBorderColor": "red; display: none"}`,
+			"rate_limit":                 `{"rateLimit": -5}`,
+			"device_scale_factor":        `{"deviceScaleFactor": 8}`,
+			"blocked_urls":               `{"blockedUrls": ["*/api/annotations", ""]}`,
+			"unblocked_urls":             `{"unblockedUrls": ["*/api/live/ ws"]}`,
+			"default_panel_width":        `{"defaultPanelWidth": -100}`,
+			"first_day_of_week":          `{"firstDayOfWeek": "friday"}`,
+			"fiscal_year_start_month":    `{"fiscalYearStartMonth": 13}`,
+			"default_time_range":         `{"defaultTimeRange": ["now-1h"]}`,
+			"max_data_points":            `{"maxDataPoints": -1}`,
+			"min_image_bytes":            `{"minImageBytes": -1}`,
+			"csv_header_renames":         `{"csvHeaderRenames": {"value(": "Value"}}`,
+			"panel_background":           `{"panelBackground": "url(x)"}`,
+			"filename_policy":            `{"filenamePolicy": "lowercase"}`,
+			"filename_extension":         `{"filenameExtension": ".pdf"}`,
+			"compression_level":          `{"compressionLevel": 10}`,
+			"remote_chrome_max_tabs":     `{"remoteChromeMaxTabs": -1}`,
+			"interactive_workers":        `{"interactiveRenderWorkers": -2}`,
+			"permission_check_timeout":   `{"permissionCheckTimeout": -1}`,
+			"permission_check_fail_mode": `{"permissionCheckFailMode": "ignore"}`,
+			"datasource_concurrency":     `{"datasourceConcurrency": {"elasticsearch": 0}}`,
+			"on_dashboard_error":         `{"onDashboardError": "ignore"}`,
+			"max_panel_failure_ratio":    `{"maxPanelFailureRatio": 1.5}`,
+			"circuit_breaker_threshold":  `{"circuitBreakerThreshold": -1}`,
+			"auth_header_hosts":          `{"authHeaderHosts": ["https://images.example.com"]}`,
+			"circuit_breaker_cooldown":   `{"circuitBreakerThreshold": 5, "circuitBreakerCooldown": 0}`,
+			"metadata_source":            `{"metadataSource": "cache"}`,
+			"paper_size":                 `{"paperSize": "B5"}`,
+			"margin_top":                 `{"marginTop": -0.5}`,
+			"include_panel_title_regex":  `{"includePanelTitleRegex": "cpu("}`,
+			"exclude_panel_title_regex":  `{"excludePanelTitleRegex": "[a-"}`,
+			"exclude_panel_types":        `{"excludePanelTypes": ["text", ""]}`,
+			"report_profiles":            `{"reportProfiles": {"weekly-exec": {"theme": "blue"}}}`,
+		}
+
+		for clName, configJSON := range cases {
+			_, err := Load(context.Background(), backend.AppInstanceSettings{JSONData: json.RawMessage(configJSON)})
+
+			Convey("Loading config should fail: "+clName, func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestSetBasicAuth(t *testing.T) {
+	Convey("When setting basic auth credentials in headers", t, func() {
+		conf := &Config{BasicAuthUser: "proxy", BasicAuthPassword: "secret"}
+		credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxy:secret"))
+
+		Convey("Credentials should be set in Authorization header when it is free", func() {
+			header := http.Header{}
+			conf.SetBasicAuth(header)
+
+			So(header.Get("Authorization"), ShouldEqual, credentials)
+			So(header.Get("Proxy-Authorization"), ShouldBeEmpty)
+		})
+
+		Convey("Credentials should not clobber forwarded Grafana token", func() {
+			header := http.Header{"Authorization": []string{"Bearer token"}}
+			conf.SetBasicAuth(header)
+
+			So(header.Get("Authorization"), ShouldEqual, "Bearer token")
+			So(header.Get("Proxy-Authorization"), ShouldEqual, credentials)
+		})
+
+		Convey("Headers should be untouched when basic auth is not configured", func() {
+			header := http.Header{}
+			(&Config{}).SetBasicAuth(header)
+
+			So(header, ShouldBeEmpty)
+		})
+
+		Convey("Password without user should be rejected", func() {
+			_, err := Load(context.Background(), backend.AppInstanceSettings{
+				DecryptedSecureJSONData: map[string]string{BasicAuthPasswordKey: "secret"},
+			})
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestClientCertificate(t *testing.T) {
+	Convey("When loading config with a client certificate", t, func() {
+		dir := t.TempDir()
+		certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+
+		// Self signed certificate and its key
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+
+		template := x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+		So(err, ShouldBeNil)
+
+		keyDER, err := x509.MarshalECPrivateKey(privateKey)
+		So(err, ShouldBeNil)
+
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+		So(os.WriteFile(certFile, certPEM, 0o600), ShouldBeNil)
+		So(os.WriteFile(keyFile, keyPEM, 0o600), ShouldBeNil)
+
+		configJSON := json.RawMessage(fmt.Sprintf(`{"clientCertFile": %q, "clientKeyFile": %q}`, certFile, keyFile))
+
+		Convey("Certificate and key should be set in HTTP client options", func() {
+			config, err := Load(context.Background(), backend.AppInstanceSettings{JSONData: configJSON})
+
+			So(err, ShouldBeNil)
+			So(config.HTTPClientOptions.TLS.ClientCertificate, ShouldEqual, string(certPEM))
+			So(config.HTTPClientOptions.TLS.ClientKey, ShouldEqual, string(keyPEM))
+		})
+
+		Convey("Mismatched certificate and key should be rejected", func() {
+			So(os.WriteFile(keyFile, certPEM, 0o600), ShouldBeNil)
+
+			_, err := Load(context.Background(), backend.AppInstanceSettings{JSONData: configJSON})
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Missing files should be rejected", func() {
+			So(os.Remove(certFile), ShouldBeNil)
+
+			_, err := Load(context.Background(), backend.AppInstanceSettings{JSONData: configJSON})
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -5,9 +5,12 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -31,6 +34,11 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 		return nil, fmt.Errorf("failed to parse app URL: %w", errors.Unwrap(err))
 	}
 
+	// Grafana can be served from a sub path. Remove trailing slash so that
+	// paths are always joined to the sub path
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+
 	// Read JS from embedded file
 	js, err := jsFS.ReadFile("js/panels.js")
 	if err != nil {
@@ -47,6 +55,7 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 		string(js),
 		model,
 		authHeader,
+		&sync.Map{},
 	}, nil
 }
 
@@ -54,22 +63,129 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 func (d *Dashboard) GetData(ctx context.Context) (*Data, error) {
 	defer helpers.TimeTrack(time.Now(), "dashboard data", d.logger)
 
-	// Make panels from loading the dashboard in a browser instance
-	panels, err := d.panels(ctx)
+	ctx, span := helpers.StartSpan(ctx, d.conf.TracingEnabled, "dashboard.GetData",
+		helpers.SpanDashboardUID.String(d.model.Dashboard.UID),
+	)
+	defer span.End()
+
+	var (
+		panels []Panel
+		err    error
+	)
+
+	if d.conf.FullPageScreenshot {
+		// Capture entire dashboard as a single image
+		panels, err = d.fullPagePanels(ctx)
+	} else {
+		// Make panels from loading the dashboard in a browser instance
+		panels, err = d.panels(ctx)
+	}
+
 	if err != nil {
 		d.logger.Error("error collecting panels from browser", "error", err)
+		helpers.SpanError(span, err)
 
 		return nil, fmt.Errorf("error collecting panels from browser: %w", err)
 	}
 
+	// Link panels to their live view in Grafana
+	if d.conf.LinkPanelsToLive {
+		for i := range panels {
+			panels[i].LiveURL = d.panelLiveURL(panels[i]).String()
+		}
+	}
+
 	return &Data{
-		Title:     d.model.Dashboard.Title,
-		TimeRange: NewTimeRange(d.model.Dashboard.Variables.Get("from"), d.model.Dashboard.Variables.Get("to")),
-		Variables: variablesValues(d.model.Dashboard.Variables),
-		Panels:    panels,
+		Title:           d.model.Dashboard.Title,
+		UID:             d.model.Dashboard.UID,
+		URL:             d.liveURL().String(),
+		TimeRange:       NewTimeRange(d.model.Dashboard.Variables.Get("from"), d.model.Dashboard.Variables.Get("to")),
+		Variables:       variablesValues(d.model.Dashboard.Variables),
+		VariableSummary: variableSummary(d.model.Dashboard.Templating.List, d.model.Dashboard.Variables),
+		Panels:          panels,
 	}, err
 }
 
+// fullPagePanels returns a single panel spanning all grid columns with the full
+// page screenshot of the dashboard as its image.
+func (d *Dashboard) fullPagePanels(ctx context.Context) ([]Panel, error) {
+	image, err := d.FullPagePNG(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Panel{
+		{
+			ID:           "dashboard",
+			Title:        d.model.Dashboard.Title,
+			GridPos:      GridPos{W: float64(d.gridColumns()), H: 1},
+			EncodedImage: image,
+		},
+	}, nil
+}
+
+// liveURL returns the URL to view the dashboard in Grafana with the same
+// variables and time range as the report.
+func (d *Dashboard) liveURL() *url.URL {
+	values := url.Values{}
+
+	for k, v := range d.model.Dashboard.Variables {
+		if k == "from" || k == "to" || strings.HasPrefix(k, "var-") {
+			values[k] = v
+		}
+	}
+
+	return d.grafanaURL(values, "d", d.model.Dashboard.UID, "_")
+}
+
+// panelLiveURL returns the URL to view the panel in Grafana with the same
+// variables and time range as the report. Full page screenshots are linked
+// to the dashboard itself.
+func (d *Dashboard) panelLiveURL(p Panel) *url.URL {
+	u := d.liveURL()
+
+	if !d.conf.FullPageScreenshot {
+		values := u.Query()
+		values.Set("viewPanel", p.ID)
+		u.RawQuery = values.Encode()
+	}
+
+	return u
+}
+
+// grafanaURL returns the URL of Grafana with the given path elements and query
+// values. Path elements are joined to the path of app URL so that the sub path
+// of Grafana served from a sub path is preserved.
+func (d *Dashboard) grafanaURL(values url.Values, elem ...string) *url.URL {
+	// Make a copy of appURL with an absolute path
+	base := *d.appURL
+	if base.Path == "" {
+		base.Path = "/"
+	}
+
+	u := base.JoinPath(elem...)
+	u.RawQuery = values.Encode()
+
+	return u
+}
+
+// queryValues returns query parameters used in dashboard and panel URLs. When
+// auto refresh is disabled, refresh parameter is removed so that it cannot turn
+// on auto refresh while panels are being captured. Grafana ignores an empty
+// refresh parameter and has no value to turn auto refresh off from the URL.
+func (d *Dashboard) queryValues() url.Values {
+	values := maps.Clone(d.model.Dashboard.Variables)
+	if values == nil {
+		values = url.Values{}
+	}
+
+	if d.conf != nil && d.conf.DisableAutoRefresh {
+		values.Del("refresh")
+	}
+
+	return values
+}
+
 // variablesValues returns current dashboard template variables and their values as
 // a string.
 func variablesValues(queryParams url.Values) string {
@@ -84,3 +200,80 @@ func variablesValues(queryParams url.Values) string {
 
 	return strings.Join(values, "; ")
 }
+
+// variableSummary returns dashboard template variables and their selected values.
+// Values set in query parameters take precedence over the current values saved in
+// dashboard model. Variables that are hidden on the dashboard are ignored.
+func variableSummary(templating []Variable, queryParams url.Values) []VariableValue {
+	summary := []VariableValue{}
+	seen := make(map[string]bool)
+
+	for _, v := range templating {
+		seen[v.Name] = true
+
+		if v.Hide == hideVariable {
+			continue
+		}
+
+		values := queryParams["var-"+v.Name]
+		if len(values) == 0 {
+			values = currentValues(v.Current.Text)
+		}
+
+		name := v.Label
+		if name == "" {
+			name = v.Name
+		}
+
+		summary = append(summary, VariableValue{Name: name, Values: formatValues(values)})
+	}
+
+	// Variables that are only present in query parameters, like ad hoc filters
+	var names []string
+
+	for k := range queryParams {
+		if n, found := strings.CutPrefix(k, "var-"); found && !seen[n] {
+			names = append(names, n)
+		}
+	}
+
+	slices.Sort(names)
+
+	for _, n := range names {
+		summary = append(summary, VariableValue{Name: n, Values: formatValues(queryParams["var-"+n])})
+	}
+
+	return summary
+}
+
+// currentValues returns current values of the variable in dashboard model.
+func currentValues(current interface{}) []string {
+	switch v := current.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = fmt.Sprint(value)
+		}
+
+		return values
+	}
+
+	return nil
+}
+
+// formatValues replaces special values of variables with their display values.
+func formatValues(values []string) []string {
+	formatted := make([]string, len(values))
+
+	for i, v := range values {
+		if v == allValue {
+			v = "All"
+		}
+
+		formatted[i] = v
+	}
+
+	return formatted
+}
@@ -0,0 +1,213 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVariableSummary(t *testing.T) {
+	Convey("When making summary of dashboard variables", t, func() {
+		const templatingJSON = `[
+			{"name": "host", "label": "Host", "type": "query", "current": {"text": ["host1", "host2"], "value": ["host1", "host2"]}},
+			{"name": "port", "type": "custom", "current": {"text": "8080", "value": "8080"}},
+			{"name": "secret", "type": "constant", "hide": 2, "current": {"text": "foo", "value": "foo"}},
+			{"name": "job", "type": "query", "current": {"text": "All", "value": "$__all"}}
+		]`
+
+		var templating []Variable
+
+		err := json.Unmarshal([]byte(templatingJSON), &templating)
+
+		Convey("setup templating unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		queryParams := url.Values{
+			"var-port":   []string{"9090"},
+			"var-job":    []string{"$__all"},
+			"var-secret": []string{"bar"},
+			"var-filter": []string{"a=b"},
+			"from":       []string{"now-1h"},
+		}
+
+		summary := variableSummary(templating, queryParams)
+
+		Convey("It should return variables in the order of dashboard", func() {
+			So(summary, ShouldResemble, []VariableValue{
+				{Name: "Host", Values: []string{"host1", "host2"}},
+				{Name: "port", Values: []string{"9090"}},
+				{Name: "job", Values: []string{"All"}},
+				{Name: "filter", Values: []string{"a=b"}},
+			})
+		})
+	})
+}
+
+func TestQueryValues(t *testing.T) {
+	Convey("When making query parameters of dashboard URLs", t, func() {
+		variables := url.Values{}
+		variables.Add("var-host", "servername")
+		variables.Add("refresh", "5s")
+
+		conf := config.Config{}
+		dash := &Dashboard{conf: &conf, model: &Model{Dashboard: Spec{Variables: variables}}}
+
+		Convey("Refresh should be retained when auto refresh is not disabled", func() {
+			So(dash.queryValues().Get("refresh"), ShouldEqual, "5s")
+		})
+
+		Convey("Refresh should be removed when auto refresh is disabled", func() {
+			conf.DisableAutoRefresh = true
+
+			values := dash.queryValues()
+
+			So(values.Has("refresh"), ShouldBeFalse)
+			So(values.Get("var-host"), ShouldEqual, "servername")
+		})
+
+		Convey("Dashboard variables should not be modified", func() {
+			conf.DisableAutoRefresh = true
+
+			dash.queryValues().Add("theme", "light")
+
+			So(variables.Get("refresh"), ShouldEqual, "5s")
+			So(variables.Has("theme"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestSharedTooltip(t *testing.T) {
+	Convey("When creating a dashboard with shared graph tooltip", t, func() {
+		model := Model{Dashboard: Spec{UID: "randomUID"}}
+		conf := config.Config{}
+
+		Convey("Shared tooltip should be retained by default", func() {
+			dash, err := New(log.NewNullLogger(), &conf, nil, nil, "http://localhost:3000", "v11.1.0", &model, nil)
+
+			So(err, ShouldBeNil)
+			So(dash.sharedTooltipTasks(), ShouldBeEmpty)
+		})
+
+		Convey("Shared tooltip should be hidden in browser when disabled", func() {
+			conf.DisableSharedTooltip = true
+
+			dash, err := New(log.NewNullLogger(), &conf, nil, nil, "http://localhost:3000", "v11.1.0", &model, nil)
+
+			So(err, ShouldBeNil)
+			So(dash.sharedTooltipTasks(), ShouldHaveLength, 1)
+			So(dash.jsContent, ShouldContainSubstring, "const disableSharedTooltip")
+		})
+	})
+}
+
+func TestPanelLiveURL(t *testing.T) {
+	Convey("When building live URL of a panel", t, func() {
+		model := Model{}
+		model.Dashboard.UID = "randomUID"
+		model.Dashboard.Variables = url.Values{
+			"from":     []string{"now-1h"},
+			"to":       []string{"now"},
+			"var-host": []string{"a", "b"},
+			"theme":    []string{"light"},
+		}
+
+		conf := config.Config{}
+
+		dash, err := New(log.NewNullLogger(), &conf, nil, nil, "http://localhost:3000", "v11.1.0", &model, nil)
+		So(err, ShouldBeNil)
+
+		Convey("URL should view the panel with variables and time range of report", func() {
+			liveURL := dash.panelLiveURL(Panel{ID: "2"})
+
+			So(liveURL.String(), ShouldEqual, "http://localhost:3000/d/randomUID/_?from=now-1h&to=now&var-host=a&var-host=b&viewPanel=2")
+		})
+
+		Convey("URL of dashboard should have variables and time range of report", func() {
+			So(dash.liveURL().String(), ShouldEqual, "http://localhost:3000/d/randomUID/_?from=now-1h&to=now&var-host=a&var-host=b")
+		})
+
+		Convey("URL of full page screenshot should point to the dashboard", func() {
+			conf.FullPageScreenshot = true
+
+			liveURL := dash.panelLiveURL(Panel{ID: "dashboard"})
+
+			So(liveURL.String(), ShouldEqual, "http://localhost:3000/d/randomUID/_?from=now-1h&to=now&var-host=a&var-host=b")
+		})
+	})
+}
+
+func TestSubPathURLs(t *testing.T) {
+	Convey("When building URLs of Grafana served from a sub path", t, func() {
+		model := Model{}
+		model.Dashboard.UID = "randomUID"
+		model.Dashboard.Variables = url.Values{"var-host": []string{"a"}}
+
+		conf := config.Config{Layout: "simple"}
+
+		for _, appURL := range []string{"https://example.com/grafana", "https://example.com/grafana/"} {
+			dash, err := New(log.NewNullLogger(), &conf, nil, nil, appURL, "v11.4.0", &model, nil)
+			So(err, ShouldBeNil)
+
+			So(dash.panelLiveURL(Panel{ID: "2"}).String(), ShouldEqual, "https://example.com/grafana/d/randomUID/_?var-host=a&viewPanel=2")
+			So(dash.panelCSVURL(Panel{ID: "2"}).Path, ShouldEqual, "/grafana/d/randomUID/_")
+			So(dash.panelPNGURL(Panel{ID: "2"}, true).Path, ShouldEqual, "/grafana/render/d-solo/randomUID/_")
+			So(dash.panelPNGURL(Panel{ID: "2"}, false).Path, ShouldEqual, "/grafana/d-solo/randomUID/_")
+			So(dash.grafanaURL(dash.queryValues(), "d", "randomUID", "_").String(), ShouldEqual, "https://example.com/grafana/d/randomUID/_?var-host=a")
+		}
+	})
+}
+
+func TestPanelDatasourceType(t *testing.T) {
+	Convey("When reading datasource type of panels from dashboard model", t, func() {
+		cases := []struct {
+			panel    string
+			expected string
+		}{
+			{`{"id": 1, "datasource": {"type": "prometheus", "uid": "prom"}}`, "prometheus"},
+			{`{"id": 2, "datasource": "Elasticsearch"}`, ""},
+			{`{"id": 3}`, ""},
+			{
+				`{"id": 4, "datasource": {"type": "datasource", "uid": "-- Mixed --"}, "targets": [{"datasource": {"type": "elasticsearch", "uid": "es"}}, {"datasource": {"type": "prometheus", "uid": "prom"}}]}`,
+				"elasticsearch",
+			},
+		}
+
+		for _, c := range cases {
+			var p Panel
+
+			err := json.Unmarshal([]byte(c.panel), &p)
+
+			So(err, ShouldBeNil)
+			So(p.DatasourceType, ShouldEqual, c.expected)
+		}
+	})
+}
+
+func TestFiscalYearStart(t *testing.T) {
+	Convey("When reading fiscal year start month of dashboard", t, func() {
+		for _, c := range []struct {
+			model string
+			month time.Month
+			ok    bool
+		}{
+			{`{"uid": "randomUID"}`, 0, false},
+			{`{"uid": "randomUID", "fiscalYearStartMonth": 0}`, time.January, true},
+			{`{"uid": "randomUID", "fiscalYearStartMonth": 3}`, time.April, true},
+			{`{"uid": "randomUID", "fiscalYearStartMonth": 12}`, 0, false},
+		} {
+			var spec Spec
+
+			So(json.Unmarshal([]byte(c.model), &spec), ShouldBeNil)
+
+			month, ok := spec.FiscalYearStart()
+			So(month, ShouldEqual, c.month)
+			So(ok, ShouldEqual, c.ok)
+		}
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"maps"
 	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,18 +20,36 @@ import (
 )
 
 // PanelCSV returns CSV data of a given panel.
-func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
+func (d *Dashboard) PanelCSV(ctx context.Context, p Panel) (CSVData, error) {
+	ctx, span := helpers.StartSpan(ctx, d.conf.TracingEnabled, "dashboard.PanelCSV",
+		helpers.SpanDashboardUID.String(d.model.Dashboard.UID),
+		helpers.SpanPanelID.String(p.ID),
+	)
+	defer span.End()
+
+	data, err := d.panelCSV(ctx, p)
+	helpers.SpanError(span, err)
+
+	return data, err
+}
+
+// panelCSV fetches CSV data of a given panel from browser.
+func (d *Dashboard) panelCSV(ctx context.Context, p Panel) (CSVData, error) {
 	// Get panel CSV data URL
 	panelURL := d.panelCSVURL(p)
 
 	defer helpers.TimeTrack(time.Now(), "fetch panel CSV data", d.logger, "fetcher", "native", "panel_id", p.ID, "url", panelURL.String())
 
-	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
+	// Get a tab from the pool
+	tab, err := d.chromeInstance.AcquireTab(ctx, d.logger, d.conf)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring tab: %w", err)
+	}
+
 	// Set a timeout for the tab
 	// Fail-safe for newer Grafana versions, if css has been changed.
-	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
-	defer tab.Close(d.logger)
+	tab.WithTimeout(2 * d.conf.TabTimeout())
+	defer d.chromeInstance.ReleaseTab(d.logger, tab)
 
 	headers := make(map[string]any)
 
@@ -39,7 +59,7 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 		}
 	}
 
-	err := tab.NavigateAndWaitFor(panelURL.String(), headers, "networkIdle")
+	err = tab.NavigateAndWaitFor(panelURL.String(), headers, "networkIdle")
 	if err != nil {
 		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
 	}
@@ -59,16 +79,25 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 		}
 	})
 
+	// Directory where browser downloads the CSV file
+	_, remote := d.chromeInstance.(*chrome.RemoteInstance)
+
+	downloadDir, cleanup, err := csvDownloadDir(remote)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CSV download directory: %w", err)
+	}
+	defer cleanup()
+
 	js := fmt.Sprintf(
 		`waitForCSVData(version = '%s', timeout = %d);`,
-		d.appVersion, d.conf.HTTPClientOptions.Timeouts.Timeout.Milliseconds(),
+		d.appVersion, d.conf.TabTimeout().Milliseconds(),
 	)
 
 	downTasks := chromedp.Tasks{
 		// Downloads needs to be allowed, otherwise the CSV request will be denied.
 		// Allow download events to emit so we can get the download URL.
 		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
-			WithDownloadPath("/dev/null").
+			WithDownloadPath(downloadDir).
 			WithEventsEnabled(true),
 		chromedp.Evaluate(d.jsContent, nil),
 		chromedp.Evaluate(js, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
@@ -110,7 +139,7 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 		chrome.WithAwaitPromise,
 	)
 
-	if err := tab.RunWithTimeout(d.conf.HTTPClientOptions.Timeouts.Timeout, task); err != nil {
+	if err := tab.RunWithTimeout(d.conf.TabTimeout(), task); err != nil {
 		return nil, fmt.Errorf("error fetching CSV data from URL from browser %s: %w", panelURL, err)
 	}
 
@@ -130,22 +159,79 @@ func (d *Dashboard) PanelCSV(_ context.Context, p Panel) (CSVData, error) {
 		return nil, fmt.Errorf("error reading CSV data: %w", err)
 	}
 
-	return csvData, nil
+	// Use display names of fields set by overrides of the panel, if enabled
+	if d.conf.ApplyFieldOverrides {
+		csvData = applyFieldOverrides(csvData, p)
+	}
+
+	return renameCSVHeaders(csvData, d.conf.CSVHeaderRenames), nil
+}
+
+// renameCSVHeaders rewrites column headers of CSV data using regex renames. Each
+// header is rewritten by the first matching pattern in the sorted order of patterns.
+// Replacements can refer to the capture groups of the pattern like $1.
+func renameCSVHeaders(data CSVData, renames map[string]string) CSVData {
+	if len(data) == 0 || len(renames) == 0 {
+		return data
+	}
+
+	type rename struct {
+		re          *regexp.Regexp
+		replacement string
+	}
+
+	// Patterns are validated in config and hence, invalid ones are simply ignored
+	var rules []rename
+
+	for _, pattern := range slices.Sorted(maps.Keys(renames)) {
+		if re, err := regexp.Compile(pattern); err == nil {
+			rules = append(rules, rename{re, renames[pattern]})
+		}
+	}
+
+	for icol, header := range data[0] {
+		for _, rule := range rules {
+			if rule.re.MatchString(header) {
+				data[0][icol] = rule.re.ReplaceAllString(header, rule.replacement)
+
+				break
+			}
+		}
+	}
+
+	return data
 }
 
 // panelCSVURL returns URL to fetch panel's CSV data.
 func (d *Dashboard) panelCSVURL(p Panel) *url.URL {
-	values := maps.Clone(d.model.Dashboard.Variables)
+	values := d.queryValues()
 	values.Add("theme", d.conf.Theme)
-	values.Add("viewPanel", p.ID)
-	values.Add("inspect", p.ID)
+	values.Add("viewPanel", d.panelKey(p))
+	values.Add("inspect", d.panelKey(p))
 	values.Add("inspectTab", "data")
 
-	// Make a copy of appURL
-	panelURL := *d.appURL
-	panelURL.Path = fmt.Sprintf("/d/%s/_", d.model.Dashboard.UID)
-	panelURL.RawQuery = values.Encode()
+	// In kiosk mode, Grafana does not render navigation bars and side menus which
+	// makes the page load faster and avoids those elements intercepting clicks
+	// on the inspector drawer.
+	if d.conf.CSVKioskMode {
+		values.Add("kiosk", "")
+	}
 
 	// Get Panel API endpoint
-	return &panelURL
+	return d.grafanaURL(values, "d", d.model.Dashboard.UID, "_")
+}
+
+// panelKey returns the key of panel used in URLs. Starting from Grafana v11.3.0,
+// panels are identified by panel-<id> keys while panels read from the dashboard
+// JSON model have numeric IDs.
+func (d *Dashboard) panelKey(p Panel) string {
+	if helpers.SemverCompare(d.appVersion, "v11.3.0") == -1 {
+		return p.ID
+	}
+
+	if _, err := strconv.Atoi(p.ID); err == nil {
+		return "panel-" + p.ID
+	}
+
+	return p.ID
 }
@@ -0,0 +1,115 @@
+package dashboard
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPanelCSVURL(t *testing.T) {
+	Convey("When building panel CSV URL", t, func() {
+		conf := config.Config{
+			Theme: "light",
+		}
+
+		variables := url.Values{}
+		variables.Add("var-host", "servername")
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			nil,
+			nil,
+			"http://localhost:3000",
+			"v11.4.0",
+			&Model{Dashboard: Spec{
+				UID:       "randomUID",
+				Variables: variables,
+			}},
+			nil,
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		Convey("It should open the data inspector of the panel", func() {
+			u := dash.panelCSVURL(Panel{ID: "44"})
+
+			So(u.Path, ShouldEqual, "/d/randomUID/_")
+			So(u.Query().Get("viewPanel"), ShouldEqual, "panel-44")
+			So(u.Query().Get("inspect"), ShouldEqual, "panel-44")
+			So(u.Query().Get("inspectTab"), ShouldEqual, "data")
+			So(u.Query().Get("var-host"), ShouldEqual, "servername")
+			So(u.Query().Has("kiosk"), ShouldBeFalse)
+		})
+
+		Convey("It should use kiosk mode when enabled", func() {
+			conf.CSVKioskMode = true
+			u := dash.panelCSVURL(Panel{ID: "44"})
+
+			So(u.Query().Has("kiosk"), ShouldBeTrue)
+			So(u.Query().Get("inspect"), ShouldEqual, "panel-44")
+			So(u.Query().Get("inspectTab"), ShouldEqual, "data")
+		})
+
+		Convey("It should keep keys of repeated panels", func() {
+			u := dash.panelCSVURL(Panel{ID: "panel-44-clone-1"})
+
+			So(u.Query().Get("viewPanel"), ShouldEqual, "panel-44-clone-1")
+			So(u.Query().Get("inspect"), ShouldEqual, "panel-44-clone-1")
+		})
+
+		Convey("It should use numeric IDs for Grafana < v11.3.0", func() {
+			dash.appVersion = "v11.2.0"
+			u := dash.panelCSVURL(Panel{ID: "44"})
+
+			So(u.Query().Get("viewPanel"), ShouldEqual, "44")
+			So(u.Query().Get("inspect"), ShouldEqual, "44")
+		})
+	})
+}
+
+func TestRenameCSVHeaders(t *testing.T) {
+	Convey("When renaming CSV headers", t, func() {
+		data := func() CSVData {
+			return CSVData{
+				{"Time", `node_load1{instance="node1:9100", job="node"}`, `node_load5{instance="node1:9100", job="node"}`},
+				{"2024-12-14 10:00:00", "0.5", "0.7"},
+			}
+		}
+
+		Convey("Data should be unchanged without renames", func() {
+			So(renameCSVHeaders(data(), nil), ShouldResemble, data())
+		})
+
+		Convey("Headers should be rewritten using regex renames", func() {
+			renamed := renameCSVHeaders(data(), map[string]string{
+				`^node_load(\d+)\{instance="([^:]+):.*$`: "Load ${1}m ($2)",
+				`^Time$`:                                 "Timestamp",
+			})
+
+			So(renamed[0], ShouldResemble, []string{"Timestamp", "Load 1m (node1)", "Load 5m (node1)"})
+			So(renamed[1], ShouldResemble, data()[1])
+		})
+
+		Convey("Only first matching pattern should be applied to a header", func() {
+			renamed := renameCSVHeaders(data(), map[string]string{
+				`^node_load1\{.*$`: "Load 1m",
+				`^node_load\d.*$`:  "Load",
+				`^Tim`:             "Da",
+			})
+
+			So(renamed[0], ShouldResemble, []string{"Dae", "Load 1m", "Load"})
+		})
+
+		Convey("Invalid patterns should be ignored", func() {
+			renamed := renameCSVHeaders(data(), map[string]string{`^Time(`: "Timestamp"})
+
+			So(renamed[0][0], ShouldEqual, "Time")
+		})
+	})
+}
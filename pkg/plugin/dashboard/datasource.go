@@ -0,0 +1,93 @@
+package dashboard
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+)
+
+// Values of datasource variables that refer to the default datasource.
+var defaultDatasourceValues = []string{"", "default", "$__default"}
+
+// Datasource represents a Grafana datasource as returned by datasources API.
+type Datasource struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// ResolveDatasourceVariables returns query parameters where the values of datasource
+// typed template variables are resolved to concrete datasource UIDs. Value provided
+// in query parameters takes precedence over the current value saved in dashboard
+// model. Values can be either UID or name of the datasource and the default
+// datasource of the variable's plugin type is used when value refers to the default
+// one. Values that cannot be resolved are kept unchanged.
+func ResolveDatasourceVariables(templating []Variable, values url.Values, datasources []Datasource) url.Values {
+	resolved := maps.Clone(values)
+	if resolved == nil {
+		resolved = url.Values{}
+	}
+
+	for _, v := range templating {
+		if v.Type != "datasource" {
+			continue
+		}
+
+		var value string
+		if provided := values["var-"+v.Name]; len(provided) > 0 {
+			value = provided[0]
+		} else if current := currentValues(v.Current.Value); len(current) > 0 {
+			value = current[0]
+		}
+
+		if uid, ok := resolveDatasource(value, v.pluginType(), datasources); ok {
+			resolved.Set("var-"+v.Name, uid)
+		}
+	}
+
+	return resolved
+}
+
+// resolveDatasource returns UID of the datasource referred by value.
+func resolveDatasource(value, pluginType string, datasources []Datasource) (string, bool) {
+	if !slices.Contains(defaultDatasourceValues, value) {
+		for _, ds := range datasources {
+			if ds.UID == value || ds.Name == value {
+				return ds.UID, true
+			}
+		}
+
+		return "", false
+	}
+
+	// Use the default datasource or the first datasource of the plugin type
+	var candidates []Datasource
+
+	for _, ds := range datasources {
+		if pluginType == "" || ds.Type == pluginType {
+			candidates = append(candidates, ds)
+		}
+	}
+
+	for _, ds := range candidates {
+		if ds.IsDefault {
+			return ds.UID, true
+		}
+	}
+
+	if len(candidates) > 0 {
+		return candidates[0].UID, true
+	}
+
+	return "", false
+}
+
+// pluginType returns the datasource plugin type of a datasource variable.
+func (v Variable) pluginType() string {
+	if t, ok := v.Query.(string); ok {
+		return t
+	}
+
+	return ""
+}
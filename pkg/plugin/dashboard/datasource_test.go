@@ -0,0 +1,64 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolveDatasourceVariables(t *testing.T) {
+	Convey("When resolving datasource variables", t, func() {
+		const templatingJSON = `[
+			{"name": "ds", "type": "datasource", "query": "prometheus", "current": {"text": "Prometheus", "value": "prom-uid"}},
+			{"name": "logs", "type": "datasource", "query": "loki", "current": {"text": "default", "value": "default"}},
+			{"name": "host", "type": "query", "query": {"query": "label_values(host)"}, "current": {"text": "host1", "value": "host1"}}
+		]`
+
+		var templating []Variable
+
+		err := json.Unmarshal([]byte(templatingJSON), &templating)
+
+		Convey("setup templating unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		datasources := []Datasource{
+			{UID: "prom-uid", Name: "Prometheus", Type: "prometheus"},
+			{UID: "thanos-uid", Name: "Thanos", Type: "prometheus", IsDefault: true},
+			{UID: "loki-1", Name: "Loki 1", Type: "loki"},
+			{UID: "loki-2", Name: "Loki 2", Type: "loki"},
+		}
+
+		Convey("Saved values should be resolved when not provided", func() {
+			values := ResolveDatasourceVariables(templating, url.Values{"var-host": []string{"host2"}}, datasources)
+
+			So(values.Get("var-ds"), ShouldEqual, "prom-uid")
+			So(values.Get("var-logs"), ShouldEqual, "loki-1")
+			So(values.Get("var-host"), ShouldEqual, "host2")
+		})
+
+		Convey("Provided datasource names should be resolved to UIDs", func() {
+			query := url.Values{"var-ds": []string{"Thanos"}, "var-logs": []string{"loki-2"}}
+			values := ResolveDatasourceVariables(templating, query, datasources)
+
+			So(values.Get("var-ds"), ShouldEqual, "thanos-uid")
+			So(values.Get("var-logs"), ShouldEqual, "loki-2")
+			So(query.Get("var-ds"), ShouldEqual, "Thanos")
+		})
+
+		Convey("Default datasource of plugin type should be used", func() {
+			values := ResolveDatasourceVariables(templating, url.Values{"var-ds": []string{"default"}}, datasources)
+
+			So(values.Get("var-ds"), ShouldEqual, "thanos-uid")
+		})
+
+		Convey("Unknown datasources should be kept unchanged", func() {
+			values := ResolveDatasourceVariables(templating, url.Values{"var-ds": []string{"${DS_PROMETHEUS}"}}, datasources)
+
+			So(values.Get("var-ds"), ShouldEqual, "${DS_PROMETHEUS}")
+			So(values.Has("var-host"), ShouldBeFalse)
+		})
+	})
+}
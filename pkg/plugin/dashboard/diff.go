@@ -0,0 +1,86 @@
+package dashboard
+
+// PanelChange represents a panel that differs between two dashboard versions.
+type PanelChange struct {
+	ID    string
+	Title string
+	Type  string
+	From  GridPos
+	To    GridPos
+}
+
+// VersionDiff represents the structural differences of panels between two
+// versions of a dashboard.
+type VersionDiff struct {
+	Title       string
+	FromVersion int
+	ToVersion   int
+	Added       []PanelChange
+	Removed     []PanelChange
+	Moved       []PanelChange
+}
+
+// Changed returns true if panels of the two versions differ.
+func (d VersionDiff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Moved) > 0
+}
+
+// DiffPanels returns the panels added, removed and moved between from and to
+// panel lists of dashboard models. Panels are matched by their IDs and panels
+// nested in collapsed rows are considered as well. Added and moved panels are
+// in the order of to and removed panels in the order of from.
+func DiffPanels(from, to []RowOrPanel) ([]PanelChange, []PanelChange, []PanelChange) {
+	fromPanels := flattenPanels(from)
+	toPanels := flattenPanels(to)
+
+	fromIDs := make(map[string]Panel, len(fromPanels))
+	for _, p := range fromPanels {
+		fromIDs[p.ID] = p
+	}
+
+	toIDs := make(map[string]Panel, len(toPanels))
+	for _, p := range toPanels {
+		toIDs[p.ID] = p
+	}
+
+	var added, removed, moved []PanelChange
+
+	for _, p := range toPanels {
+		old, ok := fromIDs[p.ID]
+
+		switch {
+		case !ok:
+			added = append(added, PanelChange{ID: p.ID, Title: p.Title, Type: p.Type, To: p.GridPos})
+		case old.GridPos != p.GridPos:
+			moved = append(moved, PanelChange{ID: p.ID, Title: p.Title, Type: p.Type, From: old.GridPos, To: p.GridPos})
+		}
+	}
+
+	for _, p := range fromPanels {
+		if _, ok := toIDs[p.ID]; !ok {
+			removed = append(removed, PanelChange{ID: p.ID, Title: p.Title, Type: p.Type, From: p.GridPos})
+		}
+	}
+
+	return added, removed, moved
+}
+
+// flattenPanels returns rows and panels including the ones nested in collapsed
+// rows. Panels without ID cannot be matched across versions and are ignored.
+func flattenPanels(rowOrPanels []RowOrPanel) []Panel {
+	var panels []Panel
+
+	for _, r := range rowOrPanels {
+		if r.ID != "" {
+			panels = append(panels, r.Panel)
+		}
+
+		for _, p := range r.Panels {
+			if p.ID != "" {
+				panels = append(panels, p)
+			}
+		}
+	}
+
+	return panels
+}
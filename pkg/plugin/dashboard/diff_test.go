@@ -0,0 +1,59 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffPanels(t *testing.T) {
+	Convey("When diffing panels of two dashboard versions", t, func() {
+		var from, to Spec
+
+		err := json.Unmarshal([]byte(`{"panels": [
+			{"id": 1, "type": "stat", "title": "Uptime", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 0}},
+			{"id": 2, "type": "graph", "title": "CPU", "gridPos": {"h": 8, "w": 12, "x": 6, "y": 0}},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8},
+			 "panels": [{"id": 4, "type": "table", "title": "Hosts", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 9}}]},
+			{"type": "text", "title": "No ID", "gridPos": {"h": 2, "w": 24, "x": 0, "y": 17}}
+		]}`), &from)
+		So(err, ShouldBeNil)
+
+		err = json.Unmarshal([]byte(`{"panels": [
+			{"id": 2, "type": "graph", "title": "CPU usage", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+			{"id": 5, "type": "gauge", "title": "Memory", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0}},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8},
+			 "panels": [{"id": 4, "type": "table", "title": "Hosts", "gridPos": {"h": 8, "w": 24, "x": 0, "y": 9}}]}
+		]}`), &to)
+		So(err, ShouldBeNil)
+
+		added, removed, moved := DiffPanels(from.RowOrPanels, to.RowOrPanels)
+
+		Convey("Panels only in new version should be added", func() {
+			So(added, ShouldResemble, []PanelChange{
+				{ID: "5", Title: "Memory", Type: "gauge", To: GridPos{H: 8, W: 12, X: 12, Y: 0}},
+			})
+		})
+
+		Convey("Panels only in old version should be removed", func() {
+			So(removed, ShouldResemble, []PanelChange{
+				{ID: "1", Title: "Uptime", Type: "stat", From: GridPos{H: 4, W: 6, X: 0, Y: 0}},
+			})
+		})
+
+		Convey("Panels with different positions should be moved with their new titles", func() {
+			So(moved, ShouldResemble, []PanelChange{
+				{
+					ID: "2", Title: "CPU usage", Type: "graph",
+					From: GridPos{H: 8, W: 12, X: 6, Y: 0}, To: GridPos{H: 8, W: 12, X: 0, Y: 0},
+				},
+			})
+		})
+
+		Convey("Identical versions should not have any differences", func() {
+			added, removed, moved := DiffPanels(to.RowOrPanels, to.RowOrPanels)
+			So(VersionDiff{Added: added, Removed: removed, Moved: moved}.Changed(), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,24 @@
+package dashboard
+
+import (
+	"os"
+	"runtime"
+)
+
+// csvDownloadDir returns the directory where the browser is allowed to download
+// CSV files and a function that cleans it up. CSV data is read from the blob URL
+// of the download and downloaded files are never used. So, downloads are sent to
+// /dev/null except on Windows where it does not exist and a temporary directory
+// is used instead. Remote browsers are assumed to run on Linux.
+func csvDownloadDir(remote bool) (string, func(), error) {
+	if remote || runtime.GOOS != "windows" {
+		return "/dev/null", func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "grafana-reporter-csv-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
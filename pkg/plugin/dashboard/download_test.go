@@ -0,0 +1,39 @@
+package dashboard
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSVDownloadDir(t *testing.T) {
+	Convey("When getting CSV download directory", t, func() {
+		Convey("Directory should be valid on current OS", func() {
+			dir, cleanup, err := csvDownloadDir(false)
+			So(err, ShouldBeNil)
+
+			_, err = os.Stat(dir)
+			So(err, ShouldBeNil)
+
+			cleanup()
+
+			if runtime.GOOS == "windows" {
+				_, err = os.Stat(dir)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			} else {
+				So(dir, ShouldEqual, "/dev/null")
+			}
+		})
+
+		Convey("Remote browsers should download to /dev/null", func() {
+			dir, cleanup, err := csvDownloadDir(true)
+			So(err, ShouldBeNil)
+
+			cleanup()
+
+			So(dir, ShouldEqual, "/dev/null")
+		})
+	})
+}
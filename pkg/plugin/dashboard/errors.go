@@ -9,4 +9,7 @@ var (
 	ErrDashboardHTTPError       = errors.New("dashboard request does not return 200 OK")
 	ErrEmptyBlobURL             = errors.New("empty blob URL")
 	ErrEmptyCSVData             = errors.New("empty csv data")
+	ErrDashboardLoad            = errors.New("dashboard loaded with errors")
+	ErrImageTooSmall            = errors.New("panel image is smaller than minimum image size")
+	ErrRendererUnavailable      = errors.New("renderer is unavailable or overloaded")
 )
@@ -14,6 +14,16 @@ const timer = ms => new Promise(res => setTimeout(res, ms));
 // Panel data
 const panelData = selector => [...document.querySelectorAll('[' + selector + ']')].map((e) => ({ "x": e.getBoundingClientRect().x, "y": e.getBoundingClientRect().y, "width": e.getBoundingClientRect().width, "height": e.getBoundingClientRect().height, "title": e.innerText.split('\n')[0], "id": e.getAttribute(selector) }))
 
+// Error alerts shown on dashboard like datasource errors
+const dashboardErrors = () => [...document.querySelectorAll('[data-testid="data-testid Alert error"]')].map((e) => e.innerText.trim()).filter((t) => t !== '')
+
+// Hide crosshair and tooltip of shared graph tooltip mode on time series panels
+const disableSharedTooltip = () => {
+    const style = document.createElement('style');
+    style.innerHTML = '.u-cursor-x, .u-cursor-y, .u-cursor-pt, [data-testid="TooltipPlugin"] { display: none !important; }';
+    document.head.appendChild(style);
+}
+
 /**
  * Semantic Versioning Comparing
  * #see https://semver.org/
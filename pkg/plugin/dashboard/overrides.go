@@ -0,0 +1,143 @@
+package dashboard
+
+import (
+	"regexp"
+)
+
+// Field matchers of overrides that can be evaluated using field names.
+const (
+	matcherByName   = "byName"
+	matcherByRegexp = "byRegexp"
+)
+
+// FieldOverride represents a field config override of a panel that sets the unit
+// and/or display name of the fields matching it.
+type FieldOverride struct {
+	Matcher     string
+	Pattern     string
+	Unit        string
+	DisplayName string
+
+	re *regexp.Regexp
+}
+
+// fieldConfigOverride represents an override of field config in dashboard model.
+type fieldConfigOverride struct {
+	Matcher struct {
+		ID      string `json:"id"`
+		Options any    `json:"options"`
+	} `json:"matcher"`
+	Properties []struct {
+		ID    string `json:"id"`
+		Value any    `json:"value"`
+	} `json:"properties"`
+}
+
+// fieldOverrides returns the overrides of the panel field config that match fields
+// by name or regex and override unit or display name. Other overrides cannot be
+// applied to CSV data and are ignored.
+func fieldOverrides(overrides []fieldConfigOverride) []FieldOverride {
+	var fieldOverrides []FieldOverride
+
+	for _, o := range overrides {
+		if o.Matcher.ID != matcherByName && o.Matcher.ID != matcherByRegexp {
+			continue
+		}
+
+		pattern, ok := o.Matcher.Options.(string)
+		if !ok || pattern == "" {
+			continue
+		}
+
+		override := FieldOverride{Matcher: o.Matcher.ID, Pattern: pattern}
+
+		// Compile regex once here as it is matched against every field of the panel.
+		// Overrides with invalid regex never match any field and are ignored
+		if override.Matcher == matcherByRegexp {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				continue
+			}
+
+			override.re = re
+		}
+
+		for _, p := range o.Properties {
+			value, ok := p.Value.(string)
+			if !ok {
+				continue
+			}
+
+			switch p.ID {
+			case "unit":
+				override.Unit = value
+			case "displayName":
+				override.DisplayName = value
+			}
+		}
+
+		if override.Unit != "" || override.DisplayName != "" {
+			fieldOverrides = append(fieldOverrides, override)
+		}
+	}
+
+	return fieldOverrides
+}
+
+// matches returns true if the field with name is matched by the override. Fields
+// already renamed by the override are matched using their display name.
+func (o FieldOverride) matches(name string) bool {
+	if o.DisplayName != "" && name == o.DisplayName {
+		return true
+	}
+
+	switch o.Matcher {
+	case matcherByName:
+		return name == o.Pattern
+	case matcherByRegexp:
+		return o.re != nil && o.re.MatchString(name)
+	}
+
+	return false
+}
+
+// FieldUnit returns the unit of the field with name. Like in Grafana, the last
+// matching override takes precedence over the previous ones and the default unit.
+func (p Panel) FieldUnit(name string) string {
+	unit := p.Unit
+
+	for _, o := range p.Overrides {
+		if o.Unit != "" && o.matches(name) {
+			unit = o.Unit
+		}
+	}
+
+	return unit
+}
+
+// FieldDisplayName returns the display name of the field with name.
+func (p Panel) FieldDisplayName(name string) string {
+	displayName := name
+
+	for _, o := range p.Overrides {
+		if o.DisplayName != "" && o.matches(name) {
+			displayName = o.DisplayName
+		}
+	}
+
+	return displayName
+}
+
+// applyFieldOverrides renames column headers of CSV data to the display names of
+// the fields set by overrides of the panel.
+func applyFieldOverrides(data CSVData, p Panel) CSVData {
+	if len(data) == 0 || len(p.Overrides) == 0 {
+		return data
+	}
+
+	for icol, header := range data[0] {
+		data[0][icol] = p.FieldDisplayName(header)
+	}
+
+	return data
+}
@@ -0,0 +1,87 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFieldOverrides(t *testing.T) {
+	Convey("When reading field overrides of a panel", t, func() {
+		var p Panel
+
+		err := json.Unmarshal([]byte(`{
+			"id": 1,
+			"type": "table",
+			"fieldConfig": {
+				"defaults": {"unit": "short"},
+				"overrides": [
+					{"matcher": {"id": "byName", "options": "cpu_usage"}, "properties": [
+						{"id": "unit", "value": "percent"},
+						{"id": "displayName", "value": "CPU"}
+					]},
+					{"matcher": {"id": "byRegexp", "options": "^mem_.*"}, "properties": [
+						{"id": "unit", "value": "bytes"},
+						{"id": "decimals", "value": 2}
+					]},
+					{"matcher": {"id": "byName", "options": "mem_free"}, "properties": [
+						{"id": "unit", "value": "decbytes"},
+						{"id": "displayName", "value": "Free memory"}
+					]},
+					{"matcher": {"id": "byType", "options": "time"}, "properties": [
+						{"id": "displayName", "value": "Timestamp"}
+					]},
+					{"matcher": {"id": "byName", "options": "disk"}, "properties": [
+						{"id": "color", "value": {"mode": "fixed"}}
+					]},
+					{"matcher": {"id": "byRegexp", "options": "^(disk"}, "properties": [
+						{"id": "unit", "value": "bytes"}
+					]}
+				]
+			}
+		}`), &p)
+		So(err, ShouldBeNil)
+
+		Convey("Only overrides of unit and display name by name or valid regex should be kept", func() {
+			overrides := make([]FieldOverride, len(p.Overrides))
+			for i, o := range p.Overrides {
+				overrides[i] = FieldOverride{Matcher: o.Matcher, Pattern: o.Pattern, Unit: o.Unit, DisplayName: o.DisplayName}
+			}
+
+			So(p.Overrides[1].re, ShouldNotBeNil)
+			So(overrides, ShouldResemble, []FieldOverride{
+				{Matcher: "byName", Pattern: "cpu_usage", Unit: "percent", DisplayName: "CPU"},
+				{Matcher: "byRegexp", Pattern: "^mem_.*", Unit: "bytes"},
+				{Matcher: "byName", Pattern: "mem_free", Unit: "decbytes", DisplayName: "Free memory"},
+			})
+		})
+
+		Convey("Units of fields should follow the last matching override", func() {
+			So(p.FieldUnit("cpu_usage"), ShouldEqual, "percent")
+			So(p.FieldUnit("CPU"), ShouldEqual, "percent")
+			So(p.FieldUnit("mem_used"), ShouldEqual, "bytes")
+			So(p.FieldUnit("mem_free"), ShouldEqual, "decbytes")
+			So(p.FieldUnit("Free memory"), ShouldEqual, "decbytes")
+			So(p.FieldUnit("disk"), ShouldEqual, "short")
+		})
+
+		Convey("CSV headers should be renamed to display names", func() {
+			data := applyFieldOverrides(CSVData{
+				{"Time", "cpu_usage", "mem_used", "mem_free"},
+				{"2024-12-14 10:00:00", "12", "1024", "2048"},
+			}, p)
+
+			So(data, ShouldResemble, CSVData{
+				{"Time", "CPU", "mem_used", "Free memory"},
+				{"2024-12-14 10:00:00", "12", "1024", "2048"},
+			})
+		})
+
+		Convey("Panels without overrides should keep their data", func() {
+			data := CSVData{{"Time", "cpu_usage"}}
+
+			So(applyFieldOverrides(data, Panel{}), ShouldResemble, data)
+		})
+	})
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 )
 
@@ -50,11 +52,24 @@ var (
 	viewportHeight int64 = 10800
 )
 
-// panels fetches dashboard panels from Grafana chromium browser instance.
+// panels fetches dashboard panels from Grafana chromium browser instance. When
+// configured, panels are made from dashboard JSON model instead.
 func (d *Dashboard) panels(ctx context.Context) ([]Panel, error) {
+	// Make panels only from dashboard JSON model
+	if d.metadataSource() == "api" {
+		return d.modelPanels()
+	}
+
 	// Fetch dashboard data from browser
 	dashboardData, err := d.panelMetaData(ctx)
 	if err != nil {
+		// If asked, fallback to dashboard JSON model
+		if d.metadataSource() == "both" && d.conf.MetadataFallback {
+			d.logger.Warn("failed to get dashboard data from browser. Using dashboard model", "err", err)
+
+			return d.modelPanels()
+		}
+
 		return nil, fmt.Errorf("failed to get dashboard data from browser: %w", err)
 	}
 
@@ -65,16 +80,20 @@ func (d *Dashboard) panels(ctx context.Context) ([]Panel, error) {
 }
 
 // panelMetaData fetches dashboard panels metadata from Grafana chromium browser instance.
-func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
+func (d *Dashboard) panelMetaData(ctx context.Context) ([]interface{}, error) {
 	// Get dashboard URL
-	dashURL := fmt.Sprintf("%s/d/%s/_?%s", d.appURL, d.model.Dashboard.UID, d.model.Dashboard.Variables.Encode())
+	dashURL := d.grafanaURL(d.queryValues(), "d", d.model.Dashboard.UID, "_").String()
 
 	defer helpers.TimeTrack(time.Now(), "fetch dashboard panels metadata", d.logger, "url", dashURL)
 
-	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
-	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
-	defer tab.Close(d.logger)
+	// Get a tab from the pool
+	tab, err := d.chromeInstance.AcquireTab(ctx, d.logger, d.conf)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring tab: %w", err)
+	}
+
+	tab.WithTimeout(2 * d.conf.TabTimeout())
+	defer d.chromeInstance.ReleaseTab(d.logger, tab)
 
 	headers := make(map[string]any)
 
@@ -84,7 +103,7 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 		}
 	}
 
-	err := tab.NavigateAndWaitFor(dashURL, headers, "networkIdle")
+	err = tab.NavigateAndWaitFor(dashURL, headers, "networkIdle")
 	if err != nil {
 		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
 	}
@@ -92,13 +111,16 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 	tasks := make(chromedp.Tasks, 0)
 
 	// Fetch dashboard data
-	var dashboardData []interface{}
+	var (
+		dashboardData []interface{}
+		dashboardErrs []string
+	)
 
 	// var buf []byte
 
 	js := fmt.Sprintf(
 		`waitForQueriesAndVisualizations(version = '%s', mode = '%s', timeout = %d);`,
-		d.appVersion, d.conf.DashboardMode, d.conf.HTTPClientOptions.Timeouts.Timeout.Milliseconds(),
+		d.appVersion, d.conf.DashboardMode, d.conf.TabTimeout().Milliseconds(),
 	)
 
 	// JS that will fetch dashboard model
@@ -108,6 +130,7 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 		chromedp.Evaluate(js, &dashboardData, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 			return p.WithAwaitPromise(true)
 		}),
+		chromedp.Evaluate(`dashboardErrors();`, &dashboardErrs),
 	}...)
 
 	if err := tab.Run(tasks); err != nil {
@@ -118,6 +141,10 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 	// 	d.logger.Error("failed to write screenshot", "err", err)
 	// }
 
+	if err := d.checkDashboardErrors(dashboardErrs); err != nil {
+		return nil, err
+	}
+
 	if len(dashboardData) == 0 {
 		return nil, ErrJavaScriptReturnedNoData
 	}
@@ -125,6 +152,24 @@ func (d *Dashboard) panelMetaData(_ context.Context) ([]interface{}, error) {
 	return dashboardData, nil
 }
 
+// checkDashboardErrors handles errors shown on dashboard, like datasource errors,
+// based on configured action. When action is fail, an error is returned with all
+// the messages and when action is warn, messages are only logged.
+func (d *Dashboard) checkDashboardErrors(msgs []string) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	switch d.conf.OnDashboardError {
+	case "fail":
+		return fmt.Errorf("%w: %s", ErrDashboardLoad, strings.Join(msgs, "; "))
+	case "warn":
+		d.logger.Warn("dashboard loaded with errors", "errors", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
 // panels creates slice of panels from the data fetched from browser's DOM model.
 func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 	var (
@@ -144,9 +189,18 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 	// So we check the maxWidth from returned coordinates and (w, h) tuples.
 	// Max Width = Max X + Width for that element
 	// We divide this maxWidth in 24 columns as done in Grafana to calculate Panel
-	// coordinates
+	// coordinates. If configured with a different number of grid columns, we
+	// use that number instead so that panels are rendered as if dashboard had
+	// that many columns
 	var maxWidth float64
 
+	// Lookup of panels in dashboard JSON model, which is empty when metadata
+	// must only be read from browser
+	var modelPanels map[string]Panel
+	if d.metadataSource() != "browser" {
+		modelPanels = d.modelPanelsByID()
+	}
+
 	// Iterate over the slice of interfaces and build each panel
 	for _, panelData := range dashData {
 		var p Panel
@@ -196,26 +250,21 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 			continue
 		}
 
-		// // Populate Type and Title from dashboard JSON model
-		// for _, rowOrPanel := range d.model.Dashboard.RowOrPanels {
-		// 	if rowOrPanel.Type == "row" {
-		// 		for _, rp := range rowOrPanel.Panels {
-		// 			if rp.ID == p.ID {
-		// 				p.Type = rp.Type
-		// 				p.Title = rp.Title
-
-		// 				break
-		// 			}
-		// 		}
-		// 	} else {
-		// 		if p.ID == rowOrPanel.ID {
-		// 			p.Type = rowOrPanel.Type
-		// 			p.Title = rowOrPanel.Title
-
-		// 			break
-		// 		}
-		// 	}
-		// }
+		// Populate Type, Unit, datasource type, repeat direction and row from
+		// dashboard JSON model. Title is only taken from the model when browser
+		// did not return one as the latter has variables already interpolated
+		if mp, ok := modelPanels[modelPanelID(p.ID)]; ok {
+			p.Type = mp.Type
+			p.Unit = mp.Unit
+			p.Overrides = mp.Overrides
+			p.DatasourceType = mp.DatasourceType
+			p.RepeatDirection = mp.RepeatDirection
+			p.Row = mp.Row
+
+			if p.Title == "" {
+				p.Title = mp.Title
+			}
+		}
 
 		// Create panel model and append to panels
 		panels = append(panels, p)
@@ -223,18 +272,22 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 	}
 
 	// Remove xOffset and yOffset from all coordinates of panels
-	// and estimate new width scale based on max width
-	newScales := scales
-	newScales["width"] = math.Round((maxWidth - xOffset) / 24)
+	// and estimate width scale based on max width
+	widthScale := math.Round((maxWidth - xOffset) / float64(d.gridColumns()))
 
 	// Estimate Panel coordinates in Grafana column scale
 	for ipanel := range panels {
-		panels[ipanel].GridPos.X = math.Round((panels[ipanel].GridPos.X - xOffset) / scales["width"])
+		panels[ipanel].GridPos.X = math.Round((panels[ipanel].GridPos.X - xOffset) / widthScale)
 		panels[ipanel].GridPos.Y = math.Round((panels[ipanel].GridPos.Y - yOffset) / scales["height"])
-		panels[ipanel].GridPos.W = math.Round(panels[ipanel].GridPos.W / scales["width"])
+		panels[ipanel].GridPos.W = math.Round(panels[ipanel].GridPos.W / widthScale)
 		panels[ipanel].GridPos.H = math.Round(panels[ipanel].GridPos.H / scales["height"])
 	}
 
+	// If asked, order repeated panels by their repeat index
+	if d.conf != nil && d.conf.OrderRepeatsByValue {
+		panels = orderRepeatedPanels(panels)
+	}
+
 	// Check if we fetched any panels
 	if len(panels) == 0 {
 		allErrs = errors.Join(err, ErrNoPanels)
@@ -246,3 +299,183 @@ func (d *Dashboard) createPanels(dashData []interface{}) ([]Panel, error) {
 
 	return panels, allErrs
 }
+
+// gridColumns returns the number of columns of the grid used to estimate
+// panel coordinates.
+func (d *Dashboard) gridColumns() int {
+	if d.conf == nil || d.conf.GridColumns <= 0 {
+		return config.DefaultGridColumns
+	}
+
+	return d.conf.GridColumns
+}
+
+// gridScales returns the number of pixels per grid unit of width and height
+// of rendered panels in grid layout.
+func (d *Dashboard) gridScales() (float64, float64) {
+	widthScale, heightScale := float64(config.DefaultGridWidthScale), float64(config.DefaultGridHeightScale)
+
+	if d.conf != nil && d.conf.GridWidthScale > 0 {
+		widthScale = d.conf.GridWidthScale
+	}
+
+	if d.conf != nil && d.conf.GridHeightScale > 0 {
+		heightScale = d.conf.GridHeightScale
+	}
+
+	return widthScale, heightScale
+}
+
+// metadataSource returns the source of panels metadata.
+func (d *Dashboard) metadataSource() string {
+	if d.conf == nil || d.conf.MetadataSource == "" {
+		return "both"
+	}
+
+	return d.conf.MetadataSource
+}
+
+// modelPanels returns panels from dashboard JSON model. Panels of collapsed rows
+// are only included in full dashboard mode. Repeated panels are not expanded as
+// they are only known to the browser.
+func (d *Dashboard) modelPanels() ([]Panel, error) {
+	var panels []Panel
+
+	var row string
+
+	for _, rowOrPanel := range d.model.Dashboard.RowOrPanels {
+		if rowOrPanel.Type != "row" {
+			p := rowOrPanel.Panel
+			p.Row = row
+			panels = append(panels, p)
+
+			continue
+		}
+
+		row = rowOrPanel.Title
+
+		if rowOrPanel.Collapsed && d.conf.DashboardMode == "full" {
+			for _, p := range rowOrPanel.Panels {
+				p.Row = row
+				panels = append(panels, p)
+			}
+		}
+	}
+
+	if len(panels) == 0 {
+		return nil, ErrNoPanels
+	}
+
+	// Order panels as they appear on the dashboard
+	sort.SliceStable(panels, func(i, j int) bool {
+		if panels[i].GridPos.Y != panels[j].GridPos.Y {
+			return panels[i].GridPos.Y < panels[j].GridPos.Y
+		}
+
+		return panels[i].GridPos.X < panels[j].GridPos.X
+	})
+
+	return panels, nil
+}
+
+// modelPanelsByID returns the panels of dashboard JSON model, including the
+// ones nested in rows, keyed by their ID. Rows are excluded.
+func (d *Dashboard) modelPanelsByID() map[string]Panel {
+	panels := make(map[string]Panel)
+
+	if d.model == nil {
+		return panels
+	}
+
+	// Panels following a row belong to that row until the next one
+	var row string
+
+	for _, rowOrPanel := range d.model.Dashboard.RowOrPanels {
+		if rowOrPanel.Type == "row" {
+			row = rowOrPanel.Title
+
+			for _, rp := range rowOrPanel.Panels {
+				rp.Row = row
+				panels[rp.ID] = rp
+			}
+
+			continue
+		}
+
+		p := rowOrPanel.Panel
+		p.Row = row
+		panels[p.ID] = p
+	}
+
+	return panels
+}
+
+// modelPanelID returns the ID of the panel in dashboard JSON model for the ID
+// of panel in browser. Repeated panels share the ID of the source panel and
+// starting from Grafana v11.3.0, panel IDs are prefixed with panel-.
+func modelPanelID(id string) string {
+	id, _ = Panel{ID: id}.RepeatIndex()
+
+	return strings.TrimPrefix(id, "panel-")
+}
+
+// orderRepeatedPanels sorts the repeated panels by their clone index within each
+// repeat group. Sorted panels take the slots (index and grid position) of the
+// group so that the panels that are not repeated are left untouched. Grid positions
+// of the slots are filled column wise for vertically repeated panels and row wise
+// otherwise.
+func orderRepeatedPanels(panels []Panel) []Panel {
+	slots := make(map[string][]int)
+
+	for ipanel, p := range panels {
+		base, _ := p.RepeatIndex()
+		slots[base] = append(slots[base], ipanel)
+	}
+
+	ordered := make([]Panel, len(panels))
+	copy(ordered, panels)
+
+	for _, group := range slots {
+		if len(group) < 2 {
+			continue
+		}
+
+		members := make([]Panel, len(group))
+		for i, ipanel := range group {
+			members[i] = panels[ipanel]
+		}
+
+		sort.SliceStable(members, func(i, j int) bool {
+			_, ii := members[i].RepeatIndex()
+			_, ij := members[j].RepeatIndex()
+
+			return ii < ij
+		})
+
+		positions := make([]GridPos, len(group))
+		for i, ipanel := range group {
+			positions[i] = panels[ipanel].GridPos
+		}
+
+		vertical := members[0].RepeatDirection == "v"
+
+		sort.SliceStable(positions, func(i, j int) bool {
+			if vertical && positions[i].X != positions[j].X {
+				return positions[i].X < positions[j].X
+			}
+
+			if positions[i].Y != positions[j].Y {
+				return positions[i].Y < positions[j].Y
+			}
+
+			return positions[i].X < positions[j].X
+		})
+
+		for i, ipanel := range group {
+			members[i].GridPos = positions[i]
+			ordered[ipanel] = members[i]
+		}
+	}
+
+	return ordered
+}
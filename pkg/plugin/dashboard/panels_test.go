@@ -3,15 +3,16 @@ package dashboard
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/chromedp/chromedp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -50,7 +51,7 @@ func TestDashboardFetchWithLocalChrome(t *testing.T) {
 	}
 
 	Convey("When fetching a Dashboard", t, func() {
-		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true)
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
 		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
 
 		Convey("setup a chrome browser should not error", func() {
@@ -105,15 +106,7 @@ func TestDashboardFetchWithLocalChrome(t *testing.T) {
 				chromeInstance,
 				ts.URL,
 				"v11.4.0",
-				&Model{Dashboard: struct {
-					ID          int          `json:"id"`
-					UID         string       `json:"uid"`
-					Title       string       `json:"title"`
-					Description string       `json:"description"`
-					RowOrPanels []RowOrPanel `json:"panels"`
-					Panels      []Panel
-					Variables   url.Values
-				}{
+				&Model{Dashboard: Spec{
 					UID: "randomUID",
 				}},
 				http.Header{
@@ -155,6 +148,8 @@ func TestDashboardFetchWithRemoteChrome(t *testing.T) {
 			context.Background(),
 			log.NewNullLogger(),
 			chromeRemoteAddr,
+			0,
+			0,
 		)
 
 		Convey("setup a chrome browser should not error", func() {
@@ -209,15 +204,7 @@ func TestDashboardFetchWithRemoteChrome(t *testing.T) {
 				chromeInstance,
 				ts.URL,
 				"v11.4.0",
-				&Model{Dashboard: struct {
-					ID          int          `json:"id"`
-					UID         string       `json:"uid"`
-					Title       string       `json:"title"`
-					Description string       `json:"description"`
-					RowOrPanels []RowOrPanel `json:"panels"`
-					Panels      []Panel
-					Variables   url.Values
-				}{
+				&Model{Dashboard: Spec{
 					UID: "randomUID",
 				}},
 				http.Header{
@@ -256,15 +243,7 @@ func TestDashboardCreatePanels(t *testing.T) {
 			nil,
 			"http://localhost:3000",
 			"v11.4.0",
-			&Model{Dashboard: struct {
-				ID          int          `json:"id"`
-				UID         string       `json:"uid"`
-				Title       string       `json:"title"`
-				Description string       `json:"description"`
-				RowOrPanels []RowOrPanel `json:"panels"`
-				Panels      []Panel
-				Variables   url.Values
-			}{
+			&Model{Dashboard: Spec{
 				UID: "randomUID",
 			}},
 			nil,
@@ -296,3 +275,395 @@ func TestDashboardCreatePanels(t *testing.T) {
 		})
 	})
 }
+
+func TestDashboardCreatePanelsFromModel(t *testing.T) {
+	Convey("When creating panels for Dashboard with rows in its model", t, func() {
+		var model Model
+
+		err := json.Unmarshal([]byte(`{"dashboard": {"uid": "randomUID", "panels": [
+			{"id": 12, "type": "table", "title": "Table", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+			{"id": 20, "type": "row", "title": "Open row", "collapsed": false, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8}},
+			{"id": 26, "type": "timeseries", "title": "CPU on $host", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 9}},
+			{"id": 30, "type": "row", "title": "Collapsed row", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 17},
+			 "panels": [{"id": 27, "type": "stat", "title": "Stat", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 18}}]}
+		]}}`), &model)
+
+		Convey("setup dashboard model unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		dash, err := New(log.NewNullLogger(), &config.Config{}, nil, nil, "http://localhost:3000", "v11.4.0", &model, nil)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		dashDataString := `[{"width":940,"height":258,"x":0,"y":0,"id":"panel-12"},{"width":940,"height":258,"x":0,"y":290,"id":"panel-26","title":"CPU on node1"},{"width":940,"height":258,"x":940,"y":290,"id":"panel-26-clone-1","title":"CPU on node2"},{"width":940,"height":258,"x":0,"y":580,"id":"panel-27"}]`
+
+		var dashData []interface{}
+		err = json.Unmarshal([]byte(dashDataString), &dashData)
+
+		Convey("setup dashboard data unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		panels, err := dash.createPanels(dashData)
+
+		Convey("It should receive no errors", func() {
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 4)
+		})
+
+		Convey("Types should be resolved from the model", func() {
+			So(panels[0].Type, ShouldEqual, "table")
+			So(panels[1].Type, ShouldEqual, "timeseries")
+			So(panels[2].Type, ShouldEqual, "timeseries")
+			So(panels[3].Type, ShouldEqual, "stat")
+		})
+
+		Convey("Titles should be taken from the model only when browser has none", func() {
+			So(panels[0].Title, ShouldEqual, "Table")
+			So(panels[1].Title, ShouldEqual, "CPU on node1")
+			So(panels[2].Title, ShouldEqual, "CPU on node2")
+			So(panels[3].Title, ShouldEqual, "Stat")
+		})
+
+		Convey("Rows should be resolved for standalone and nested panels", func() {
+			So(panels[0].Row, ShouldBeEmpty)
+			So(panels[1].Row, ShouldEqual, "Open row")
+			So(panels[2].Row, ShouldEqual, "Open row")
+			So(panels[3].Row, ShouldEqual, "Collapsed row")
+		})
+	})
+}
+
+func TestDashboardMetadataSource(t *testing.T) {
+	Convey("When making panels from different metadata sources", t, func() {
+		var model Model
+
+		err := json.Unmarshal([]byte(`{"dashboard": {"uid": "randomUID", "panels": [
+			{"id": 26, "type": "graph", "title": "Graph", "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0},
+			 "fieldConfig": {"defaults": {"unit": "bytes"}}},
+			{"id": 12, "type": "table", "title": "Table", "repeatDirection": "v", "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}},
+			{"id": 30, "type": "row", "title": "Row", "collapsed": true, "gridPos": {"h": 1, "w": 24, "x": 0, "y": 8},
+			 "panels": [{"id": 27, "type": "stat", "title": "Stat", "gridPos": {"h": 4, "w": 6, "x": 0, "y": 9}}]}
+		]}}`), &model)
+
+		Convey("setup dashboard model unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		conf := config.Config{DashboardMode: "default"}
+
+		dash, err := New(log.NewNullLogger(), &conf, nil, nil, "http://localhost:3000", "v11.4.0", &model, nil)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		var dashData []interface{}
+		err = json.Unmarshal([]byte(`[{"width":940,"height":258,"x":0,"y":0,"id":"12"},{"width":940,"height":258,"x":940,"y":0,"id":"26"}]`), &dashData)
+
+		Convey("setup dashboard data unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Panels should be made only from dashboard model with api source", func() {
+			conf.MetadataSource = "api"
+			panels, err := dash.panels(context.Background())
+
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 2)
+			So(panels[0].ID, ShouldEqual, "12")
+			So(panels[0].Type, ShouldEqual, "table")
+			So(panels[1].ID, ShouldEqual, "26")
+			So(panels[1].GridPos, ShouldResemble, GridPos{H: 8, W: 12, X: 12, Y: 0})
+		})
+
+		Convey("Panels of collapsed rows should be included with api source in full mode", func() {
+			conf.MetadataSource = "api"
+			conf.DashboardMode = "full"
+			panels, err := dash.panels(context.Background())
+
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 3)
+			So(panels[2].ID, ShouldEqual, "27")
+			So(panels[2].Type, ShouldEqual, "stat")
+			So(panels[2].Row, ShouldEqual, "Row")
+			So(panels[0].Row, ShouldBeEmpty)
+		})
+
+		Convey("Panel types should be populated from dashboard model with both sources", func() {
+			conf.MetadataSource = "both"
+			panels, err := dash.createPanels(dashData)
+
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 2)
+			So(panels[0].Type, ShouldEqual, "table")
+			So(panels[1].Type, ShouldEqual, "graph")
+			So(panels[1].Unit, ShouldEqual, "bytes")
+			So(panels[0].RepeatDirection, ShouldEqual, "v")
+		})
+
+		Convey("Panel types should not be populated from dashboard model with browser source", func() {
+			conf.MetadataSource = "browser"
+			panels, err := dash.createPanels(dashData)
+
+			So(err, ShouldBeNil)
+			So(panels, ShouldHaveLength, 2)
+			So(panels[0].Type, ShouldBeEmpty)
+			So(panels[1].Type, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestDashboardGridColumns(t *testing.T) {
+	Convey("When creating panels for Dashboard with custom grid columns", t, func() {
+		dashDataString := `[{"width":940,"height":258,"x":0,"y":0,"id":"12"},{"width":940,"height":258,"x":940,"y":0,"id":"26"},{"width":1880,"height":258,"x":0,"y":258,"id":"30"}]`
+
+		var dashData []interface{}
+		err := json.Unmarshal([]byte(dashDataString), &dashData)
+
+		Convey("setup dashboard data unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		cases := map[string]struct {
+			GridColumns int
+			X, W, FullW float64
+			Width       int64
+		}{
+			"default": {0, 12, 12, 24, 1200},
+			"24":      {24, 12, 12, 24, 1200},
+			"12":      {12, 6, 6, 12, 1200},
+			"6":       {6, 3, 3, 6, 1200},
+		}
+
+		for clName, cl := range cases {
+			dash, err := New(
+				log.NewNullLogger(),
+				&config.Config{Layout: "grid", GridColumns: cl.GridColumns},
+				nil,
+				nil,
+				"http://localhost:3000",
+				"v11.4.0",
+				&Model{Dashboard: Spec{
+					UID: "randomUID",
+				}},
+				nil,
+			)
+			So(err, ShouldBeNil)
+
+			panels, err := dash.createPanels(dashData)
+
+			Convey("Panel coordinates should use grid columns: "+clName, func() {
+				So(err, ShouldBeNil)
+				So(panels, ShouldHaveLength, 3)
+				So(panels[0].GridPos.X, ShouldEqual, 0)
+				So(panels[0].GridPos.W, ShouldEqual, cl.W)
+				So(panels[1].GridPos.X, ShouldEqual, cl.X)
+				So(panels[1].GridPos.W, ShouldEqual, cl.W)
+			})
+
+			Convey("Full width panel should span all grid columns: "+clName, func() {
+				So(panels[2].GridPos.X, ShouldEqual, 0)
+				So(panels[2].GridPos.W, ShouldEqual, cl.FullW)
+			})
+
+			Convey("Panel width should be scaled to grid columns: "+clName, func() {
+				width, _ := dash.panelDims(panels[0])
+				So(width, ShouldEqual, cl.Width)
+
+				// Full width panels have the same resolution whatever the grid columns
+				width, _ = dash.panelDims(panels[2])
+				So(width, ShouldEqual, 2*cl.Width)
+			})
+		}
+	})
+}
+
+func TestDashboardOrderRepeatedPanels(t *testing.T) {
+	Convey("When creating panels for Dashboard with repeated panels", t, func() {
+		dash, err := New(
+			log.NewNullLogger(),
+			&config.Config{OrderRepeatsByValue: true},
+			nil,
+			nil,
+			"http://localhost:3000",
+			"v11.4.0",
+			&Model{Dashboard: Spec{
+				UID: "randomUID",
+			}},
+			nil,
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		dashDataString := `[{"width":470,"height":258,"x":0,"y":0,"id":"panel-1"},{"width":470,"height":258,"x":470,"y":0,"id":"panel-2-clone-2"},{"width":470,"height":258,"x":940,"y":0,"id":"panel-2"},{"width":470,"height":258,"x":1410,"y":0,"id":"panel-2-clone-1"}]`
+
+		var dashData []interface{}
+		err = json.Unmarshal([]byte(dashDataString), &dashData)
+
+		Convey("setup dashboard data unmarshal", func() {
+			So(err, ShouldBeNil)
+		})
+
+		panels, err := dash.createPanels(dashData)
+
+		Convey("It should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+		Convey("It should order repeated panels by their clone index", func() {
+			So(panels, ShouldHaveLength, 4)
+			So(panels[0].ID, ShouldEqual, "panel-1")
+			So(panels[1].ID, ShouldEqual, "panel-2")
+			So(panels[2].ID, ShouldEqual, "panel-2-clone-1")
+			So(panels[3].ID, ShouldEqual, "panel-2-clone-2")
+		})
+		Convey("It should keep the grid positions of the slots", func() {
+			So(panels[1].GridPos.X, ShouldBeLessThan, panels[2].GridPos.X)
+			So(panels[2].GridPos.X, ShouldBeLessThan, panels[3].GridPos.X)
+		})
+	})
+}
+
+func TestDashboardErrorsWithLocalChrome(t *testing.T) {
+	execPath, err := exec.LookPath("google-chrome")
+	if err != nil || execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When detecting errors shown on a dashboard", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		Convey("setup a chrome browser should not error", func() {
+			So(err, ShouldBeNil)
+		})
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "testdata/dashboard_error.html")
+		}))
+		defer ts.Close()
+
+		conf := config.Config{HTTPClientOptions: httpclient.Options{Timeouts: &httpclient.DefaultTimeoutOptions}}
+
+		dash, err := New(log.NewNullLogger(), &conf, http.DefaultClient, chromeInstance, ts.URL, "v11.4.0", &Model{}, nil)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		tab, err := chromeInstance.NewTab(context.Background(), log.NewNullLogger(), &conf)
+		So(err, ShouldBeNil)
+
+		defer tab.Close(log.NewNullLogger())
+
+		var msgs []string
+
+		err = tab.NavigateAndWaitFor(ts.URL, nil, "networkIdle")
+		if err == nil {
+			err = tab.Run(chromedp.Tasks{
+				chromedp.Evaluate(dash.jsContent, nil),
+				chromedp.Evaluate(`dashboardErrors();`, &msgs),
+			})
+		}
+
+		Convey("It should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		Convey("It should return only error alerts", func() {
+			So(msgs, ShouldResemble, []string{"Datasource prometheus was not found"})
+		})
+	})
+}
+
+func TestDashboardCheckErrors(t *testing.T) {
+	Convey("When checking errors shown on a dashboard", t, func() {
+		conf := config.Config{}
+
+		dash, err := New(log.NewNullLogger(), &conf, nil, nil, "http://localhost:3000", "v11.4.0", &Model{}, nil)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		msgs := []string{"Datasource prometheus was not found", "Query error"}
+
+		Convey("No errors should be returned without error messages", func() {
+			conf.OnDashboardError = "fail"
+
+			So(dash.checkDashboardErrors(nil), ShouldBeNil)
+		})
+
+		Convey("Error should be returned when action is fail", func() {
+			conf.OnDashboardError = "fail"
+			err := dash.checkDashboardErrors(msgs)
+
+			So(errors.Is(err, ErrDashboardLoad), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "Datasource prometheus was not found; Query error")
+		})
+
+		Convey("No error should be returned when action is warn", func() {
+			conf.OnDashboardError = "warn"
+
+			So(dash.checkDashboardErrors(msgs), ShouldBeNil)
+		})
+
+		Convey("No error should be returned when action is continue", func() {
+			conf.OnDashboardError = "continue"
+
+			So(dash.checkDashboardErrors(msgs), ShouldBeNil)
+		})
+	})
+}
+
+func TestOrderRepeatedPanelsDirection(t *testing.T) {
+	Convey("When ordering repeated panels with repeat direction", t, func() {
+		// 2x2 grid of repeated panels in shuffled order
+		positions := []GridPos{
+			{H: 8, W: 12, X: 0, Y: 0},
+			{H: 8, W: 12, X: 12, Y: 0},
+			{H: 8, W: 12, X: 0, Y: 8},
+			{H: 8, W: 12, X: 12, Y: 8},
+		}
+
+		panels := func(direction string) []Panel {
+			return []Panel{
+				{ID: "panel-2-clone-3", GridPos: positions[0], RepeatDirection: direction},
+				{ID: "panel-2-clone-1", GridPos: positions[1], RepeatDirection: direction},
+				{ID: "panel-2", GridPos: positions[2], RepeatDirection: direction},
+				{ID: "panel-2-clone-2", GridPos: positions[3], RepeatDirection: direction},
+			}
+		}
+
+		Convey("Horizontally repeated panels should fill grid row wise", func() {
+			ordered := orderRepeatedPanels(panels("h"))
+
+			So(ordered[0].ID, ShouldEqual, "panel-2")
+			So(ordered[0].GridPos, ShouldResemble, positions[0])
+			So(ordered[1].ID, ShouldEqual, "panel-2-clone-1")
+			So(ordered[1].GridPos, ShouldResemble, positions[1])
+			So(ordered[2].ID, ShouldEqual, "panel-2-clone-2")
+			So(ordered[2].GridPos, ShouldResemble, positions[2])
+			So(ordered[3].ID, ShouldEqual, "panel-2-clone-3")
+			So(ordered[3].GridPos, ShouldResemble, positions[3])
+		})
+
+		Convey("Vertically repeated panels should fill grid column wise", func() {
+			ordered := orderRepeatedPanels(panels("v"))
+
+			So(ordered[0].ID, ShouldEqual, "panel-2")
+			So(ordered[0].GridPos, ShouldResemble, positions[0])
+			So(ordered[1].ID, ShouldEqual, "panel-2-clone-1")
+			So(ordered[1].GridPos, ShouldResemble, positions[2])
+			So(ordered[2].ID, ShouldEqual, "panel-2-clone-2")
+			So(ordered[2].GridPos, ShouldResemble, positions[1])
+			So(ordered[3].ID, ShouldEqual, "panel-2-clone-3")
+			So(ordered[3].GridPos, ShouldResemble, positions[3])
+		})
+	})
+}
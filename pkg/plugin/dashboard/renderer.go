@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
-	"maps"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,31 +13,137 @@ import (
 
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var getPanelRetrySleepTime = time.Duration(10) * time.Second
 
-// PanelPNG returns encoded PNG image of a given panel.
+// Panel dimensions in pixels below which they are considered implausible.
+const minPanelDim = 50
+
+// PanelPNG returns encoded PNG image of a given panel. When panel PNG cache is
+// enabled, panels with identical render URLs are fetched only once during the
+// lifetime of the dashboard. Failed fetches are not cached.
 func (d *Dashboard) PanelPNG(ctx context.Context, p Panel) (PanelImage, error) {
+	renderer := "grafana-image-renderer"
 	if d.conf.NativeRendering {
-		return d.panelPNGNativeRenderer(ctx, p)
+		renderer = "native"
+	}
+
+	ctx, span := helpers.StartSpan(ctx, d.conf.TracingEnabled, "dashboard.PanelPNG",
+		helpers.SpanDashboardUID.String(d.model.Dashboard.UID),
+		helpers.SpanPanelID.String(p.ID),
+		helpers.SpanRenderer.String(renderer),
+	)
+	defer span.End()
+
+	if !d.conf.PanelPNGCache {
+		image, err := d.panelPNG(ctx, p)
+		helpers.SpanError(span, err)
+
+		return image, err
+	}
+
+	// Device scale factor is not part of URL of native renderer
+	key := d.panelPNGURL(p, !d.conf.NativeRendering).String() + "@" + strconv.FormatFloat(d.conf.DeviceScaleFactor, 'f', -1, 64)
+
+	v, _ := d.pngCache.LoadOrStore(key, &pngCacheEntry{})
+
+	entry, _ := v.(*pngCacheEntry)
+	entry.once.Do(func() {
+		entry.image, entry.err = d.panelPNG(ctx, p)
+
+		// Evict failed entry so that the panel is fetched again next time. Concurrent
+		// callers waiting on this entry still get the error
+		if entry.err != nil {
+			d.pngCache.CompareAndDelete(key, entry)
+		}
+	})
+
+	helpers.SpanError(span, entry.err)
+
+	return entry.image, entry.err
+}
+
+// panelPNG returns encoded PNG image of a given panel using the configured renderer.
+func (d *Dashboard) panelPNG(ctx context.Context, p Panel) (PanelImage, error) {
+	if d.conf.NativeRendering {
+		panelImage, err := d.panelPNGNativeRenderer(ctx, p)
+		if err == nil || !d.conf.AutoFallbackRenderer {
+			return panelImage, err
+		}
+
+		d.logger.Warn("native rendering of panel failed, falling back to grafana-image-renderer", "panel_id", p.ID, "err", err)
+
+		// Span of panel must report the renderer that is actually used
+		if d.conf.TracingEnabled {
+			trace.SpanFromContext(ctx).SetAttributes(helpers.SpanRenderer.String("grafana-image-renderer"))
+		}
 	}
 
 	return d.panelPNGImageRenderer(ctx, p)
 }
 
 // panelPNGNativeRenderer returns panel PNG data by capturing screenshot of panel in browser.
-func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelImage, error) {
+func (d *Dashboard) panelPNGNativeRenderer(ctx context.Context, p Panel) (PanelImage, error) {
 	// Get panel URL
 	panelURL := d.panelPNGURL(p, false)
 
 	defer helpers.TimeTrack(time.Now(), "fetch panel PNG", d.logger, "panel_id", p.ID, "renderer", "native", "url", panelURL.String())
 
-	// Create a new tab
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
-	tab.WithTimeout(2 * d.conf.HTTPClientOptions.Timeouts.Timeout)
-	defer tab.Close(d.logger)
+	buf, err := d.capturePanelPNG(ctx, p, panelURL.String())
+	if err != nil {
+		return PanelImage{}, err
+	}
+
+	// Do multiple tries to capture screenshot if it is smaller than minimum image
+	// size. Panel is loaded again on each try and tab is released while waiting
+	// so that other panels can use it
+	for retries := 1; retries < 3 && len(buf) < d.conf.MinImageBytes; retries++ {
+		delay := getPanelRetrySleepTime * time.Duration(retries)
+
+		select {
+		case <-ctx.Done():
+			return PanelImage{}, fmt.Errorf("error waiting to retry panel PNG %s: %w", panelURL, ctx.Err())
+		case <-time.After(delay):
+		}
+
+		if buf, err = d.capturePanelPNG(ctx, p, panelURL.String()); err != nil {
+			return PanelImage{}, err
+		}
+	}
+
+	if len(buf) < d.conf.MinImageBytes {
+		return PanelImage{}, fmt.Errorf("%w: URL: %s. Size: %d bytes", ErrImageTooSmall, panelURL, len(buf))
+	}
+
+	sb := &bytes.Buffer{}
+
+	encoder := base64.NewEncoder(base64.StdEncoding, sb)
+
+	if _, err = encoder.Write(buf); err != nil {
+		return PanelImage{}, fmt.Errorf("error reading data of panel PNG: %w", err)
+	}
+
+	return PanelImage{
+		Image:    sb.String(),
+		MimeType: "image/png",
+	}, nil
+}
+
+// capturePanelPNG loads panel at panelURL in a tab of the pool and returns its
+// screenshot. Tab is released back to the pool once done.
+func (d *Dashboard) capturePanelPNG(ctx context.Context, p Panel, panelURL string) ([]byte, error) {
+	// Get a tab from the pool
+	tab, err := d.chromeInstance.AcquireTab(ctx, d.logger, d.conf)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring tab: %w", err)
+	}
+
+	tab.WithTimeout(2 * d.conf.TabTimeout())
+	defer d.chromeInstance.ReleaseTab(d.logger, tab)
 
 	headers := make(map[string]any)
 
@@ -48,9 +153,9 @@ func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelIma
 		}
 	}
 
-	err := tab.NavigateAndWaitFor(panelURL.String(), headers, "networkIdle")
+	err = tab.NavigateAndWaitFor(panelURL, headers, "networkIdle")
 	if err != nil {
-		return PanelImage{}, fmt.Errorf("NavigateAndWaitFor: %w", err)
+		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
 	}
 
 	var buf []byte
@@ -59,12 +164,13 @@ func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelIma
 
 	js := fmt.Sprintf(
 		`waitForQueriesAndVisualizations(version = '%s', timeout = %d);`,
-		d.appVersion, d.conf.HTTPClientOptions.Timeouts.Timeout.Milliseconds(),
+		d.appVersion, d.conf.TabTimeout().Milliseconds(),
 	)
 
 	tasks = append(tasks, chromedp.Tasks{
 		chromedp.Evaluate(d.jsContent, nil),
-		chromedp.EmulateViewport(d.panelDims(p)),
+		d.sharedTooltipTasks(),
+		d.emulateViewport(d.panelDims(p)),
 		chromedp.Evaluate(js, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 			return p.WithAwaitPromise(true)
 		}),
@@ -72,23 +178,82 @@ func (d *Dashboard) panelPNGNativeRenderer(_ context.Context, p Panel) (PanelIma
 	}...)
 
 	if err := tab.Run(tasks); err != nil {
-		return PanelImage{}, fmt.Errorf("error fetching panel PNG from browser %s: %w", panelURL.String(), err)
+		return nil, fmt.Errorf("error fetching panel PNG from browser %s: %w", panelURL, err)
 	}
 
-	sb := &bytes.Buffer{}
+	return buf, nil
+}
 
-	encoder := base64.NewEncoder(base64.StdEncoding, sb)
+// FullPagePNG returns encoded PNG image of the entire dashboard by capturing a full
+// page screenshot in browser. Dashboard is scrolled to the bottom to load lazy panels
+// and back to the top before capturing the screenshot.
+func (d *Dashboard) FullPagePNG(ctx context.Context) (PanelImage, error) {
+	// Get dashboard URL
+	dashURL := d.grafanaURL(d.queryValues(), "d", d.model.Dashboard.UID, "_").String()
 
-	if _, err = encoder.Write(buf); err != nil {
-		return PanelImage{}, fmt.Errorf("error reading data of panel PNG: %w", err)
+	defer helpers.TimeTrack(time.Now(), "fetch full page PNG", d.logger, "url", dashURL)
+
+	// Get a tab from the pool
+	tab, err := d.chromeInstance.AcquireTab(ctx, d.logger, d.conf)
+	if err != nil {
+		return PanelImage{}, fmt.Errorf("error acquiring tab: %w", err)
+	}
+
+	tab.WithTimeout(2 * d.conf.TabTimeout())
+	defer d.chromeInstance.ReleaseTab(d.logger, tab)
+
+	headers := make(map[string]any)
+
+	for name, values := range d.authHeader {
+		for _, value := range values {
+			headers[name] = value
+		}
+	}
+
+	err = tab.NavigateAndWaitFor(dashURL, headers, "networkIdle")
+	if err != nil {
+		return PanelImage{}, fmt.Errorf("NavigateAndWaitFor: %w", err)
+	}
+
+	var buf []byte
+
+	// waitForQueriesAndVisualizations scrolls to the bottom of the dashboard
+	js := fmt.Sprintf(
+		`waitForQueriesAndVisualizations(version = '%s', mode = '%s', timeout = %d);`,
+		d.appVersion, d.conf.DashboardMode, d.conf.TabTimeout().Milliseconds(),
+	)
+
+	tasks := chromedp.Tasks{
+		chromedp.Evaluate(d.jsContent, nil),
+		d.sharedTooltipTasks(),
+		d.emulateViewport(viewportWidth, viewportHeight),
+		chromedp.Evaluate(js, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+		chromedp.Evaluate(`window.scrollTo(0, 0);`, nil),
+		chromedp.FullScreenshot(&buf, 100),
+	}
+
+	if err := tab.Run(tasks); err != nil {
+		return PanelImage{}, fmt.Errorf("error capturing full page PNG from browser %s: %w", dashURL, err)
 	}
 
 	return PanelImage{
-		Image:    sb.String(),
+		Image:    base64.StdEncoding.EncodeToString(buf),
 		MimeType: "image/png",
 	}, nil
 }
 
+// sharedTooltipTasks returns the tasks that hide the shared crosshair and tooltip
+// on the page when shared graph tooltip is disabled.
+func (d *Dashboard) sharedTooltipTasks() chromedp.Tasks {
+	if !d.conf.DisableSharedTooltip {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{chromedp.Evaluate(`disableSharedTooltip();`, nil)}
+}
+
 // panelPNGImageRenderer returns panel PNG data by making API requests to grafana-image-renderer.
 func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelImage, error) {
 	// Get panel render URL
@@ -116,35 +281,56 @@ func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelIm
 	}
 	defer resp.Body.Close()
 
-	// Do multiple tries to get panel before giving up
-	for retries := 1; retries < 3 && resp.StatusCode != http.StatusOK; retries++ {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return PanelImage{}, fmt.Errorf("error reading response body of panel PNG: %w", err)
+	}
+
+	// Do multiple tries to get panel before giving up. Images smaller than
+	// minimum image size are considered as truncated and retried as well
+	for retries := 1; retries < 3 && (resp.StatusCode != http.StatusOK || len(body) < d.conf.MinImageBytes); retries++ {
 		resp.Body.Close()
 
 		delay := getPanelRetrySleepTime * time.Duration(retries)
-		time.Sleep(delay)
+
+		select {
+		case <-ctx.Done():
+			return PanelImage{}, fmt.Errorf("error waiting to retry request for %s: %w", panelURL, ctx.Err())
+		case <-time.After(delay):
+		}
 
 		resp, err = d.httpClient.Do(req)
 		if err != nil {
 			return PanelImage{}, fmt.Errorf("error executing retry request for %s: %w", panelURL, err)
 		}
 		defer resp.Body.Close()
-	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return PanelImage{}, fmt.Errorf("error reading response body of panel PNG: %w", err)
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return PanelImage{}, fmt.Errorf("error reading response body of panel PNG: %w", err)
+		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		// Too many requests and server errors are signs of an overloaded renderer
+		httpErr := ErrDashboardHTTPError
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			httpErr = fmt.Errorf("%w: %w", ErrDashboardHTTPError, ErrRendererUnavailable)
+		}
+
 		return PanelImage{}, fmt.Errorf(
 			"%w: URL: %s. Status: %s, message: %s",
-			ErrDashboardHTTPError,
+			httpErr,
 			panelURL,
 			resp.Status,
 			string(body),
 		)
 	}
 
+	if len(body) < d.conf.MinImageBytes {
+		return PanelImage{}, fmt.Errorf("%w: URL: %s. Size: %d bytes", ErrImageTooSmall, panelURL, len(body))
+	}
+
 	sb := &bytes.Buffer{}
 	sb.Grow(base64.StdEncoding.EncodedLen(int(resp.ContentLength)))
 
@@ -160,9 +346,19 @@ func (d *Dashboard) panelPNGImageRenderer(ctx context.Context, p Panel) (PanelIm
 	}, nil
 }
 
+// emulateViewport returns the action to emulate viewport of given dimensions in
+// browser. Configured device scale factor is applied, if any.
+func (d *Dashboard) emulateViewport(width, height int64) chromedp.EmulateAction {
+	if d.conf.DeviceScaleFactor > 0 {
+		return chromedp.EmulateViewport(width, height, chromedp.EmulateScale(d.conf.DeviceScaleFactor))
+	}
+
+	return chromedp.EmulateViewport(width, height)
+}
+
 // panelPNGURL returns the URL to fetch panel PNG.
 func (d *Dashboard) panelPNGURL(p Panel, render bool) *url.URL {
-	values := maps.Clone(d.model.Dashboard.Variables)
+	values := d.queryValues()
 	values.Add("theme", d.conf.Theme)
 	values.Add("panelId", p.ID)
 
@@ -179,33 +375,59 @@ func (d *Dashboard) panelPNGURL(p Panel, render bool) *url.URL {
 	var renderer string
 	if render {
 		renderer = "render/"
-	}
 
-	// Make a copy of appURL
-	panelURL := *d.appURL
-	panelURL.Path = fmt.Sprintf("/%sd-solo/%s/_", renderer, d.model.Dashboard.UID)
-	panelURL.RawQuery = values.Encode()
+		// Timeout in seconds that Grafana waits for grafana-image-renderer
+		if d.conf.RenderTimeout > 0 {
+			values.Add("timeout", strconv.Itoa(d.conf.RenderTimeout))
+		}
+
+		// Device scale factor of the browser used by grafana-image-renderer.
+		// Grafana reads it from scale query parameter
+		if d.conf.DeviceScaleFactor > 0 {
+			values.Add("scale", strconv.FormatFloat(d.conf.DeviceScaleFactor, 'f', -1, 64))
+		}
+
+		// Maximum data points of panel queries
+		if d.conf.MaxDataPoints > 0 {
+			values.Add("maxDataPoints", strconv.Itoa(d.conf.MaxDataPoints))
+		}
+	}
 
 	// Get Panel API endpoint
-	return &panelURL
+	return d.grafanaURL(values, renderer+"d-solo", d.model.Dashboard.UID, "_")
 }
 
 // panelDims returns width and height of panel based on layout.
 func (d *Dashboard) panelDims(p Panel) (int64, int64) {
-	// If using a grid layout we use 100px for width and 36px for height scalind.
-	// Grafana panels are fitted into 24 units width and height units are said to
-	// 30px in docs but 36px seems to be better.
+	// If using a grid layout we use 100px for width and 36px for height scaling
+	// by default. Grafana panels are fitted into 24 units width and height units
+	// are said to 30px in docs but 36px seems to be better. Both scales can be
+	// configured to tune the aspect ratio of panels.
+	//
+	// When a custom number of grid columns is used, width is scaled so that full
+	// width panels always have the same resolution.
 	//
 	// In simple layout we create panels with 1000x500 resolution always and include
 	// them one in each page of report
 	var width, height int64
 	if d.conf.Layout == "grid" {
-		width = int64(p.GridPos.W * 100)
-		height = int64(p.GridPos.H * 36)
+		widthScale, heightScale := d.gridScales()
+		width = int64(p.GridPos.W * widthScale * config.DefaultGridColumns / float64(d.gridColumns()))
+		height = int64(p.GridPos.H * heightScale)
 	} else {
 		width = 1000
 		height = 500
 	}
 
+	// Panels with missing or malformed grid positions result in degenerate
+	// dimensions. Use configured default dimensions for such panels
+	if width < minPanelDim && d.conf.DefaultPanelWidth > 0 {
+		width = int64(d.conf.DefaultPanelWidth)
+	}
+
+	if height < minPanelDim && d.conf.DefaultPanelHeight > 0 {
+		height = int64(d.conf.DefaultPanelHeight)
+	}
+
 	return width, height
 }
@@ -1,14 +1,23 @@
 package dashboard
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
@@ -48,15 +57,7 @@ func TestFetchPanelPNG(t *testing.T) {
 			&chrome.LocalInstance{},
 			ts.URL,
 			"v11.1.0",
-			&Model{Dashboard: struct {
-				ID          int          `json:"id"`
-				UID         string       `json:"uid"`
-				Title       string       `json:"title"`
-				Description string       `json:"description"`
-				RowOrPanels []RowOrPanel `json:"panels"`
-				Panels      []Panel
-				Variables   url.Values
-			}{
+			&Model{Dashboard: Spec{
 				UID:       "randomUID",
 				Variables: variables,
 			}},
@@ -112,15 +113,7 @@ func TestFetchPanelPNG(t *testing.T) {
 			&chrome.LocalInstance{},
 			ts.URL,
 			"v11.1.0",
-			&Model{Dashboard: struct {
-				ID          int          `json:"id"`
-				UID         string       `json:"uid"`
-				Title       string       `json:"title"`
-				Description string       `json:"description"`
-				RowOrPanels []RowOrPanel `json:"panels"`
-				Panels      []Panel
-				Variables   url.Values
-			}{
+			&Model{Dashboard: Spec{
 				UID:       "randomUID",
 				Variables: variables,
 			}},
@@ -143,5 +136,516 @@ func TestFetchPanelPNG(t *testing.T) {
 			So(requestURI, ShouldContainSubstring, "width=2400")
 			So(requestURI, ShouldContainSubstring, "height=216")
 		})
+
+		// Set render timeout
+		conf.RenderTimeout = 60
+
+		_, err = dash.PanelPNG(context.Background(), Panel{ID: "44", Type: "graph", Title: "title", GridPos: GridPos{H: 6, W: 24}})
+
+		Convey("It should receives no errors using render timeout", func() {
+			So(err, ShouldBeNil)
+		})
+
+		Convey("The httpClient should pass render timeout to render endpoint", func() {
+			So(requestURI, ShouldContainSubstring, "timeout=60")
+		})
+
+		Convey("Render timeout should not be added to native renderer URL", func() {
+			So(dash.panelPNGURL(Panel{ID: "44"}, false).Query().Has("timeout"), ShouldBeFalse)
+		})
+
+		// Set device scale factor
+		conf.DeviceScaleFactor = 1.5
+
+		Convey("Device scale factor should be added to render endpoint URL", func() {
+			So(dash.panelPNGURL(Panel{ID: "44"}, true).Query().Get("scale"), ShouldEqual, "1.5")
+		})
+
+		Convey("Device scale factor should not be added to native renderer URL", func() {
+			So(dash.panelPNGURL(Panel{ID: "44"}, false).Query().Has("scale"), ShouldBeFalse)
+		})
+
+		Convey("Device scale factor should not change panel dimensions", func() {
+			w, h := dash.panelDims(Panel{ID: "44"})
+			values := dash.panelPNGURL(Panel{ID: "44"}, true).Query()
+
+			So(values.Get("width"), ShouldEqual, strconv.FormatInt(w, 10))
+			So(values.Get("height"), ShouldEqual, strconv.FormatInt(h, 10))
+		})
+
+		// Set max data points
+		conf.MaxDataPoints = 300
+
+		Convey("Max data points should be added to render endpoint URL", func() {
+			So(dash.panelPNGURL(Panel{ID: "44"}, true).Query().Get("maxDataPoints"), ShouldEqual, "300")
+		})
+
+		Convey("Max data points should not be added to native renderer URL", func() {
+			So(dash.panelPNGURL(Panel{ID: "44"}, false).Query().Has("maxDataPoints"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestFetchPanelPNGMinImageBytes(t *testing.T) {
+	Convey("When fetching a panel PNG with minimum image size", t, func() {
+		var requests atomic.Int32
+
+		// Renderer returns a truncated image for first request
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				w.Write([]byte("truncated"))
+
+				return
+			}
+
+			w.Write([]byte(strings.Repeat("x", 1024)))
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Layout:        "simple",
+			DashboardMode: "default",
+			MinImageBytes: 512,
+		}
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			http.DefaultClient,
+			&chrome.LocalInstance{},
+			ts.URL,
+			"v11.1.0",
+			&Model{Dashboard: Spec{UID: "randomUID"}},
+			nil,
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Truncated image should be retried", func() {
+			image, err := dash.panelPNGImageRenderer(context.Background(), Panel{ID: "44"})
+
+			So(err, ShouldBeNil)
+			So(image.Image, ShouldNotBeEmpty)
+			So(requests.Load(), ShouldEqual, 2)
+		})
+
+		Convey("Error should be returned when image is always smaller than minimum size", func() {
+			conf.MinImageBytes = 2048
+
+			_, err := dash.panelPNGImageRenderer(context.Background(), Panel{ID: "44"})
+
+			So(errors.Is(err, ErrImageTooSmall), ShouldBeTrue)
+			So(requests.Load(), ShouldEqual, 3)
+		})
+
+		Convey("Retries should stop when context is done", func() {
+			defer func(delay time.Duration) { getPanelRetrySleepTime = delay }(getPanelRetrySleepTime)
+
+			getPanelRetrySleepTime = time.Minute
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := dash.panelPNGImageRenderer(ctx, Panel{ID: "44"})
+
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(requests.Load(), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestFetchPanelPNGNativeMinImageBytes(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When capturing a panel PNG smaller than minimum size in browser", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		Convey("setup a chrome browser should not error", func() {
+			So(err, ShouldBeNil)
+		})
+
+		var navigations atomic.Int32
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/d-solo/") {
+				navigations.Add(1)
+			}
+
+			http.ServeFile(w, r, "testdata/dashboard.html")
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Layout:            "simple",
+			DashboardMode:     "default",
+			NativeRendering:   true,
+			MinImageBytes:     100 << 20,
+			HTTPClientOptions: httpclient.Options{Timeouts: &httpclient.DefaultTimeoutOptions},
+		}
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			http.DefaultClient,
+			chromeInstance,
+			ts.URL,
+			"v11.4.0",
+			&Model{Dashboard: Spec{UID: "randomUID"}},
+			nil,
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		_, err = dash.panelPNGNativeRenderer(context.Background(), Panel{ID: "44", Type: "graph", Title: "title"})
+
+		Convey("Panel should be loaded again on each try", func() {
+			So(errors.Is(err, ErrImageTooSmall), ShouldBeTrue)
+			So(navigations.Load(), ShouldEqual, 3)
+		})
+	})
+}
+
+func TestFetchPanelPNGCache(t *testing.T) {
+	Convey("When fetching panel PNGs with panel PNG cache enabled", t, func() {
+		var requests atomic.Int32
+
+		var failing atomic.Bool
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+
+			if failing.Load() {
+				http.Error(w, "renderer unavailable", http.StatusInternalServerError)
+
+				return
+			}
+
+			w.Write([]byte("PNG")) //nolint:errcheck
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Layout:        "simple",
+			DashboardMode: "default",
+			PanelPNGCache: true,
+		}
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			http.DefaultClient,
+			&chrome.LocalInstance{},
+			ts.URL,
+			"v11.1.0",
+			&Model{Dashboard: Spec{UID: "randomUID", Variables: url.Values{}}},
+			http.Header{},
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		panel := Panel{ID: "44", Type: "graph", Title: "title"}
+
+		first, err1 := dash.PanelPNG(context.Background(), panel)
+		second, err2 := dash.PanelPNG(context.Background(), panel)
+
+		Convey("Repeated panel should be fetched only once", func() {
+			So(err1, ShouldBeNil)
+			So(err2, ShouldBeNil)
+			So(second, ShouldResemble, first)
+			So(requests.Load(), ShouldEqual, 1)
+		})
+
+		_, err = dash.PanelPNG(context.Background(), Panel{ID: "45", Type: "graph", Title: "title"})
+
+		Convey("Different panel should be fetched again", func() {
+			So(err, ShouldBeNil)
+			So(requests.Load(), ShouldEqual, 2)
+		})
+
+		// Failed fetches must not be cached
+		failedPanel := Panel{ID: "46", Type: "graph", Title: "title"}
+
+		failing.Store(true)
+
+		_, err = dash.PanelPNG(context.Background(), failedPanel)
+
+		Convey("Failed panel should return error", func() {
+			So(err, ShouldNotBeNil)
+		})
+
+		failing.Store(false)
+
+		fetched := requests.Load()
+
+		_, err = dash.PanelPNG(context.Background(), failedPanel)
+
+		Convey("Failed panel should be fetched again", func() {
+			So(err, ShouldBeNil)
+			So(requests.Load(), ShouldEqual, fetched+1)
+		})
+
+		// Disable cache
+		conf.PanelPNGCache = false
+
+		fetched = requests.Load()
+
+		_, err = dash.PanelPNG(context.Background(), panel)
+
+		Convey("Panel should be fetched again when cache is disabled", func() {
+			So(err, ShouldBeNil)
+			So(requests.Load(), ShouldEqual, fetched+1)
+		})
+	})
+}
+
+func TestFetchPanelPNGWithFallback(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When native rendering of panel PNG fails", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		Convey("setup a chrome browser should not error", func() {
+			So(err, ShouldBeNil)
+		})
+
+		var requestURI []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			muLock.Lock()
+			requestURI = append(requestURI, r.RequestURI)
+			muLock.Unlock()
+
+			// Fail all requests that are not made to grafana-image-renderer
+			if !strings.HasPrefix(r.URL.Path, "/render/") {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+
+				return
+			}
+
+			w.Write([]byte("iVBORw0KGgo"))
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Layout:               "simple",
+			DashboardMode:        "default",
+			NativeRendering:      true,
+			AutoFallbackRenderer: true,
+			HTTPClientOptions:    httpclient.Options{Timeouts: &httpclient.DefaultTimeoutOptions},
+		}
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			http.DefaultClient,
+			chromeInstance,
+			ts.URL,
+			"v11.4.0",
+			&Model{Dashboard: Spec{
+				UID: "randomUID",
+			}},
+			http.Header{
+				backend.OAuthIdentityTokenHeaderName: []string{"Bearer token"},
+			},
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		panelImage, err := dash.PanelPNG(context.Background(), Panel{ID: "44", Type: "graph", Title: "title"})
+
+		Convey("It should fall back to grafana-image-renderer", func() {
+			So(err, ShouldBeNil)
+			So(panelImage.Image, ShouldNotBeEmpty)
+			So(requestURI[0], ShouldStartWith, "/d-solo/randomUID/_")
+			So(requestURI[len(requestURI)-1], ShouldStartWith, "/render/d-solo/randomUID/_")
+		})
+
+		// Disable fallback
+		conf.AutoFallbackRenderer = false
+
+		_, err = dash.PanelPNG(context.Background(), Panel{ID: "44", Type: "graph", Title: "title"})
+
+		Convey("It should return error when fallback is disabled", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestFullPagePNGWithLocalChrome(t *testing.T) {
+	execPath, err := exec.LookPath("google-chrome")
+	if err != nil || execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When capturing full page PNG of dashboard", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		Convey("setup a chrome browser should not error", func() {
+			So(err, ShouldBeNil)
+		})
+
+		var requestURI []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			muLock.Lock()
+			requestURI = append(requestURI, r.URL.Path)
+			muLock.Unlock()
+
+			http.ServeFile(w, r, "testdata/dashboard_lazy.html")
+		}))
+		defer ts.Close()
+
+		conf := config.Config{
+			Layout:             "simple",
+			DashboardMode:      "default",
+			FullPageScreenshot: true,
+			HTTPClientOptions:  httpclient.Options{Timeouts: &httpclient.DefaultTimeoutOptions},
+		}
+
+		dash, err := New(
+			log.NewNullLogger(),
+			&conf,
+			http.DefaultClient,
+			chromeInstance,
+			ts.URL,
+			"v11.4.0",
+			&Model{Dashboard: Spec{UID: "randomUID", Title: "My dashboard"}},
+			nil,
+		)
+
+		Convey("New dashboard should receive no errors", func() {
+			So(err, ShouldBeNil)
+		})
+
+		panels, err := dash.fullPagePanels(context.Background())
+
+		Convey("It should scroll the dashboard before capturing screenshot", func() {
+			So(err, ShouldBeNil)
+			So(requestURI, ShouldContain, "/lazy")
+		})
+
+		Convey("It should return single panel with full page image", func() {
+			So(panels, ShouldHaveLength, 1)
+			So(panels[0].Title, ShouldEqual, "My dashboard")
+
+			data, err := base64.StdEncoding.DecodeString(panels[0].EncodedImage.Image)
+			So(err, ShouldBeNil)
+
+			img, err := png.DecodeConfig(bytes.NewReader(data))
+			So(err, ShouldBeNil)
+			So(img.Width, ShouldEqual, int(viewportWidth))
+			So(img.Height, ShouldBeGreaterThanOrEqualTo, 3000)
+		})
+	})
+}
+
+func TestPanelDims(t *testing.T) {
+	Convey("When estimating panel dimensions", t, func() {
+		conf := config.Config{
+			Layout:             "grid",
+			DefaultPanelWidth:  1000,
+			DefaultPanelHeight: 500,
+		}
+		dash := &Dashboard{conf: &conf}
+
+		Convey("Dimensions should be based on grid position", func() {
+			w, h := dash.panelDims(Panel{GridPos: GridPos{H: 6, W: 12}})
+
+			So(w, ShouldEqual, 1200)
+			So(h, ShouldEqual, 216)
+		})
+
+		Convey("Default dimensions should be used for panels with zero grid position", func() {
+			w, h := dash.panelDims(Panel{GridPos: GridPos{}})
+
+			So(w, ShouldEqual, 1000)
+			So(h, ShouldEqual, 500)
+		})
+
+		Convey("Default dimensions should be used only for implausible dimensions", func() {
+			w, h := dash.panelDims(Panel{GridPos: GridPos{H: 1, W: 6}})
+
+			So(w, ShouldEqual, 600)
+			So(h, ShouldEqual, 500)
+		})
+
+		Convey("Dimensions should follow configured grid scales", func() {
+			conf.GridWidthScale = 80
+			conf.GridHeightScale = 50
+
+			w, h := dash.panelDims(Panel{GridPos: GridPos{H: 6, W: 12}})
+
+			So(w, ShouldEqual, 960)
+			So(h, ShouldEqual, 300)
+		})
+
+		Convey("Computed dimensions should be used when defaults are unset", func() {
+			conf.DefaultPanelWidth = 0
+			conf.DefaultPanelHeight = 0
+
+			w, h := dash.panelDims(Panel{GridPos: GridPos{}})
+
+			So(w, ShouldEqual, 0)
+			So(h, ShouldEqual, 0)
+		})
 	})
 }
@@ -1,8 +1,10 @@
 package dashboard
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,10 +20,16 @@ type TimeRange struct {
 //     To:  "now/d" -> end of today
 //     To:  "now/w" -> end of the week
 //     To:  "now-1d/d" -> end of yesterday
+//     From:"now/fQ" -> start of the current fiscal quarter
 //     When used as boundary, the same string will evaluate to a different time if used in 'From' or 'To'
 //   - absolute unix time: "142321234"
 //   - absolute time string: "2024-12-02T23:00:00.000Z" start from Grafana v11.3.0
 //
+// Relative times and boundaries are evaluated in the wall clock of the location
+// of now so that days, weeks, months and years follow the calendar across daylight
+// saving time transitions. For instance, "now-1d" can be 23 or 25 hours before now
+// and "now/d" always starts at the first instant of the day in that location.
+//
 // The required behaviour is clearly documented in the unit tests, time_test.go.
 type now time.Time
 
@@ -34,7 +42,7 @@ const (
 
 const (
 	relTimeRegExp      = "^now([+-][0-9]+)([mhdwMy])$"
-	boundaryTimeRegExp = "^(.*?)/([dwMy])$"
+	boundaryTimeRegExp = "^(.*?)/([dwMy]|fQ)$"
 	layout             = "2006-01-02T15:04:05.000Z"
 )
 
@@ -52,18 +60,34 @@ func add(b boundary) int {
 	return 0
 }
 
-// Convert days to week boundary.
-func daysToWeekBoundary(wd time.Weekday, b boundary) int {
+// Convert days to week boundary based on the first day of the week.
+func daysToWeekBoundary(wd time.Weekday, b boundary, weekStart time.Weekday) int {
+	// Number of days since the start of the week
+	days := (int(wd) - int(weekStart) + 7) % 7
+
 	if b == To {
-		return 1 + int(time.Saturday) - int(wd)
+		return 7 - days
 	} else {
 		// b == From
-		return -int(wd)
+		return -days
+	}
+}
+
+// Convert months to fiscal quarter boundary based on the first month of the fiscal year.
+func monthsToFiscalQuarterBoundary(m time.Month, b boundary, fiscalYearStart time.Month) int {
+	// Number of months since the start of the fiscal quarter
+	months := (int(m) - int(fiscalYearStart) + 12) % 3
+
+	if b == To {
+		return 3 - months
+	} else {
+		// b == From
+		return -months
 	}
 }
 
 // Parse grafana specific time to time.Time format.
-func roundTimeToBoundary(t time.Time, b boundary, boundaryUnit string) time.Time {
+func roundTimeToBoundary(t time.Time, b boundary, boundaryUnit string, weekStart time.Weekday, fiscalYearStart time.Month) time.Time {
 	y := t.Year()
 	M := t.Month()
 	d := t.Day()
@@ -72,17 +96,41 @@ func roundTimeToBoundary(t time.Time, b boundary, boundaryUnit string) time.Time
 	case "d":
 		d += add(b)
 	case "w":
-		d += daysToWeekBoundary(t.Weekday(), b)
+		d += daysToWeekBoundary(t.Weekday(), b, weekStart)
 	case "M":
 		d = 1
 		M = time.Month(int(M) + add(b))
+	case "fQ":
+		d = 1
+		M = time.Month(int(M) + monthsToFiscalQuarterBoundary(M, b, fiscalYearStart))
 	case "y":
 		d = 1
 		M = time.January
 		y += add(b)
 	}
 
-	return time.Date(y, M, d, 0, 0, 0, 0, t.Location())
+	return startOfDay(y, M, d, t.Location())
+}
+
+// startOfDay returns the first instant of the given day in loc. In locations
+// where daylight saving time starts at midnight, midnight does not exist on the
+// day of transition and time.Date returns a time on the previous day instead. In
+// that case, the first instant of the day is the transition itself.
+func startOfDay(y int, M time.Month, d int, loc *time.Location) time.Time {
+	t := time.Date(y, M, d, 0, 0, 0, 0, loc)
+
+	// Normalised date of the day, e.g., 32 January is 1 February
+	day := time.Date(y, M, d, 0, 0, 0, 0, time.UTC)
+
+	if t.Day() == day.Day() {
+		return t
+	}
+
+	// Move to the transition by the difference of offsets before and after it
+	_, before := t.Zone()
+	_, after := time.Date(y, M, d, 12, 0, 0, 0, loc).Zone()
+
+	return t.Add(time.Duration(after-before) * time.Second)
 }
 
 // Parse time stamp to time.Unix() format.
@@ -127,23 +175,63 @@ func NewTimeRange(from, to string) TimeRange {
 	return TimeRange{from, to}
 }
 
-// Formats Grafana 'From' time spec into absolute printable time.
-func (tr TimeRange) FromFormatted(loc *time.Location, layout string) string {
-	n := newNow()
+// Formats Grafana 'From' time spec into absolute printable time. If showTimeZone is
+// true, time zone abbreviation and offset are appended to the formatted time. Week
+// boundaries are computed using weekStart as the first day of the week and fiscal
+// quarter boundaries using fiscalYearStart as the first month of the fiscal year.
+func (tr TimeRange) FromFormatted(loc *time.Location, layout string, showTimeZone bool, weekStart time.Weekday, fiscalYearStart time.Month) string {
+	n := newNow(loc)
+
+	return n.parseFrom(tr.From, weekStart, fiscalYearStart).In(loc).Format(timeZoneLayout(layout, showTimeZone))
+}
+
+// Formats Grafana 'To' time spec into absolute printable time. If showTimeZone is
+// true, time zone abbreviation and offset are appended to the formatted time. Week
+// boundaries are computed using weekStart as the first day of the week and fiscal
+// quarter boundaries using fiscalYearStart as the first month of the fiscal year.
+func (tr TimeRange) ToFormatted(loc *time.Location, layout string, showTimeZone bool, weekStart time.Weekday, fiscalYearStart time.Month) string {
+	n := newNow(loc)
+
+	return n.parseTo(tr.To, weekStart, fiscalYearStart).In(loc).Format(timeZoneLayout(layout, showTimeZone))
+}
+
+// Absolute returns absolute from and to times of the time range. Relative times and
+// boundaries are evaluated in loc. Week boundaries are computed using weekStart as
+// the first day of the week and fiscal quarter boundaries using fiscalYearStart as
+// the first month of the fiscal year. An error is returned if either of from and to
+// time specs is not recognised.
+func (tr TimeRange) Absolute(loc *time.Location, weekStart time.Weekday, fiscalYearStart time.Month) (from time.Time, to time.Time, err error) {
+	// Parsing panics on unrecognised time formats
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid time range: %v", r)
+		}
+	}()
+
+	n := newNow(loc)
 
-	return n.parseFrom(tr.From).In(loc).Format(layout)
+	return n.parseFrom(tr.From, weekStart, fiscalYearStart), n.parseTo(tr.To, weekStart, fiscalYearStart), nil
 }
 
-// Formats Grafana 'To' time spec into absolute printable time.
-func (tr TimeRange) ToFormatted(loc *time.Location, layout string) string {
-	n := newNow()
+// Appends time zone abbreviation and offset to layout if it does not contain
+// time zone already.
+func timeZoneLayout(layout string, showTimeZone bool) string {
+	if !showTimeZone {
+		return layout
+	}
+
+	for _, zone := range []string{"MST", "Z07", "-07"} {
+		if strings.Contains(layout, zone) {
+			return layout
+		}
+	}
 
-	return n.parseTo(tr.To).In(loc).Format(layout)
+	return layout + " MST -0700"
 }
 
-// Make current time custom struct.
-func newNow() now {
-	return now(time.Now())
+// Make current time custom struct in loc.
+func newNow(loc *time.Location) now {
+	return now(time.Now().In(loc))
 }
 
 // Get current time as time.Time format.
@@ -152,13 +240,13 @@ func (n now) asTime() time.Time {
 }
 
 // Parse from time string.
-func (n now) parseFrom(s string) time.Time {
-	return n.parseHumanFriendlyBoundary(s, From)
+func (n now) parseFrom(s string, weekStart time.Weekday, fiscalYearStart time.Month) time.Time {
+	return n.parseHumanFriendlyBoundary(s, From, weekStart, fiscalYearStart)
 }
 
 // Parse to time string.
-func (n now) parseTo(s string) time.Time {
-	return n.parseHumanFriendlyBoundary(s, To)
+func (n now) parseTo(s string, weekStart time.Weekday, fiscalYearStart time.Month) time.Time {
+	return n.parseHumanFriendlyBoundary(s, To, weekStart, fiscalYearStart)
 }
 
 // Parse time and boundary unit.
@@ -170,20 +258,21 @@ func (n now) parseTimeAndBoundaryUnit(s string) (time.Time, string) {
 		panic(unrecognized(s))
 	}
 
-	moment := n.parseTime(matches[1])
+	// Boundaries of absolute times are in the location of now as well
+	moment := n.parseTime(matches[1]).In(n.asTime().Location())
 	boundaryUnit := matches[2]
 
 	return moment, boundaryUnit
 }
 
 // Parse boundary time string.
-func (n now) parseHumanFriendlyBoundary(s string, b boundary) time.Time {
+func (n now) parseHumanFriendlyBoundary(s string, b boundary, weekStart time.Weekday, fiscalYearStart time.Month) time.Time {
 	if !isHumanFriendlyBoundray(s) {
 		return n.parseTime(s)
 	} else {
 		moment, boundaryUnit := n.parseTimeAndBoundaryUnit(s)
 
-		return roundTimeToBoundary(moment, b, boundaryUnit)
+		return roundTimeToBoundary(moment, b, boundaryUnit, weekStart, fiscalYearStart)
 	}
 }
 
@@ -32,117 +32,117 @@ func TestTimeParsing(tst *testing.T) {
 
 	Convey("When parsing relative time", tst, func() {
 		Convey("'now' should return the time it was initialised with", func() {
-			So(t.parseTo("now"), sameTimeAs, testNow)
+			So(t.parseTo("now", time.Sunday, time.January), sameTimeAs, testNow)
 		})
 
 		Convey("Minutes are supported", func() {
 			d, _ := time.ParseDuration("-1m")
-			So(t.parseTo("now-1m"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now-1m", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 
 			d, _ = time.ParseDuration("-58m")
-			So(t.parseTo("now-58m"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now-58m", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 		})
 
 		Convey("Positive relative time is supported", func() {
 			d, _ := time.ParseDuration("+1m")
-			So(t.parseTo("now+1m"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now+1m", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 
 			d, _ = time.ParseDuration("+58m")
-			So(t.parseTo("now+58m"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now+58m", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 		})
 
 		Convey("Hours are supported", func() {
 			d, _ := time.ParseDuration("-3h")
-			So(t.parseTo("now-3h"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now-3h", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 
 			d, _ = time.ParseDuration("-82h")
-			So(t.parseTo("now-82h"), sameTimeAs, testNow.Add(d))
+			So(t.parseTo("now-82h", time.Sunday, time.January), sameTimeAs, testNow.Add(d))
 		})
 
 		Convey("Days are supported", func() {
-			So(t.parseTo("now-1d"), sameTimeAs, testNow.AddDate(0, 0, -1))
-			So(t.parseTo("now-105d"), sameTimeAs, testNow.AddDate(0, 0, -105))
+			So(t.parseTo("now-1d", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, 0, -1))
+			So(t.parseTo("now-105d", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, 0, -105))
 		})
 
 		Convey("Weeks are supported", func() {
-			So(t.parseTo("now-1w"), sameTimeAs, testNow.AddDate(0, 0, -1*7))
-			So(t.parseTo("now-33w"), sameTimeAs, testNow.AddDate(0, 0, -33*7))
+			So(t.parseTo("now-1w", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, 0, -1*7))
+			So(t.parseTo("now-33w", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, 0, -33*7))
 		})
 
 		Convey("Months are supported", func() {
-			So(t.parseTo("now-1M"), sameTimeAs, testNow.AddDate(0, -1, 0))
-			So(t.parseTo("now-33M"), sameTimeAs, testNow.AddDate(0, -33, 0))
+			So(t.parseTo("now-1M", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, -1, 0))
+			So(t.parseTo("now-33M", time.Sunday, time.January), sameTimeAs, testNow.AddDate(0, -33, 0))
 		})
 
 		Convey("Years are supported", func() {
-			So(t.parseTo("now-1y"), sameTimeAs, testNow.AddDate(-1, 0, 0))
-			So(t.parseTo("now-33y"), sameTimeAs, testNow.AddDate(-33, 0, 0))
+			So(t.parseTo("now-1y", time.Sunday, time.January), sameTimeAs, testNow.AddDate(-1, 0, 0))
+			So(t.parseTo("now-33y", time.Sunday, time.January), sameTimeAs, testNow.AddDate(-33, 0, 0))
 		})
 	})
 
 	// ?from=1463464226537&to=1463472462258
 	Convey("Should be able to parse absolute time ", tst, func() {
-		So(t.parseTo("1463464226537"), sameTimeAs, time.Unix(1463464226537/1000, 0))
+		So(t.parseTo("1463464226537", time.Sunday, time.January), sameTimeAs, time.Unix(1463464226537/1000, 0))
 	})
 
 	Convey("Should panic on accept unrecognised formats", tst, func() {
-		So(func() { t.parseTo("not-a-time") }, ShouldPanic)
-		So(func() { t.parseTo("now-43k") }, ShouldPanic)
-		So(func() { t.parseTo("1235032k") }, ShouldPanic)
+		So(func() { t.parseTo("not-a-time", time.Sunday, time.January) }, ShouldPanic)
+		So(func() { t.parseTo("now-43k", time.Sunday, time.January) }, ShouldPanic)
+		So(func() { t.parseTo("1235032k", time.Sunday, time.January) }, ShouldPanic)
 	})
 
 	Convey("When parsing human frienly start time boundaries, parseFrom()", tst, func() {
 		Convey("Should return the same time as parseTo() if boundary specifier ('/') is missing", func() {
-			So(t.parseFrom("now"), sameTimeAs, t.parseTo("now"))
-			So(t.parseFrom("now-3M"), sameTimeAs, t.parseTo("now-3M"))
-			So(t.parseFrom("14123456789"), sameTimeAs, t.parseTo("14123456789"))
+			So(t.parseFrom("now", time.Sunday, time.January), sameTimeAs, t.parseTo("now", time.Sunday, time.January))
+			So(t.parseFrom("now-3M", time.Sunday, time.January), sameTimeAs, t.parseTo("now-3M", time.Sunday, time.January))
+			So(t.parseFrom("14123456789", time.Sunday, time.January), sameTimeAs, t.parseTo("14123456789", time.Sunday, time.January))
 		})
 
 		// now = Wed, 06 Jan 2016 16:34:32 UTC
 		Convey("Should support days", func() {
 			startOfTheDay, _ := time.Parse(time.RFC1123, "Wed, 06 Jan 2016 00:00:00 UTC")
-			So(t.parseFrom("now/d"), sameTimeAs, startOfTheDay)
-			So(t.parseFrom("now-1m/d"), sameTimeAs, startOfTheDay)
-			So(t.parseFrom("now-72m/d"), sameTimeAs, startOfTheDay)
+			So(t.parseFrom("now/d", time.Sunday, time.January), sameTimeAs, startOfTheDay)
+			So(t.parseFrom("now-1m/d", time.Sunday, time.January), sameTimeAs, startOfTheDay)
+			So(t.parseFrom("now-72m/d", time.Sunday, time.January), sameTimeAs, startOfTheDay)
 
 			startOfYesterday, _ := time.Parse(time.RFC1123, "Tue, 05 Jan 2016 00:00:00 UTC")
-			So(t.parseFrom("now-1d/d"), sameTimeAs, startOfYesterday)
-			So(t.parseFrom("now-24h/d"), sameTimeAs, startOfYesterday)
+			So(t.parseFrom("now-1d/d", time.Sunday, time.January), sameTimeAs, startOfYesterday)
+			So(t.parseFrom("now-24h/d", time.Sunday, time.January), sameTimeAs, startOfYesterday)
 		})
 
 		Convey("Should support weeks", func() {
 			startOfTheWeek, _ := time.Parse(time.RFC1123, "Sun, 03 Jan 2016 00:00:00 UTC")
-			So(t.parseFrom("now/w"), sameTimeAs, startOfTheWeek)
-			So(t.parseFrom("now-82m/w"), sameTimeAs, startOfTheWeek)
-			So(t.parseFrom("now-33h/w"), sameTimeAs, startOfTheWeek)
-			So(t.parseFrom("now-2d/w"), sameTimeAs, startOfTheWeek)
+			So(t.parseFrom("now/w", time.Sunday, time.January), sameTimeAs, startOfTheWeek)
+			So(t.parseFrom("now-82m/w", time.Sunday, time.January), sameTimeAs, startOfTheWeek)
+			So(t.parseFrom("now-33h/w", time.Sunday, time.January), sameTimeAs, startOfTheWeek)
+			So(t.parseFrom("now-2d/w", time.Sunday, time.January), sameTimeAs, startOfTheWeek)
 
 			startOfLastWeek, _ := time.Parse(time.RFC1123, "Sun, 27 Dec 2015 00:00:00 UTC")
-			So(t.parseFrom("now-1w/w"), sameTimeAs, startOfLastWeek)
+			So(t.parseFrom("now-1w/w", time.Sunday, time.January), sameTimeAs, startOfLastWeek)
 		})
 
 		Convey("Should support months", func() {
 			startOfTheMonth, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
 			So(time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC), sameTimeAs, startOfTheMonth)
 
-			So(t.parseFrom("now/M"), sameTimeAs, startOfTheMonth)
-			So(t.parseFrom("now-82m/M"), sameTimeAs, startOfTheMonth)
-			So(t.parseFrom("now-33h/M"), sameTimeAs, startOfTheMonth)
-			So(t.parseFrom("now-2d/M"), sameTimeAs, startOfTheMonth)
+			So(t.parseFrom("now/M", time.Sunday, time.January), sameTimeAs, startOfTheMonth)
+			So(t.parseFrom("now-82m/M", time.Sunday, time.January), sameTimeAs, startOfTheMonth)
+			So(t.parseFrom("now-33h/M", time.Sunday, time.January), sameTimeAs, startOfTheMonth)
+			So(t.parseFrom("now-2d/M", time.Sunday, time.January), sameTimeAs, startOfTheMonth)
 
 			startOfLastMonth, _ := time.Parse(time.RFC1123, "Tue, 01 Dec 2015 00:00:00 UTC")
-			So(t.parseFrom("now-1M/M"), sameTimeAs, startOfLastMonth)
+			So(t.parseFrom("now-1M/M", time.Sunday, time.January), sameTimeAs, startOfLastMonth)
 		})
 
 		Convey("Should support years", func() {
 			startOfTheYear, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
-			So(t.parseFrom("now/y"), sameTimeAs, startOfTheYear)
-			So(t.parseFrom("now-82m/y"), sameTimeAs, startOfTheYear)
-			So(t.parseFrom("now-33h/y"), sameTimeAs, startOfTheYear)
-			So(t.parseFrom("now-2d/y"), sameTimeAs, startOfTheYear)
+			So(t.parseFrom("now/y", time.Sunday, time.January), sameTimeAs, startOfTheYear)
+			So(t.parseFrom("now-82m/y", time.Sunday, time.January), sameTimeAs, startOfTheYear)
+			So(t.parseFrom("now-33h/y", time.Sunday, time.January), sameTimeAs, startOfTheYear)
+			So(t.parseFrom("now-2d/y", time.Sunday, time.January), sameTimeAs, startOfTheYear)
 
 			startOfLastYear, _ := time.Parse(time.RFC1123, "Thu, 01 Jan 2015 00:00:00 UTC")
-			So(t.parseFrom("now-1y/y"), sameTimeAs, startOfLastYear)
+			So(t.parseFrom("now-1y/y", time.Sunday, time.January), sameTimeAs, startOfLastYear)
 		})
 	})
 
@@ -150,45 +150,242 @@ func TestTimeParsing(tst *testing.T) {
 		// now = Wed, 06 Jan 2016 16:34:32 UTC
 		Convey("Should support days", func() {
 			endOfToday, _ := time.Parse(time.RFC1123, "Thu, 07 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now/d"), sameTimeAs, endOfToday)
-			So(t.parseTo("now-1m/d"), sameTimeAs, endOfToday)
-			So(t.parseTo("now-72m/d"), sameTimeAs, endOfToday)
+			So(t.parseTo("now/d", time.Sunday, time.January), sameTimeAs, endOfToday)
+			So(t.parseTo("now-1m/d", time.Sunday, time.January), sameTimeAs, endOfToday)
+			So(t.parseTo("now-72m/d", time.Sunday, time.January), sameTimeAs, endOfToday)
 
 			endOfYesterday, _ := time.Parse(time.RFC1123, "Wed, 06 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now-1d/d"), sameTimeAs, endOfYesterday)
+			So(t.parseTo("now-1d/d", time.Sunday, time.January), sameTimeAs, endOfYesterday)
 		})
 
 		Convey("Should support weeks", func() {
 			endOfTheWeek, _ := time.Parse(time.RFC1123, "Sun, 10 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now/w"), sameTimeAs, endOfTheWeek)
-			So(t.parseTo("now-82m/w"), sameTimeAs, endOfTheWeek)
-			So(t.parseTo("now-33h/w"), sameTimeAs, endOfTheWeek)
-			So(t.parseTo("now-2d/w"), sameTimeAs, endOfTheWeek)
+			So(t.parseTo("now/w", time.Sunday, time.January), sameTimeAs, endOfTheWeek)
+			So(t.parseTo("now-82m/w", time.Sunday, time.January), sameTimeAs, endOfTheWeek)
+			So(t.parseTo("now-33h/w", time.Sunday, time.January), sameTimeAs, endOfTheWeek)
+			So(t.parseTo("now-2d/w", time.Sunday, time.January), sameTimeAs, endOfTheWeek)
 
 			endOfLastWeek, _ := time.Parse(time.RFC1123, "Sun, 03 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now-1w/w"), sameTimeAs, endOfLastWeek)
+			So(t.parseTo("now-1w/w", time.Sunday, time.January), sameTimeAs, endOfLastWeek)
 		})
 
 		Convey("Should support months", func() {
 			endOfTheMonth, _ := time.Parse(time.RFC1123, "Mon, 01 Feb 2016 00:00:00 UTC")
-			So(t.parseTo("now/M"), sameTimeAs, endOfTheMonth)
-			So(t.parseTo("now-82m/M"), sameTimeAs, endOfTheMonth)
-			So(t.parseTo("now-33h/M"), sameTimeAs, endOfTheMonth)
-			So(t.parseTo("now-2d/M"), sameTimeAs, endOfTheMonth)
+			So(t.parseTo("now/M", time.Sunday, time.January), sameTimeAs, endOfTheMonth)
+			So(t.parseTo("now-82m/M", time.Sunday, time.January), sameTimeAs, endOfTheMonth)
+			So(t.parseTo("now-33h/M", time.Sunday, time.January), sameTimeAs, endOfTheMonth)
+			So(t.parseTo("now-2d/M", time.Sunday, time.January), sameTimeAs, endOfTheMonth)
 
 			endOfLastMonth, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now-1M/M"), sameTimeAs, endOfLastMonth)
+			So(t.parseTo("now-1M/M", time.Sunday, time.January), sameTimeAs, endOfLastMonth)
 		})
 
 		Convey("Should support years", func() {
 			endOfTheYear, _ := time.Parse(time.RFC1123, "Sun, 01 Jan 2017 00:00:00 UTC")
-			So(t.parseTo("now/y"), sameTimeAs, endOfTheYear)
-			So(t.parseTo("now-82m/y"), sameTimeAs, endOfTheYear)
-			So(t.parseTo("now-33h/y"), sameTimeAs, endOfTheYear)
-			So(t.parseTo("now-2d/y"), sameTimeAs, endOfTheYear)
+			So(t.parseTo("now/y", time.Sunday, time.January), sameTimeAs, endOfTheYear)
+			So(t.parseTo("now-82m/y", time.Sunday, time.January), sameTimeAs, endOfTheYear)
+			So(t.parseTo("now-33h/y", time.Sunday, time.January), sameTimeAs, endOfTheYear)
+			So(t.parseTo("now-2d/y", time.Sunday, time.January), sameTimeAs, endOfTheYear)
 
 			endOfLastYear, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
-			So(t.parseTo("now-1y/y"), sameTimeAs, endOfLastYear)
+			So(t.parseTo("now-1y/y", time.Sunday, time.January), sameTimeAs, endOfLastYear)
 		})
 	})
+
+	Convey("When parsing week boundaries with different first day of week", tst, func() {
+		// now = Wed, 06 Jan 2016 16:34:32 UTC
+		Convey("Should support weeks starting on Monday", func() {
+			startOfTheWeek, _ := time.Parse(time.RFC1123, "Mon, 04 Jan 2016 00:00:00 UTC")
+			So(t.parseFrom("now/w", time.Monday, time.January), sameTimeAs, startOfTheWeek)
+			So(t.parseFrom("now-2d/w", time.Monday, time.January), sameTimeAs, startOfTheWeek)
+
+			endOfTheWeek, _ := time.Parse(time.RFC1123, "Mon, 11 Jan 2016 00:00:00 UTC")
+			So(t.parseTo("now/w", time.Monday, time.January), sameTimeAs, endOfTheWeek)
+
+			// Sunday belongs to the previous week
+			startOfLastWeek, _ := time.Parse(time.RFC1123, "Mon, 28 Dec 2015 00:00:00 UTC")
+			So(t.parseFrom("now-3d/w", time.Monday, time.January), sameTimeAs, startOfLastWeek)
+		})
+
+		Convey("Should support weeks starting on Saturday", func() {
+			startOfTheWeek, _ := time.Parse(time.RFC1123, "Sat, 02 Jan 2016 00:00:00 UTC")
+			So(t.parseFrom("now/w", time.Saturday, time.January), sameTimeAs, startOfTheWeek)
+
+			endOfTheWeek, _ := time.Parse(time.RFC1123, "Sat, 09 Jan 2016 00:00:00 UTC")
+			So(t.parseTo("now/w", time.Saturday, time.January), sameTimeAs, endOfTheWeek)
+		})
+	})
+
+	Convey("When parsing fiscal quarter boundaries with different fiscal year start months", tst, func() {
+		// now = Wed, 06 Jan 2016 16:34:32 UTC
+		Convey("Should support fiscal years starting in January", func() {
+			startOfTheQuarter, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
+			So(t.parseFrom("now/fQ", time.Sunday, time.January), sameTimeAs, startOfTheQuarter)
+
+			endOfTheQuarter, _ := time.Parse(time.RFC1123, "Fri, 01 Apr 2016 00:00:00 UTC")
+			So(t.parseTo("now/fQ", time.Sunday, time.January), sameTimeAs, endOfTheQuarter)
+		})
+
+		Convey("Should support fiscal years starting in February", func() {
+			startOfTheQuarter, _ := time.Parse(time.RFC1123, "Sun, 01 Nov 2015 00:00:00 UTC")
+			So(t.parseFrom("now/fQ", time.Sunday, time.February), sameTimeAs, startOfTheQuarter)
+			So(t.parseFrom("now-1M/fQ", time.Sunday, time.February), sameTimeAs, startOfTheQuarter)
+
+			endOfTheQuarter, _ := time.Parse(time.RFC1123, "Mon, 01 Feb 2016 00:00:00 UTC")
+			So(t.parseTo("now/fQ", time.Sunday, time.February), sameTimeAs, endOfTheQuarter)
+
+			startOfLastQuarter, _ := time.Parse(time.RFC1123, "Sat, 01 Aug 2015 00:00:00 UTC")
+			So(t.parseFrom("now-3M/fQ", time.Sunday, time.February), sameTimeAs, startOfLastQuarter)
+		})
+
+		Convey("Should support fiscal years starting in April", func() {
+			startOfTheQuarter, _ := time.Parse(time.RFC1123, "Fri, 01 Jan 2016 00:00:00 UTC")
+			So(t.parseFrom("now/fQ", time.Sunday, time.April), sameTimeAs, startOfTheQuarter)
+
+			endOfTheQuarter, _ := time.Parse(time.RFC1123, "Fri, 01 Apr 2016 00:00:00 UTC")
+			So(t.parseTo("now/fQ", time.Sunday, time.April), sameTimeAs, endOfTheQuarter)
+		})
+
+		Convey("Should support fiscal years starting in December", func() {
+			startOfTheQuarter, _ := time.Parse(time.RFC1123, "Tue, 01 Dec 2015 00:00:00 UTC")
+			So(t.parseFrom("now/fQ", time.Sunday, time.December), sameTimeAs, startOfTheQuarter)
+
+			endOfTheQuarter, _ := time.Parse(time.RFC1123, "Tue, 01 Mar 2016 00:00:00 UTC")
+			So(t.parseTo("now/fQ", time.Sunday, time.December), sameTimeAs, endOfTheQuarter)
+		})
+	})
+}
+
+func TestTimeParsingAcrossDST(t *testing.T) {
+	Convey("When parsing time around daylight saving time transitions", t, func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		So(err, ShouldBeNil)
+
+		saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+		So(err, ShouldBeNil)
+
+		Convey("After spring forward", func() {
+			// Clocks moved from 02:00 to 03:00 on 31 March 2024
+			n := now(time.Date(2024, time.March, 31, 12, 0, 0, 0, berlin))
+
+			Convey("Day should start at midnight and last 23 hours", func() {
+				from := n.parseFrom("now/d", time.Monday, time.January)
+				to := n.parseTo("now/d", time.Monday, time.January)
+
+				So(from, sameTimeAs, time.Date(2024, time.March, 31, 0, 0, 0, 0, berlin))
+				So(to, sameTimeAs, time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin))
+				So(to.Sub(from), ShouldEqual, 23*time.Hour)
+			})
+
+			Convey("Relative days should keep the wall clock time", func() {
+				So(n.parseFrom("now-1d", time.Monday, time.January), sameTimeAs, time.Date(2024, time.March, 30, 12, 0, 0, 0, berlin))
+				So(n.parseFrom("now-1d/d", time.Monday, time.January), sameTimeAs, time.Date(2024, time.March, 30, 0, 0, 0, 0, berlin))
+			})
+
+			Convey("Relative hours should be absolute durations", func() {
+				So(n.parseFrom("now-12h", time.Monday, time.January), sameTimeAs, time.Date(2024, time.March, 30, 23, 0, 0, 0, berlin))
+			})
+		})
+
+		Convey("After fall back", func() {
+			// Clocks moved from 03:00 to 02:00 on 27 October 2024
+			n := now(time.Date(2024, time.October, 27, 12, 0, 0, 0, berlin))
+
+			Convey("Day should start at midnight and last 25 hours", func() {
+				from := n.parseFrom("now/d", time.Monday, time.January)
+				to := n.parseTo("now/d", time.Monday, time.January)
+
+				So(from, sameTimeAs, time.Date(2024, time.October, 27, 0, 0, 0, 0, berlin))
+				So(to.Sub(from), ShouldEqual, 25*time.Hour)
+			})
+
+			Convey("Week should start at midnight of Monday", func() {
+				So(n.parseFrom("now/w", time.Monday, time.January), sameTimeAs, time.Date(2024, time.October, 21, 0, 0, 0, 0, berlin))
+				So(n.parseTo("now/w", time.Monday, time.January), sameTimeAs, time.Date(2024, time.October, 28, 0, 0, 0, 0, berlin))
+			})
+		})
+
+		Convey("When midnight does not exist on the day of transition", func() {
+			// Clocks moved from 00:00 to 01:00 on 4 November 2018
+			n := now(time.Date(2018, time.November, 4, 12, 0, 0, 0, saoPaulo))
+
+			Convey("Day should start at the transition", func() {
+				from := n.parseFrom("now/d", time.Sunday, time.January)
+
+				So(from.Format(time.RFC3339), ShouldEqual, "2018-11-04T01:00:00-02:00")
+				So(n.parseFrom("now-1d/d", time.Sunday, time.January).Format(time.RFC3339), ShouldEqual, "2018-11-03T00:00:00-03:00")
+			})
+		})
+
+		Convey("Boundaries should be evaluated in the given location", func() {
+			// 23:30 UTC is already next day in Berlin
+			from, _, err := NewTimeRange("1711841400000/d", "now").Absolute(berlin, time.Monday, time.January)
+
+			So(err, ShouldBeNil)
+			So(from, sameTimeAs, time.Date(2024, time.March, 31, 0, 0, 0, 0, berlin))
+		})
+	})
+}
+
+func TestTimeRangeAbsolute(t *testing.T) {
+	Convey("When resolving absolute time range", t, func() {
+		Convey("Absolute times should be returned for valid time range", func() {
+			from, to, err := NewTimeRange("1734194455000", "1734194465000").Absolute(time.UTC, time.Sunday, time.January)
+
+			So(err, ShouldBeNil)
+			So(from.Unix(), ShouldEqual, 1734194455)
+			So(to.Unix(), ShouldEqual, 1734194465)
+		})
+
+		Convey("Error should be returned for invalid time range", func() {
+			_, _, err := NewTimeRange("yesterday", "now").Absolute(time.UTC, time.Sunday, time.January)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestTimeRangeFormatting(t *testing.T) {
+	Convey("When formatting time range with time zone", t, func() {
+		tr := NewTimeRange("1734194455000", "1734194465000")
+
+		cases := map[string]struct {
+			Zone         string
+			Layout       string
+			ShowTimeZone bool
+			From         string
+			To           string
+		}{
+			"utc": {
+				"UTC", "2006-01-02 15:04:05", true,
+				"2024-12-14 16:40:55 UTC +0000", "2024-12-14 16:41:05 UTC +0000",
+			},
+			"new_york": {
+				"America/New_York", "2006-01-02 15:04:05", true,
+				"2024-12-14 11:40:55 EST -0500", "2024-12-14 11:41:05 EST -0500",
+			},
+			"kolkata": {
+				"Asia/Kolkata", "2006-01-02 15:04:05", true,
+				"2024-12-14 22:10:55 IST +0530", "2024-12-14 22:11:05 IST +0530",
+			},
+			"layout_with_zone": {
+				"America/New_York", time.UnixDate, true,
+				"Sat Dec 14 11:40:55 EST 2024", "Sat Dec 14 11:41:05 EST 2024",
+			},
+			"disabled": {
+				"America/New_York", "2006-01-02 15:04:05", false,
+				"2024-12-14 11:40:55", "2024-12-14 11:41:05",
+			},
+		}
+
+		for clName, cl := range cases {
+			loc, err := time.LoadLocation(cl.Zone)
+
+			Convey("Time should be formatted with time zone: "+clName, func() {
+				So(err, ShouldBeNil)
+				So(tr.FromFormatted(loc, cl.Layout, cl.ShowTimeZone, time.Sunday, time.January), ShouldEqual, cl.From)
+				So(tr.ToFormatted(loc, cl.Layout, cl.ShowTimeZone, time.Sunday, time.January), ShouldEqual, cl.To)
+			})
+		}
+	})
 }
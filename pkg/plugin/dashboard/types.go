@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
@@ -23,6 +26,14 @@ type Dashboard struct {
 	jsContent      string
 	model          *Model
 	authHeader     http.Header
+	pngCache       *sync.Map
+}
+
+// pngCacheEntry is the cache entry of a panel PNG.
+type pngCacheEntry struct {
+	once  sync.Once
+	image PanelImage
+	err   error
 }
 
 // RowOrPanel represents a container for Panels.
@@ -32,6 +43,28 @@ type RowOrPanel struct {
 	Panels    []Panel `json:"panels"`
 }
 
+// UnmarshalJSON unmarshals row or panel. As Panel implements json.Unmarshaler,
+// the fields of row must be unmarshalled explicitly.
+func (r *RowOrPanel) UnmarshalJSON(b []byte) error {
+	if err := r.Panel.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	var s struct {
+		Collapsed bool    `json:"collapsed"`
+		Panels    []Panel `json:"panels"`
+	}
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	r.Collapsed = s.Collapsed
+	r.Panels = s.Panels
+
+	return nil
+}
+
 // Model represents a Grafana JSON dashboard.
 type Model struct {
 	Meta struct {
@@ -39,23 +72,80 @@ type Model struct {
 		FolderTitle string `json:"folderTitle"`
 		FolderURL   string `json:"folderUrl"`
 	} `json:"meta"`
-	Dashboard struct {
-		ID          int          `json:"id"`
-		UID         string       `json:"uid"`
-		Title       string       `json:"title"`
-		Description string       `json:"description"`
-		RowOrPanels []RowOrPanel `json:"panels"`
-		Panels      []Panel
-		Variables   url.Values
-	} `json:"dashboard"`
+	Dashboard Spec `json:"dashboard"`
+}
+
+// Spec represents the dashboard section of Grafana JSON dashboard.
+type Spec struct {
+	ID                   int          `json:"id"`
+	UID                  string       `json:"uid"`
+	Title                string       `json:"title"`
+	Description          string       `json:"description"`
+	Time                 TimeRange    `json:"time"`
+	FiscalYearStartMonth *int         `json:"fiscalYearStartMonth"`
+	RowOrPanels          []RowOrPanel `json:"panels"`
+	Templating           struct {
+		List []Variable `json:"list"`
+	} `json:"templating"`
+	Panels    []Panel
+	Variables url.Values
+}
+
+// FiscalYearStart returns the first month of the fiscal year set in the dashboard.
+// Grafana counts months from 0 (January). It returns false when the dashboard
+// does not set a valid month.
+func (s Spec) FiscalYearStart() (time.Month, bool) {
+	if s.FiscalYearStartMonth == nil || *s.FiscalYearStartMonth < 0 || *s.FiscalYearStartMonth > 11 {
+		return 0, false
+	}
+
+	return time.Month(*s.FiscalYearStartMonth + 1), true
+}
+
+// Datasource type of built in datasources like Mixed and Dashboard.
+const builtinDatasourceType = "datasource"
+
+// Template variable specific values.
+const (
+	hideVariable = 2
+	allValue     = "$__all"
+)
+
+// Variable represents a Grafana dashboard template variable.
+type Variable struct {
+	Name    string `json:"name"`
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Hide    int    `json:"hide"`
+	Query   any    `json:"query"`
+	Current struct {
+		Text  interface{} `json:"text"`
+		Value interface{} `json:"value"`
+	} `json:"current"`
+}
+
+// VariableValue represents a template variable and its selected values.
+type VariableValue struct {
+	Name   string
+	Values []string
+}
+
+// KPI represents the headline value of a stat panel in the executive summary.
+type KPI struct {
+	Title string
+	Value string
 }
 
 // Data represents dashboard data that will be included in the report.
 type Data struct {
-	Title     string
-	TimeRange TimeRange
-	Variables string
-	Panels    []Panel
+	Title           string
+	UID             string
+	URL             string
+	TimeRange       TimeRange
+	Variables       string
+	VariableSummary []VariableValue
+	Summary         []KPI
+	Panels          []Panel
 }
 
 type PanelType int
@@ -66,6 +156,8 @@ func (p PanelType) string() string {
 		"text",
 		"graph",
 		"table",
+		"stat",
+		"gauge",
 	}[p]
 }
 
@@ -74,6 +166,8 @@ const (
 	Text
 	Graph
 	Table
+	Stat
+	Gauge
 )
 
 // GridPos represents a Grafana dashboard panel position.
@@ -106,12 +200,23 @@ func (i *PanelID) UnmarshalJSON(b []byte) error {
 
 // Panel represents a Grafana dashboard panel.
 type Panel struct {
-	ID           string  `json:"-"`
-	Type         string  `json:"type"`
-	Title        string  `json:"title"`
-	GridPos      GridPos `json:"gridPos"`
-	EncodedImage PanelImage
-	CSVData      CSVData
+	ID              string          `json:"-"`
+	Type            string          `json:"type"`
+	Title           string          `json:"title"`
+	GridPos         GridPos         `json:"gridPos"`
+	Unit            string          `json:"-"`
+	Overrides       []FieldOverride `json:"-"`
+	DatasourceType  string          `json:"-"`
+	RepeatDirection string          `json:"repeatDirection"`
+	Row             string          `json:"-"`
+	Group           string          `json:"-"`
+	EncodedImage    PanelImage
+	CSVData         CSVData
+	StatValue       string
+	ImageFailed     bool
+	DataFailed      bool
+	Duplicates      []string
+	LiveURL         string
 }
 
 func (p *Panel) String() string {
@@ -123,7 +228,17 @@ func (p *Panel) UnmarshalJSON(b []byte) error {
 
 	var s struct {
 		tmp
-		ID PanelID `json:"id"`
+		ID          PanelID `json:"id"`
+		FieldConfig struct {
+			Defaults struct {
+				Unit string `json:"unit"`
+			} `json:"defaults"`
+			Overrides []fieldConfigOverride `json:"overrides"`
+		} `json:"fieldConfig"`
+		Datasource any `json:"datasource"`
+		Targets    []struct {
+			Datasource any `json:"datasource"`
+		} `json:"targets"`
 	}
 
 	err := json.Unmarshal(b, &s)
@@ -133,28 +248,55 @@ func (p *Panel) UnmarshalJSON(b []byte) error {
 
 	*p = Panel(s.tmp)
 	p.ID = string(s.ID)
+	p.Unit = s.FieldConfig.Defaults.Unit
+	p.Overrides = fieldOverrides(s.FieldConfig.Overrides)
+	p.DatasourceType = datasourceType(s.Datasource)
+
+	// Panels with mixed datasources use the datasource of first query
+	if p.DatasourceType == builtinDatasourceType && len(s.Targets) > 0 {
+		p.DatasourceType = datasourceType(s.Targets[0].Datasource)
+	}
 
 	return err
 }
 
+// datasourceType returns the plugin type of datasource reference of a panel or
+// query. Legacy references using datasource names do not have a type.
+func datasourceType(ref any) string {
+	if r, ok := ref.(map[string]any); ok {
+		if t, ok := r["type"].(string); ok {
+			return t
+		}
+	}
+
+	return ""
+}
+
 // IsSingleStat returns true if panel is of type SingleStat.
 func (p Panel) IsSingleStat() bool {
 	return p.Is(SingleStat)
 }
 
-// IsPartialWidth If panel has width less than total allowable width.
-func (p Panel) IsPartialWidth() bool {
-	return (p.GridPos.W < 24)
+// IsStat returns true if panel shows a single value like SingleStat, Stat and Gauge.
+func (p Panel) IsStat() bool {
+	return p.Is(SingleStat) || p.Is(Stat) || p.Is(Gauge)
 }
 
-// Width returns the width of the panel.
-func (p Panel) Width() float64 {
-	return float64(p.GridPos.W) * 0.04
-}
+// RepeatIndex returns the ID of the source panel and the clone index of a repeated
+// panel. Panel IDs of repeated panels are of form panel-<id>-clone-<index>. The
+// source panel itself is considered as the clone with index 0.
+func (p Panel) RepeatIndex() (string, int) {
+	base, index, found := strings.Cut(p.ID, "-clone-")
+	if !found {
+		return p.ID, 0
+	}
+
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return p.ID, 0
+	}
 
-// Height returns the height of the panel.
-func (p Panel) Height() float64 {
-	return float64(p.GridPos.H) * 0.04
+	return base, i
 }
 
 // Is returns true if panel is of type t.
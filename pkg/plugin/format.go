@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Output formats of reports.
+const (
+	formatPDF  = "pdf"
+	formatHTML = "html"
+	formatZIP  = "zip"
+)
+
+// Output formats of data exports.
+const (
+	formatNDJSON = "ndjson"
+	formatXLSX   = "xlsx"
+)
+
+var (
+	errInvalidFormat = errors.New("invalid format")
+	errNotAcceptable = errors.New("no acceptable format")
+)
+
+// reportFormats are the supported output formats of reports.
+var reportFormats = []string{formatPDF, formatHTML, formatZIP}
+
+// dataFormats are the supported output formats of data exports.
+var dataFormats = []string{formatNDJSON, formatXLSX}
+
+// formatMediaTypes maps the media types of Accept header to output formats.
+// Media ranges default to PDF unless a more specific media type is matched.
+var formatMediaTypes = map[string]string{
+	"application/pdf": formatPDF,
+	"text/html":       formatHTML,
+	"application/zip": formatZIP,
+	"application/*":   formatPDF,
+	"text/*":          formatHTML,
+	"*/*":             formatPDF,
+}
+
+// mediaRange is a media range of Accept header with its quality value.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// reportFormat returns the output format of the report. The format query
+// parameter takes precedence over Accept header. Accept header is ignored for
+// browser navigations as browsers prefer HTML for them, which would otherwise
+// break the report links that always returned PDF.
+func reportFormat(req *http.Request) (string, error) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		if !slices.Contains(reportFormats, format) {
+			return "", fmt.Errorf("%w: %s", errInvalidFormat, format)
+		}
+
+		return format, nil
+	}
+
+	if req.Header.Get("Sec-Fetch-Mode") == "navigate" {
+		return formatPDF, nil
+	}
+
+	accept := strings.Join(req.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return formatPDF, nil
+	}
+
+	for _, r := range parseAccept(accept) {
+		if format, ok := formatMediaTypes[r.mediaType]; ok {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", errNotAcceptable, accept)
+}
+
+// parseAccept returns the media ranges of Accept header sorted by decreasing
+// quality. Media ranges with the same quality keep their order and the ones
+// that are not acceptable (q=0) or invalid are left out.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, v := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		if quality == 0 {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return ranges
+}
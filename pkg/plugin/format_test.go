@@ -0,0 +1,185 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReportFormat(t *testing.T) {
+	Convey("When negotiating output format of report", t, func() {
+		tests := []struct {
+			name     string
+			query    string
+			accept   string
+			expected string
+		}{
+			{
+				name:     "no Accept header",
+				expected: formatPDF,
+			},
+			{
+				name:     "PDF",
+				accept:   "application/pdf",
+				expected: formatPDF,
+			},
+			{
+				name:     "HTML",
+				accept:   "text/html",
+				expected: formatHTML,
+			},
+			{
+				name:     "ZIP",
+				accept:   "application/zip",
+				expected: formatZIP,
+			},
+			{
+				name:     "any media type",
+				accept:   "*/*",
+				expected: formatPDF,
+			},
+			{
+				name:     "any text media type",
+				accept:   "text/*",
+				expected: formatHTML,
+			},
+			{
+				name:     "unsupported media types with fallback",
+				accept:   "text/markdown, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet;q=0.9, application/*;q=0.1",
+				expected: formatPDF,
+			},
+			{
+				name:     "media types with quality",
+				accept:   "application/pdf;q=0.5, application/zip;q=0.8, text/html;q=0.8",
+				expected: formatZIP,
+			},
+			{
+				name:     "not acceptable media type",
+				accept:   "application/pdf;q=0, text/html",
+				expected: formatHTML,
+			},
+			{
+				name:     "format query parameter over Accept header",
+				query:    "format=html",
+				accept:   "application/zip",
+				expected: formatHTML,
+			},
+		}
+
+		for _, test := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/report?"+test.query, nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			format, err := reportFormat(req)
+
+			So(err, ShouldBeNil)
+			So(format, ShouldEqual, test.expected)
+		}
+
+		Convey("Accept header should be ignored for browser navigations", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report", nil)
+			req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+			req.Header.Set("Sec-Fetch-Mode", "navigate")
+
+			format, err := reportFormat(req)
+
+			So(err, ShouldBeNil)
+			So(format, ShouldEqual, formatPDF)
+		})
+
+		Convey("Unsupported media types should not be acceptable", func() {
+			for _, accept := range []string{"text/markdown", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/pdf;q=0"} {
+				req := httptest.NewRequest(http.MethodGet, "/report", nil)
+				req.Header.Set("Accept", accept)
+
+				_, err := reportFormat(req)
+
+				So(errors.Is(err, errNotAcceptable), ShouldBeTrue)
+			}
+		})
+
+		Convey("Invalid format query parameter should return error", func() {
+			_, err := reportFormat(httptest.NewRequest(http.MethodGet, "/report?format=docx", nil))
+
+			So(errors.Is(err, errInvalidFormat), ShouldBeTrue)
+		})
+	})
+
+	Convey("When the report handler is called with Accept header", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/dashboards/uid/testDash" {
+				http.Error(w, "not found", http.StatusNotFound)
+
+				return
+			}
+
+			w.Write([]byte(`{"dashboard": {"uid": "testDash", "title": "My dashboard"}}`))
+		}))
+		defer ts.Close()
+
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+			DecryptedSecureJSONData: map[string]string{
+				config.SaToken: "token",
+			},
+		})
+		So(err, ShouldBeNil)
+
+		app := &App{httpClient: ts.Client(), conf: conf, grafanaSemVer: "v10.4.0"}
+
+		ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		headReport := func(target, accept string) *httptest.ResponseRecorder {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+			req.Header.Set("Accept", accept)
+
+			w := httptest.NewRecorder()
+			app.handleReport(w, req)
+
+			return w
+		}
+
+		Convey("It should return headers of negotiated format", func() {
+			for accept, contentType := range map[string]string{
+				"application/pdf": "application/pdf",
+				"text/html":       "text/html; charset=utf-8",
+				"application/zip": "application/zip",
+			} {
+				w := headReport("/report?dashUid=testDash", accept)
+
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, contentType)
+				So(w.Header().Get("Vary"), ShouldEqual, "Accept")
+			}
+		})
+
+		Convey("It should return ZIP archive for multiple resolutions", func() {
+			w := headReport("/report?dashUid=testDash&resolutions=1,2", "text/html")
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/zip")
+		})
+
+		Convey("It should reject resolutions with other formats", func() {
+			w := headReport("/report?dashUid=testDash&resolutions=1,2&format=pdf", "")
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("It should reject unsupported media types", func() {
+			w := headReport("/report?dashUid=testDash", "text/markdown")
+
+			So(w.Code, ShouldEqual, http.StatusNotAcceptable)
+		})
+	})
+}
@@ -0,0 +1,194 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Redacted is the value that replaces sensitive values in logs.
+const Redacted = "REDACTED"
+
+// Query parameters whose names contain any of these are considered sensitive.
+var sensitiveParams = []string{
+	"token", "auth", "secret", "password", "passwd", "apikey", "api_key", "signature", "session", "credential",
+}
+
+// Regex to find URLs in strings.
+var urlRegex = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// LogLevels are the levels that can be used to override log level.
+var LogLevels = map[string]log.Level{
+	"debug": log.Debug,
+	"info":  log.Info,
+	"warn":  log.Warn,
+	"error": log.Error,
+}
+
+// RedactURL returns the URL with values of template variables, sensitive query
+// parameters and password of user info redacted. Order of query parameters is
+// preserved. Query of URLs that cannot be parsed is removed altogether.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		base, _, _ := strings.Cut(rawURL, "?")
+
+		return base
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		key, _, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+
+		if name, err := url.QueryUnescape(key); err == nil && isSensitiveParam(name) {
+			params[i] = key + "=" + Redacted
+		}
+	}
+
+	u.RawQuery = strings.Join(params, "&")
+
+	return u.String()
+}
+
+// RedactURLs returns s with all the URLs in it redacted using RedactURL.
+func RedactURLs(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	return urlRegex.ReplaceAllStringFunc(s, RedactURL)
+}
+
+// isSensitiveParam returns true when values of query parameter must be redacted.
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+
+	if strings.HasPrefix(name, "var-") {
+		return true
+	}
+
+	for _, p := range sensitiveParams {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactingLogger redacts URLs in log messages and their arguments.
+type redactingLogger struct {
+	log.Logger
+}
+
+// NewRedactingLogger returns a logger that redacts URLs in messages and arguments
+// including errors before passing them to logger.
+func NewRedactingLogger(logger log.Logger) log.Logger {
+	return redactingLogger{logger}
+}
+
+func (l redactingLogger) Debug(msg string, args ...interface{}) {
+	l.Logger.Debug(RedactURLs(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Info(msg string, args ...interface{}) {
+	l.Logger.Info(RedactURLs(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Warn(msg string, args ...interface{}) {
+	l.Logger.Warn(RedactURLs(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) Error(msg string, args ...interface{}) {
+	l.Logger.Error(RedactURLs(msg), redactArgs(args)...)
+}
+
+func (l redactingLogger) With(args ...interface{}) log.Logger {
+	return redactingLogger{l.Logger.With(redactArgs(args)...)}
+}
+
+func (l redactingLogger) FromContext(ctx context.Context) log.Logger {
+	return redactingLogger{l.Logger.FromContext(ctx)}
+}
+
+// redactArgs redacts URLs in string, error and stringer arguments of logger.
+func redactArgs(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			redacted[i] = RedactURLs(v)
+		case error:
+			redacted[i] = RedactURLs(v.Error())
+		case fmt.Stringer:
+			redacted[i] = RedactURLs(v.String())
+		default:
+			redacted[i] = arg
+		}
+	}
+
+	return redacted
+}
+
+// levelLogger drops the messages that are less severe than its level.
+type levelLogger struct {
+	log.Logger
+
+	level log.Level
+}
+
+// NewLevelLogger returns a logger that only passes messages at level or more
+// severe to logger.
+func NewLevelLogger(logger log.Logger, level log.Level) log.Logger {
+	return levelLogger{logger, level}
+}
+
+func (l levelLogger) Debug(msg string, args ...interface{}) {
+	if l.level <= log.Debug {
+		l.Logger.Debug(msg, args...)
+	}
+}
+
+func (l levelLogger) Info(msg string, args ...interface{}) {
+	if l.level <= log.Info {
+		l.Logger.Info(msg, args...)
+	}
+}
+
+func (l levelLogger) Warn(msg string, args ...interface{}) {
+	if l.level <= log.Warn {
+		l.Logger.Warn(msg, args...)
+	}
+}
+
+func (l levelLogger) Error(msg string, args ...interface{}) {
+	l.Logger.Error(msg, args...)
+}
+
+func (l levelLogger) Level() log.Level {
+	return max(l.level, l.Logger.Level())
+}
+
+func (l levelLogger) With(args ...interface{}) log.Logger {
+	return levelLogger{l.Logger.With(args...), l.level}
+}
+
+func (l levelLogger) FromContext(ctx context.Context) log.Logger {
+	return levelLogger{l.Logger.FromContext(ctx), l.level}
+}
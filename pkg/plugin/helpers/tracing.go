@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Attribute keys of spans.
+const (
+	SpanDashboardUID = attribute.Key("dashboard.uid")
+	SpanPanelID      = attribute.Key("panel.id")
+	SpanRenderer     = attribute.Key("renderer")
+)
+
+// StartSpan starts a span named name with attrs as a child of the span in ctx
+// using the tracer of the plugin. When tracing is not enabled, ctx is returned
+// untouched along with a no-op span.
+func StartSpan(ctx context.Context, enabled bool, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled {
+		return ctx, noop.Span{}
+	}
+
+	return tracing.DefaultTracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// SpanError records err in span and sets its status to error. Nil errors are
+// ignored.
+func SpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
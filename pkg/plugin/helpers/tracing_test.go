@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartSpan(t *testing.T) {
+	Convey("When starting spans", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		tracing.InitDefaultTracer(provider.Tracer("test"))
+
+		parentCtx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+		Convey("Spans should not be created when tracing is disabled", func() {
+			ctx, span := StartSpan(parentCtx, false, "handleReport")
+			span.End()
+
+			So(ctx, ShouldEqual, parentCtx)
+			So(span.IsRecording(), ShouldBeFalse)
+			So(recorder.Ended(), ShouldBeEmpty)
+		})
+
+		Convey("Spans should nest under the span of context when tracing is enabled", func() {
+			ctx, span := StartSpan(parentCtx, true, "handleReport", SpanDashboardUID.String("abc"))
+
+			// Child spans started in other goroutines should nest as well
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				_, child := StartSpan(ctx, true, "dashboard.PanelPNG", SpanPanelID.String("2"), SpanRenderer.String("native"))
+				SpanError(child, errors.New("render failed"))
+				child.End()
+			}()
+
+			<-done
+			span.End()
+			parent.End()
+
+			spans := recorder.Ended()
+			So(spans, ShouldHaveLength, 3)
+
+			child, report := spans[0], spans[1]
+
+			So(report.Name(), ShouldEqual, "handleReport")
+			So(report.Parent().SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			So(report.Attributes(), ShouldContain, SpanDashboardUID.String("abc"))
+			So(report.Status().Code, ShouldEqual, codes.Unset)
+
+			So(child.Name(), ShouldEqual, "dashboard.PanelPNG")
+			So(child.Parent().SpanID(), ShouldEqual, report.SpanContext().SpanID())
+			So(child.Attributes(), ShouldContain, SpanRenderer.String("native"))
+			So(child.Status().Code, ShouldEqual, codes.Error)
+		})
+	})
+}
@@ -1,7 +1,11 @@
 package plugin
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,9 +14,14 @@ import (
 	"github.com/mahendrapaipuri/authlib/authn"
 	"github.com/mahendrapaipuri/authlib/authz"
 	"github.com/mahendrapaipuri/authlib/cache"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 )
 
+// errPermissionCheckTimeout is returned when permission check does not complete
+// within the configured timeout.
+var errPermissionCheckTimeout = errors.New("permission check timed out")
+
 // HasAccess verifies if the current request context has access to certain action.
 func (app *App) HasAccess(req *http.Request, action string, resources ...authz.Resource) (bool, error) {
 	// Retrieve the id token
@@ -26,13 +35,68 @@ func (app *App) HasAccess(req *http.Request, action string, resources ...authz.R
 		return false, err
 	}
 
-	// Check user access
-	hasAccess, err := authzClient.HasAccess(req.Context(), idToken, action, resources...)
-	if err != nil || !hasAccess {
-		return false, err
+	// Do not let a slow Grafana block the request indefinitely
+	ctx := req.Context()
+
+	if app.conf.PermissionCheckTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(app.conf.PermissionCheckTimeout)*time.Second)
+		defer cancel()
+	}
+
+	// Verification of ID token by authz client does not use the context of
+	// request. So, run the check in a goroutine and stop waiting on deadline
+	type result struct {
+		hasAccess bool
+		err       error
+	}
+
+	resultCh := make(chan result, 1)
+
+	go func() {
+		hasAccess, err := authzClient.HasAccess(ctx, idToken, action, resources...)
+		resultCh <- result{hasAccess, err}
+	}()
+
+	select {
+	case res := <-resultCh:
+		if res.err != nil || !res.hasAccess {
+			return false, res.err
+		}
+
+		return true, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return false, fmt.Errorf("%w: %w", errPermissionCheckTimeout, ctx.Err())
+		}
+
+		return false, ctx.Err()
+	}
+}
+
+// checkAccess returns true when the request has access to action on any of the
+// resources. When the permission check times out, access is granted only when
+// fail mode of permission check is open.
+func (app *App) checkAccess(req *http.Request, conf *config.Config, logger log.Logger, action string, resources ...authz.Resource) bool {
+	hasAccess, err := app.HasAccess(req, action, resources...)
+
+	switch {
+	case errors.Is(err, errPermissionCheckTimeout) && conf.PermissionCheckFailMode == "open":
+		logger.Warn("permission check timed out, allowing request as fail mode is open", "err", err)
+
+		return true
+	case err != nil:
+		logger.Error("failed to check permissions", "err", err)
+
+		return false
+	case !hasAccess:
+		logger.Error("user does not have necessary permissions to view dashboard")
+
+		return false
 	}
 
-	return true, nil
+	return true
 }
 
 // GetAuthZClient returns an authz enforcement client configured thanks to the plugin context.
@@ -40,10 +104,6 @@ func (app *App) GetAuthZClient(req *http.Request) (authz.EnforcementClient, erro
 	ctx := req.Context()
 	ctxLogger := log.DefaultLogger.FromContext(ctx)
 
-	// Prevent two concurrent calls from updating the client
-	app.mx.Lock()
-	defer app.mx.Unlock()
-
 	grafanaConfig := backend.GrafanaConfigFromContext(req.Context())
 
 	grafanaAppURL, err := app.grafanaAppURL(grafanaConfig)
@@ -67,12 +127,27 @@ func (app *App) GetAuthZClient(req *http.Request) (authz.EnforcementClient, erro
 		}
 	}
 
-	if saToken == app.saToken {
-		ctxLogger.Debug("token unchanged returning existing authz client")
+	// Concurrent requests with the same token share the client without locking
+	key := authzClientKey(grafanaAppURL, saToken)
+
+	if v, ok := app.authzClients.Load(key); ok {
+		client, _ := v.(authz.EnforcementClient)
 
-		return app.authzClient, nil
+		return client, nil
 	}
 
+	// Prevent two concurrent calls from creating the client
+	app.mx.Lock()
+	defer app.mx.Unlock()
+
+	if v, ok := app.authzClients.Load(key); ok {
+		client, _ := v.(authz.EnforcementClient)
+
+		return client, nil
+	}
+
+	ctxLogger.Debug("token changed creating new authz client")
+
 	// Header "typ" has been added only in Grafana 11.1.0 (https://github.com/grafana/grafana/pull/87430)
 	// So this check will fail for Grafana < 11.1.0
 	// Set VerifierConfig{DisableTypHeaderCheck: true} for those cases
@@ -116,8 +191,22 @@ func (app *App) GetAuthZClient(req *http.Request) (authz.EnforcementClient, erro
 		return nil, err
 	}
 
-	app.authzClient = client
-	app.saToken = saToken
+	// Clients of previous tokens will not be used anymore
+	app.authzClients.Range(func(k, _ any) bool {
+		app.authzClients.Delete(k)
+
+		return true
+	})
+
+	app.authzClients.Store(key, client)
 
 	return client, nil
 }
+
+// authzClientKey returns the key of authz client in cache. Token is hashed so
+// that it is not kept in the keys of the cache.
+func authzClientKey(appURL, token string) string {
+	sum := sha256.Sum256([]byte(appURL + "\x00" + token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/authlib/authz"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// Unsigned ID token with a key ID so that verifier attempts to fetch signing keys.
+var idToken = strings.Join([]string{
+	base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"default","typ":"jwt"}`)),
+	base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user:1"}`)),
+	base64.RawURLEncoding.EncodeToString([]byte("signature")),
+}, ".")
+
+func TestPermissionCheckTimeout(t *testing.T) {
+	Convey("When checking permissions against a slow Grafana", t, func() {
+		// Authz endpoints do not respond until the test is done
+		done := make(chan struct{})
+
+		ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}))
+		defer ts.Close()
+		defer close(done)
+
+		conf := config.Config{
+			AppURL:                  ts.URL,
+			Token:                   "token",
+			PermissionCheckTimeout:  1,
+			PermissionCheckFailMode: "closed",
+		}
+
+		app := &App{conf: conf, httpClient: ts.Client(), grafanaSemVer: "v11.3.0"}
+
+		ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(nil))
+		req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash", nil).WithContext(ctx)
+		req.Header.Set(GrafanaUserSignInTokenHeaderName, idToken)
+
+		resource := authz.Resource{Kind: "dashboards", Attr: "uid", ID: "testDash"}
+
+		Convey("Permission check should time out", func() {
+			start := time.Now()
+
+			hasAccess, err := app.HasAccess(req, "dashboards:read", resource)
+
+			So(hasAccess, ShouldBeFalse)
+			So(errors.Is(err, errPermissionCheckTimeout), ShouldBeTrue)
+			So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		})
+
+		Convey("Access should be denied when fail mode is closed", func() {
+			So(app.checkAccess(req, &conf, log.NewNullLogger(), "dashboards:read", resource), ShouldBeFalse)
+		})
+
+		Convey("Access should be granted when fail mode is open", func() {
+			conf.PermissionCheckFailMode = "open"
+
+			So(app.checkAccess(req, &conf, log.NewNullLogger(), "dashboards:read", resource), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGetAuthZClient(t *testing.T) {
+	Convey("When getting authz client concurrently", t, func() {
+		app := &App{
+			conf:          config.Config{AppURL: "http://localhost:3000"},
+			httpClient:    http.DefaultClient,
+			grafanaSemVer: "v11.3.0",
+		}
+
+		newRequest := func(token string) *http.Request {
+			ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+				backend.AppClientSecret: token,
+			}))
+
+			return httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash", nil).WithContext(ctx)
+		}
+
+		client, err := app.GetAuthZClient(newRequest("token"))
+		So(err, ShouldBeNil)
+		So(client, ShouldNotBeNil)
+
+		Convey("Cached client should be returned without waiting on creation of clients", func() {
+			// Hold the creation lock so that requests that do not hit cache block
+			app.mx.Lock()
+			defer app.mx.Unlock()
+
+			const numRequests = 20
+
+			clients := make(chan authz.EnforcementClient, numRequests)
+
+			for range numRequests {
+				go func() {
+					c, _ := app.GetAuthZClient(newRequest("token"))
+					clients <- c
+				}()
+			}
+
+			for range numRequests {
+				select {
+				case c := <-clients:
+					So(c, ShouldEqual, client)
+				case <-time.After(5 * time.Second):
+					So("cache hits were serialized on creation lock", ShouldBeEmpty)
+
+					return
+				}
+			}
+		})
+
+		Convey("New client should be created when token changes", func() {
+			newClient, err := app.GetAuthZClient(newRequest("newToken"))
+			So(err, ShouldBeNil)
+			So(newClient, ShouldNotEqual, client)
+
+			// Client of previous token is evicted
+			count := 0
+
+			app.authzClients.Range(func(_, _ any) bool {
+				count++
+
+				return true
+			})
+			So(count, ShouldEqual, 1)
+
+			sameClient, err := app.GetAuthZClient(newRequest("newToken"))
+			So(err, ShouldBeNil)
+			So(sameClient, ShouldEqual, newClient)
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket is the token bucket of a single user.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed by user. Each user can make
+// a burst of requests up to the configured requests per minute and tokens are
+// refilled at a constant rate.
+type rateLimiter struct {
+	mx      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// newRateLimiter returns a new rateLimiter that allows requestsPerMinute requests
+// per minute per user.
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(requestsPerMinute),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow returns true if request of the user identified by key is allowed. If not,
+// it returns the duration after which the next request will be allowed.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := l.now()
+
+	// Remove buckets that are fully refilled as they are equivalent to new ones.
+	// This ensures that buckets of inactive users do not pile up.
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill tokens based on elapsed time
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
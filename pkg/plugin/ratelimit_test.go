@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	Convey("When rate limiting requests of users", t, func() {
+		now := time.Now()
+
+		limiter := newRateLimiter(2)
+		limiter.now = func() time.Time { return now }
+
+		Convey("Requests up to the limit should be allowed", func() {
+			allowed, _ := limiter.allow("1/user1")
+			So(allowed, ShouldBeTrue)
+
+			allowed, _ = limiter.allow("1/user1")
+			So(allowed, ShouldBeTrue)
+
+			Convey("Requests beyond the limit should be rejected with retry after", func() {
+				allowed, retryAfter := limiter.allow("1/user1")
+				So(allowed, ShouldBeFalse)
+				So(retryAfter, ShouldEqual, 30*time.Second)
+			})
+
+			Convey("Requests of other users should be allowed", func() {
+				allowed, _ := limiter.allow("1/user2")
+				So(allowed, ShouldBeTrue)
+
+				allowed, _ = limiter.allow("2/user1")
+				So(allowed, ShouldBeTrue)
+			})
+
+			Convey("Requests should be allowed after tokens are refilled", func() {
+				now = now.Add(30 * time.Second)
+
+				allowed, _ := limiter.allow("1/user1")
+				So(allowed, ShouldBeTrue)
+
+				allowed, retryAfter := limiter.allow("1/user1")
+				So(allowed, ShouldBeFalse)
+				So(retryAfter, ShouldEqual, 30*time.Second)
+			})
+
+			Convey("Buckets of inactive users should be removed", func() {
+				now = now.Add(time.Minute)
+
+				limiter.allow("1/user2")
+				So(limiter.buckets, ShouldHaveLength, 1)
+				So(limiter.buckets, ShouldContainKey, "1/user2")
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Renderers used to render panels.
+const (
+	rendererNative        = "native"
+	rendererImageRenderer = "grafana-image-renderer"
+	rendererUnavailable   = "unavailable"
+	rendererUnknown       = "unknown"
+)
+
+// Timeout for detecting the availability of grafana-image-renderer.
+const rendererDetectionTimeout = 10 * time.Second
+
+// frontendSettings contains the Grafana frontend settings used by the plugin.
+type frontendSettings struct {
+	RendererAvailable bool `json:"rendererAvailable"`
+}
+
+// imageRendererAvailable returns true when Grafana has an image renderer available.
+// It can be either grafana-image-renderer plugin or a remote rendering service.
+func (app *App) imageRendererAvailable(ctx context.Context, appURL string, authHeader http.Header) (bool, error) {
+	body, err := app.grafanaAPIRequest(ctx, appURL+"/api/frontend/settings", authHeader)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch frontend settings: %w", err)
+	}
+
+	var settings frontendSettings
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return false, fmt.Errorf("error reading response body into frontend settings: %w", err)
+	}
+
+	return settings.RendererAvailable, nil
+}
+
+// detectRenderer returns the renderer that will be used to render panels. When
+// native rendering is disabled, it checks if grafana-image-renderer is available and
+// returns native renderer when it is not and AutoDetectRenderer is enabled.
+func (app *App) detectRenderer(ctx context.Context, grafanaConfig *backend.GrafanaCfg) string {
+	if app.conf.NativeRendering {
+		return rendererNative
+	}
+
+	appURL, err := app.grafanaAppURL(grafanaConfig)
+	if err != nil {
+		app.ctxLogger.Warn("failed to detect availability of grafana-image-renderer", "err", err)
+
+		return rendererUnknown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rendererDetectionTimeout)
+	defer cancel()
+
+	available, err := app.imageRendererAvailable(ctx, appURL, app.startupAuthHeader(grafanaConfig))
+	if err != nil {
+		app.ctxLogger.Warn("failed to detect availability of grafana-image-renderer", "err", err)
+
+		return rendererUnknown
+	}
+
+	if available {
+		return rendererImageRenderer
+	}
+
+	if app.conf.AutoDetectRenderer {
+		app.ctxLogger.Warn("grafana-image-renderer is not available. Switching to native rendering of panels")
+
+		return rendererNative
+	}
+
+	app.ctxLogger.Error(
+		"grafana-image-renderer is not available and panels cannot be rendered. Install grafana-image-renderer " +
+			"plugin, configure a remote rendering service in Grafana or enable native rendering with nativeRenderer " +
+			"or autoDetectRenderer settings",
+	)
+
+	return rendererUnavailable
+}
+
+// updateRenderer detects the renderer and sets it as current renderer of app.
+func (app *App) updateRenderer(grafanaConfig *backend.GrafanaCfg) {
+	// Context of app instance is closed once it is created. So, use a
+	// background context for detection
+	renderer := app.detectRenderer(context.Background(), grafanaConfig)
+
+	app.rendererMx.Lock()
+	app.renderer = renderer
+	app.rendererMx.Unlock()
+}
+
+// currentRenderer returns the renderer detected at startup.
+func (app *App) currentRenderer() string {
+	app.rendererMx.RLock()
+	defer app.rendererMx.RUnlock()
+
+	return app.renderer
+}
+
+// startupAuthHeader returns the headers used to make requests to Grafana API
+// outside of user requests.
+func (app *App) startupAuthHeader(grafanaConfig *backend.GrafanaCfg) http.Header {
+	authHeader := http.Header{}
+
+	switch {
+	case app.conf.AnonymousAccess:
+	case app.conf.Token != "":
+		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+app.conf.Token)
+	default:
+		if saToken, err := grafanaConfig.PluginAppClientSecret(); err == nil && saToken != "" {
+			authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+saToken)
+		}
+	}
+
+	app.conf.SetBasicAuth(authHeader)
+
+	return authHeader
+}
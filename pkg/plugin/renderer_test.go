@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDetectRenderer(t *testing.T) {
+	Convey("When detecting renderer at startup", t, func() {
+		rendererAvailable := false
+		authorization := ""
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/frontend/settings" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			authorization = r.Header.Get(backend.OAuthIdentityTokenHeaderName)
+
+			fmt.Fprintf(w, `{"appSubUrl": "", "rendererAvailable": %v}`, rendererAvailable)
+		}))
+		defer ts.Close()
+
+		grafanaConfig := backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL:          ts.URL,
+			backend.AppClientSecret: "secret",
+		})
+
+		newApp := func(conf config.Config) *App {
+			return &App{conf: conf, httpClient: ts.Client(), ctxLogger: log.NewNullLogger()}
+		}
+
+		Convey("Native renderer should be used without detection when enabled", func() {
+			app := newApp(config.Config{NativeRendering: true, AppURL: "http://localhost:1"})
+
+			So(app.detectRenderer(context.Background(), grafanaConfig), ShouldEqual, rendererNative)
+		})
+
+		Convey("Image renderer should be detected when available", func() {
+			rendererAvailable = true
+			app := newApp(config.Config{})
+
+			So(app.detectRenderer(context.Background(), grafanaConfig), ShouldEqual, rendererImageRenderer)
+			So(app.conf.NativeRendering, ShouldBeFalse)
+			So(authorization, ShouldEqual, "Bearer secret")
+		})
+
+		Convey("Missing image renderer should be reported without auto detection", func() {
+			app := newApp(config.Config{})
+			app.updateRenderer(grafanaConfig)
+
+			So(app.currentRenderer(), ShouldEqual, rendererUnavailable)
+			So(app.conf.NativeRendering, ShouldBeFalse)
+
+			result, err := app.CheckHealth(context.Background(), nil)
+			So(err, ShouldBeNil)
+			So(result.Status, ShouldEqual, backend.HealthStatusError)
+			So(result.Message, ShouldContainSubstring, "grafana-image-renderer is not available")
+		})
+
+		Convey("Native rendering should be used when image renderer is missing with auto detection", func() {
+			app := newApp(config.Config{AutoDetectRenderer: true})
+			app.updateRenderer(grafanaConfig)
+
+			So(app.currentRenderer(), ShouldEqual, rendererNative)
+
+			// Config of app is not modified as requests read it concurrently
+			So(app.conf.NativeRendering, ShouldBeFalse)
+
+			result, err := app.CheckHealth(context.Background(), nil)
+			So(err, ShouldBeNil)
+			So(result.Status, ShouldEqual, backend.HealthStatusOk)
+			So(result.Message, ShouldEqual, "ok; renderer native")
+		})
+
+		Convey("Renderer should be unknown when detection fails", func() {
+			app := newApp(config.Config{AutoDetectRenderer: true, AppURL: ts.URL + "/subpath"})
+
+			So(app.detectRenderer(context.Background(), grafanaConfig), ShouldEqual, rendererUnknown)
+			So(app.conf.NativeRendering, ShouldBeFalse)
+		})
+	})
+}
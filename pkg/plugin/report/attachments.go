@@ -0,0 +1,540 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
+)
+
+// ErrUnsupportedPDF is returned when files cannot be attached to the PDF.
+var ErrUnsupportedPDF = errors.New("unsupported pdf")
+
+// Whitespace and delimiter characters of PDF syntax.
+const (
+	pdfWhitespace = " \t\r\n\f\x00"
+	pdfDelimiters = "/<>[]()%{}"
+)
+
+// Regexes to read entries of PDF trailer.
+var (
+	pdfRootRegex = regexp.MustCompile(`/Root\s+(\d+)\s+(\d+)\s+R`)
+	pdfSizeRegex = regexp.MustCompile(`/Size\s+(\d+)`)
+	pdfPrevRegex = regexp.MustCompile(`/Prev\s+(\d+)`)
+	pdfInfoRegex = regexp.MustCompile(`/Info\s+\d+\s+\d+\s+R`)
+	pdfIDRegex   = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	pdfRefRegex  = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R`)
+)
+
+// attachment is a file embedded in the PDF report.
+type attachment struct {
+	Name        string
+	Description string
+	Data        []byte
+}
+
+// panelAttachments returns CSV data of rendered panels as attachments. Data of
+// panels that is already fetched is reused and data of other panels is fetched
+// using browser worker pool within the limits of datasource concurrency. Panels
+// whose data cannot be fetched are skipped.
+func (r *Report) panelAttachments(ctx context.Context, panels []dashboard.Panel) []attachment {
+	data := make([]dashboard.CSVData, len(panels))
+
+	wg := sync.WaitGroup{}
+
+	for idx, panel := range panels {
+		if panel.CSVData != nil {
+			data[idx] = panel.CSVData
+
+			continue
+		}
+
+		if panel.EncodedImage.Image == "" && panel.StatValue == "" {
+			continue
+		}
+
+		wg.Add(1)
+
+		// Fetches are limited per datasource type like the ones of panels
+		r.dsLimiter.do(panel.DatasourceType, r.pools[worker.Browser].Do, func() {
+			defer wg.Done()
+
+			panelData, err := r.dashboard.PanelCSV(ctx, panel)
+			if err != nil {
+				r.logger.Debug("failed to fetch data of panel for attachment", "panel_id", panel.ID, "err", err)
+
+				return
+			}
+
+			data[idx] = panelData
+		})
+	}
+
+	wg.Wait()
+
+	return csvAttachments(panels, data)
+}
+
+// csvAttachments returns attachments of panels with CSV data. data must be
+// indexed like panels.
+func csvAttachments(panels []dashboard.Panel, data []dashboard.CSVData) []attachment {
+	var attachments []attachment
+
+	for idx, panel := range panels {
+		if idx >= len(data) || len(data[idx]) == 0 {
+			continue
+		}
+
+		var buf bytes.Buffer
+
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(data[idx]); err != nil {
+			continue
+		}
+
+		attachments = append(attachments, attachment{
+			Name:        panelDataFilename(panel),
+			Description: panel.Title,
+			Data:        buf.Bytes(),
+		})
+	}
+
+	return attachments
+}
+
+// attachFiles embeds files in the PDF by appending an incremental update that
+// adds them to the embedded files name tree of the document catalog. Name
+// dictionary of the catalog, like the one holding named destinations of the
+// Chromium PDFs, is kept and embedded files are added to it. Only PDFs using
+// cross-reference tables, like the ones produced by Chromium, are supported.
+func attachFiles(pdf []byte, files []attachment) ([]byte, error) {
+	if len(files) == 0 {
+		return pdf, nil
+	}
+
+	// Read the last cross-reference section and its trailer
+	xrefOffset, err := pdfStartXref(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	trailer, err := pdfTrailer(pdf, xrefOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	root := pdfRootRegex.FindSubmatch(trailer)
+	size := pdfSizeRegex.FindSubmatch(trailer)
+
+	if root == nil || size == nil {
+		return nil, fmt.Errorf("%w: trailer without root or size", ErrUnsupportedPDF)
+	}
+
+	rootNum, _ := strconv.Atoi(string(root[1]))
+	rootGen, _ := strconv.Atoi(string(root[2]))
+	nextNum, _ := strconv.Atoi(string(size[1]))
+
+	catalog, err := pdfObjectDict(pdf, xrefOffset, rootNum)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name dictionary of the catalog is either inline or an indirect object
+	var namesDict []byte
+
+	namesNum, namesGen := -1, 0
+
+	namesStart := pdfDictValue(catalog, "/Names")
+	if namesStart >= 0 {
+		value := catalog[namesStart:]
+
+		if ref := pdfRefRegex.FindSubmatch(value); ref != nil {
+			namesNum, _ = strconv.Atoi(string(ref[1]))
+			namesGen, _ = strconv.Atoi(string(ref[2]))
+
+			namesDict, err = pdfObjectDict(pdf, xrefOffset, namesNum)
+		} else if bytes.HasPrefix(value, []byte("<<")) {
+			namesDict, err = pdfDict(value)
+		} else {
+			err = fmt.Errorf("%w: invalid name dictionary", ErrUnsupportedPDF)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pdfDictValue(namesDict, "/EmbeddedFiles") >= 0 {
+			return nil, fmt.Errorf("%w: document already has embedded files", ErrUnsupportedPDF)
+		}
+	}
+
+	out := bytes.NewBuffer(slices.Clip(pdf))
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	offsets := make(map[int]int)
+	gens := make(map[int]int)
+
+	writeObject := func(num, gen int, body []byte) {
+		offsets[num] = out.Len()
+		gens[num] = gen
+
+		fmt.Fprintf(out, "%d %d obj\n", num, gen)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+
+	// Names of the name tree must be sorted
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b attachment) int { return strings.Compare(a.Name, b.Name) })
+
+	var names bytes.Buffer
+
+	for _, f := range files {
+		var compressed bytes.Buffer
+
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(f.Data); err != nil {
+			return nil, fmt.Errorf("failed to compress attachment %s: %w", f.Name, err)
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress attachment %s: %w", f.Name, err)
+		}
+
+		streamNum, specNum := nextNum, nextNum+1
+		nextNum += 2
+
+		var stream bytes.Buffer
+
+		fmt.Fprintf(&stream,
+			"<< /Type /EmbeddedFile /Subtype /text#2Fcsv /Filter /FlateDecode /Length %d /Params << /Size %d >> >>\nstream\n",
+			compressed.Len(), len(f.Data),
+		)
+		stream.Write(compressed.Bytes())
+		stream.WriteString("\nendstream")
+
+		writeObject(streamNum, 0, stream.Bytes())
+		writeObject(specNum, 0, fmt.Appendf(nil,
+			"<< /Type /Filespec /F %s /UF %s /Desc %s /EF << /F %d 0 R >> /AFRelationship /Data >>",
+			pdfString(f.Name), pdfTextString(f.Name), pdfTextString(f.Description), streamNum,
+		))
+
+		fmt.Fprintf(&names, "%s %d 0 R ", pdfString(f.Name), specNum)
+	}
+
+	treeNum := nextNum
+	nextNum++
+
+	writeObject(treeNum, 0, fmt.Appendf(nil, "<< /Names [%s] >>", strings.TrimSpace(names.String())))
+
+	// Add embedded files to a new revision of the name dictionary or the catalog
+	embeddedFiles := fmt.Appendf(nil, "/EmbeddedFiles %d 0 R", treeNum)
+
+	switch {
+	case namesNum >= 0:
+		writeObject(namesNum, namesGen, pdfDictAppend(namesDict, embeddedFiles))
+	case namesStart >= 0:
+		writeObject(rootNum, rootGen, slices.Concat(
+			catalog[:namesStart],
+			pdfDictAppend(namesDict, embeddedFiles),
+			catalog[namesStart+len(namesDict):],
+		))
+	default:
+		writeObject(rootNum, rootGen, pdfDictAppend(catalog, fmt.Appendf(nil, "/Names << %s >>", embeddedFiles)))
+	}
+
+	// Cross-reference section of the update with a subsection per run of
+	// consecutive object numbers
+	newXrefOffset := out.Len()
+
+	out.WriteString("xref\n")
+
+	nums := slices.Sorted(maps.Keys(offsets))
+
+	for start := 0; start < len(nums); {
+		end := start + 1
+		for end < len(nums) && nums[end] == nums[end-1]+1 {
+			end++
+		}
+
+		fmt.Fprintf(out, "%d %d\n", nums[start], end-start)
+
+		for _, num := range nums[start:end] {
+			fmt.Fprintf(out, "%010d %05d n\r\n", offsets[num], gens[num])
+		}
+
+		start = end
+	}
+
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root %d %d R /Prev %d", nextNum, rootNum, rootGen, xrefOffset)
+
+	for _, re := range []*regexp.Regexp{pdfInfoRegex, pdfIDRegex} {
+		if entry := re.Find(trailer); entry != nil {
+			out.WriteByte(' ')
+			out.Write(entry)
+		}
+	}
+
+	fmt.Fprintf(out, " >>\nstartxref\n%d\n%%%%EOF\n", newXrefOffset)
+
+	return out.Bytes(), nil
+}
+
+// pdfStartXref returns the offset of the last cross-reference section.
+func pdfStartXref(pdf []byte) (int, error) {
+	idx := bytes.LastIndex(pdf, []byte("startxref"))
+	if idx < 0 {
+		return 0, fmt.Errorf("%w: startxref not found", ErrUnsupportedPDF)
+	}
+
+	fields := bytes.Fields(pdf[idx+len("startxref"):])
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("%w: invalid startxref", ErrUnsupportedPDF)
+	}
+
+	offset, err := strconv.Atoi(string(fields[0]))
+	if err != nil || offset < 0 || offset >= len(pdf) {
+		return 0, fmt.Errorf("%w: invalid startxref", ErrUnsupportedPDF)
+	}
+
+	return offset, nil
+}
+
+// pdfTrailer returns the trailer dictionary of cross-reference section at offset.
+func pdfTrailer(pdf []byte, xrefOffset int) ([]byte, error) {
+	if !bytes.HasPrefix(pdf[xrefOffset:], []byte("xref")) {
+		return nil, fmt.Errorf("%w: cross-reference streams are not supported", ErrUnsupportedPDF)
+	}
+
+	idx := bytes.Index(pdf[xrefOffset:], []byte("trailer"))
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: trailer not found", ErrUnsupportedPDF)
+	}
+
+	return pdfDict(pdf[xrefOffset+idx+len("trailer"):])
+}
+
+// pdfObjectDict returns the dictionary of object num by looking it up in the
+// cross-reference sections starting from the one at xrefOffset.
+func pdfObjectDict(pdf []byte, xrefOffset, num int) ([]byte, error) {
+	for {
+		offset, found, err := pdfXrefEntry(pdf, xrefOffset, num)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			if offset < 0 || offset >= len(pdf) {
+				return nil, fmt.Errorf("%w: invalid offset of object %d", ErrUnsupportedPDF, num)
+			}
+
+			return pdfDict(pdf[offset:])
+		}
+
+		// Look up in the previous cross-reference section, if any
+		trailer, err := pdfTrailer(pdf, xrefOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		prev := pdfPrevRegex.FindSubmatch(trailer)
+		if prev == nil {
+			return nil, fmt.Errorf("%w: object %d not found", ErrUnsupportedPDF, num)
+		}
+
+		xrefOffset, _ = strconv.Atoi(string(prev[1]))
+		if xrefOffset < 0 || xrefOffset >= len(pdf) {
+			return nil, fmt.Errorf("%w: invalid previous cross-reference offset", ErrUnsupportedPDF)
+		}
+	}
+}
+
+// pdfXrefEntry returns the offset of object num from cross-reference table at
+// xrefOffset.
+func pdfXrefEntry(pdf []byte, xrefOffset, num int) (int, bool, error) {
+	lines := bytes.Split(pdf[xrefOffset:], []byte("\n"))
+
+	// First line is the xref keyword
+	for i := 1; i < len(lines); {
+		fields := bytes.Fields(lines[i])
+		if len(fields) != 2 {
+			// End of cross-reference table
+			return 0, false, nil
+		}
+
+		start, err1 := strconv.Atoi(string(fields[0]))
+		count, err2 := strconv.Atoi(string(fields[1]))
+
+		if err1 != nil || err2 != nil || i+count >= len(lines) {
+			return 0, false, fmt.Errorf("%w: invalid cross-reference table", ErrUnsupportedPDF)
+		}
+
+		if num >= start && num < start+count {
+			entry := bytes.Fields(lines[i+1+num-start])
+			if len(entry) != 3 || string(entry[2]) != "n" {
+				return 0, false, fmt.Errorf("%w: object %d is not in use", ErrUnsupportedPDF, num)
+			}
+
+			offset, err := strconv.Atoi(string(entry[0]))
+			if err != nil {
+				return 0, false, fmt.Errorf("%w: invalid cross-reference entry", ErrUnsupportedPDF)
+			}
+
+			return offset, true, nil
+		}
+
+		i += count + 1
+	}
+
+	return 0, false, nil
+}
+
+// pdfDict returns the first dictionary in b including its delimiters.
+func pdfDict(b []byte) ([]byte, error) {
+	start := bytes.Index(b, []byte("<<"))
+	if start < 0 {
+		return nil, fmt.Errorf("%w: dictionary not found", ErrUnsupportedPDF)
+	}
+
+	depth := 0
+
+	for i := start; i < len(b); i++ {
+		switch {
+		case bytes.HasPrefix(b[i:], []byte("<<")):
+			depth++
+			i++
+		case bytes.HasPrefix(b[i:], []byte(">>")):
+			depth--
+			i++
+
+			if depth == 0 {
+				return b[start : i+1], nil
+			}
+		case b[i] == '<' || b[i] == '(':
+			end, err := pdfStringEnd(b, i)
+			if err != nil {
+				return nil, err
+			}
+
+			i = end
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unterminated dictionary", ErrUnsupportedPDF)
+}
+
+// pdfDictValue returns the offset of the value of key in the top level of
+// dictionary dict or -1 when dict does not have key.
+func pdfDictValue(dict []byte, key string) int {
+	depth := 0
+
+	for i := 0; i < len(dict); i++ {
+		switch {
+		case bytes.HasPrefix(dict[i:], []byte("<<")):
+			depth++
+			i++
+		case bytes.HasPrefix(dict[i:], []byte(">>")):
+			depth--
+			i++
+		case dict[i] == '[':
+			depth++
+		case dict[i] == ']':
+			depth--
+		case dict[i] == '<' || dict[i] == '(':
+			end, err := pdfStringEnd(dict, i)
+			if err != nil {
+				return -1
+			}
+
+			i = end
+		case dict[i] == '/':
+			// Name ends at a whitespace or a delimiter
+			end := i + 1
+			for end < len(dict) && !strings.ContainsRune(pdfWhitespace+pdfDelimiters, rune(dict[end])) {
+				end++
+			}
+
+			if depth == 1 && string(dict[i:end]) == key {
+				return len(dict) - len(bytes.TrimLeft(dict[end:], pdfWhitespace))
+			}
+
+			i = end - 1
+		}
+	}
+
+	return -1
+}
+
+// pdfDictAppend returns dictionary dict with entry added at its end.
+func pdfDictAppend(dict, entry []byte) []byte {
+	return slices.Concat(bytes.TrimSpace(dict[:len(dict)-2]), []byte(" "), entry, []byte(" >>"))
+}
+
+// pdfStringEnd returns the offset of the last byte of hex or literal string
+// starting at b[start].
+func pdfStringEnd(b []byte, start int) (int, error) {
+	if b[start] == '<' {
+		end := bytes.IndexByte(b[start:], '>')
+		if end < 0 {
+			return 0, fmt.Errorf("%w: unterminated hex string", ErrUnsupportedPDF)
+		}
+
+		return start + end, nil
+	}
+
+	// Literal strings can have balanced parentheses
+	nesting := 0
+
+	for i := start; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '(':
+			nesting++
+		case ')':
+			nesting--
+
+			if nesting == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unterminated literal string", ErrUnsupportedPDF)
+}
+
+// pdfString returns s as a PDF literal string.
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+
+	return "(" + r.Replace(s) + ")"
+}
+
+// pdfTextString returns s as a PDF text string encoded in UTF-16BE.
+func pdfTextString(s string) string {
+	var b strings.Builder
+
+	b.WriteString("<FEFF")
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+
+	b.WriteString(">")
+
+	return b.String()
+}
@@ -0,0 +1,285 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// minimalPDF returns a PDF with a single empty page using a cross-reference table.
+// entries are added to the catalog and extra objects are numbered from 5.
+func minimalPDF(entries string, extra ...string) []byte {
+	objects := append([]string{
+		"<< /Type /Catalog /Pages 2 0 R" + entries + " >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Producer (Skia\\(PDF\\)) /Title <FEFF0041> >>",
+	}, extra...)
+
+	var buf bytes.Buffer
+
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R /ID [<AB> <AB>] >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func TestAttachFiles(t *testing.T) {
+	Convey("When attaching files to a PDF", t, func() {
+		pdf := minimalPDF("")
+		files := []attachment{
+			{Name: "panel-2.csv", Description: "Requests", Data: []byte("a,b\n1,2\n")},
+			{Name: "panel-1.csv", Description: "Température", Data: []byte("c\n3\n")},
+		}
+
+		out, err := attachFiles(pdf, files)
+		So(err, ShouldBeNil)
+
+		Convey("Original PDF should be kept as is", func() {
+			So(bytes.HasPrefix(out, pdf), ShouldBeTrue)
+		})
+
+		Convey("Catalog should refer to embedded files", func() {
+			catalog, err := pdfObjectDict(out, mustStartXref(out), 1)
+			So(err, ShouldBeNil)
+			So(string(catalog), ShouldContainSubstring, "/Pages 2 0 R")
+			So(string(catalog), ShouldContainSubstring, "/EmbeddedFiles 9 0 R")
+
+			names, err := pdfObjectDict(out, mustStartXref(out), 9)
+			So(err, ShouldBeNil)
+			So(string(names), ShouldEqual, "<< /Names [(panel-1.csv) 6 0 R (panel-2.csv) 8 0 R] >>")
+		})
+
+		Convey("Trailer should refer to previous revision", func() {
+			trailer, err := pdfTrailer(out, mustStartXref(out))
+			So(err, ShouldBeNil)
+			So(string(trailer), ShouldContainSubstring, "/Size 10")
+			So(string(trailer), ShouldContainSubstring, "/Prev "+strconv.Itoa(mustStartXref(pdf)))
+			So(string(trailer), ShouldContainSubstring, "/Info 4 0 R")
+			So(string(trailer), ShouldContainSubstring, "/ID [<AB> <AB>]")
+		})
+
+		Convey("Cross-reference entries should point to objects", func() {
+			for num := 1; num <= 9; num++ {
+				offset, found, err := pdfXrefEntry(out, mustStartXref(out), num)
+				if num < 5 && num != 1 {
+					// Unchanged objects are in previous revision
+					So(found, ShouldBeFalse)
+
+					continue
+				}
+
+				So(err, ShouldBeNil)
+				So(found, ShouldBeTrue)
+				So(string(out[offset:]), ShouldStartWith, fmt.Sprintf("%d 0 obj", num))
+			}
+		})
+
+		Convey("Embedded files should have the data of files", func() {
+			spec, err := pdfObjectDict(out, mustStartXref(out), 6)
+			So(err, ShouldBeNil)
+			So(string(spec), ShouldContainSubstring, "/F (panel-1.csv)")
+			So(string(spec), ShouldContainSubstring, "/Desc "+pdfTextString("Température"))
+
+			So(embeddedFile(out, 5), ShouldEqual, "c\n3\n")
+			So(embeddedFile(out, 7), ShouldEqual, "a,b\n1,2\n")
+		})
+	})
+
+	Convey("When attaching files to an unsupported PDF", t, func() {
+		files := []attachment{{Name: "panel-1.csv", Data: []byte("c\n3\n")}}
+
+		Convey("PDF with embedded files should fail", func() {
+			pdf := minimalPDF(" /Names << /EmbeddedFiles 5 0 R >>", "<< /Names [] >>")
+
+			_, err := attachFiles(pdf, files)
+			So(err, ShouldWrap, ErrUnsupportedPDF)
+		})
+
+		Convey("PDF with cross-reference stream should fail", func() {
+			pdf := []byte("%PDF-1.5\n1 0 obj\n<< /Type /XRef >>\nendobj\nstartxref\n9\n%%EOF\n")
+
+			_, err := attachFiles(pdf, files)
+			So(err, ShouldWrap, ErrUnsupportedPDF)
+		})
+	})
+}
+
+func TestAttachFilesNamedDestinations(t *testing.T) {
+	Convey("When attaching files to a PDF with named destinations", t, func() {
+		files := []attachment{{Name: "panel-1.csv", Data: []byte("c\n3\n")}}
+		dests := "<< /Names [(panel-1) [3 0 R /XYZ 0 792 0]] >>"
+
+		Convey("Inline name dictionary of catalog should keep destinations", func() {
+			pdf := minimalPDF(" /Names << /Dests 5 0 R >>", dests)
+
+			out, err := attachFiles(pdf, files)
+			So(err, ShouldBeNil)
+
+			catalog, err := pdfObjectDict(out, mustStartXref(out), 1)
+			So(err, ShouldBeNil)
+			So(string(catalog), ShouldEqual, "<< /Type /Catalog /Pages 2 0 R /Names << /Dests 5 0 R /EmbeddedFiles 8 0 R >> >>")
+			So(embeddedFile(out, 6), ShouldEqual, "c\n3\n")
+		})
+
+		Convey("Indirect name dictionary should be updated in place of catalog", func() {
+			pdf := minimalPDF(" /Names 6 0 R", dests, "<< /Dests 5 0 R >>")
+
+			out, err := attachFiles(pdf, files)
+			So(err, ShouldBeNil)
+
+			names, err := pdfObjectDict(out, mustStartXref(out), 6)
+			So(err, ShouldBeNil)
+			So(string(names), ShouldEqual, "<< /Dests 5 0 R /EmbeddedFiles 9 0 R >>")
+
+			// Catalog is unchanged and kept in previous revision
+			_, found, err := pdfXrefEntry(out, mustStartXref(out), 1)
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+
+			for num := 6; num <= 9; num++ {
+				offset, found, err := pdfXrefEntry(out, mustStartXref(out), num)
+				So(err, ShouldBeNil)
+				So(found, ShouldBeTrue)
+				So(string(out[offset:]), ShouldStartWith, fmt.Sprintf("%d 0 obj", num))
+			}
+
+			So(embeddedFile(out, 7), ShouldEqual, "c\n3\n")
+		})
+	})
+}
+
+func TestAttachFilesChromiumPDF(t *testing.T) {
+	var execPath string
+
+	locations := []string{
+		// Mac
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		// Windows
+		"chrome.exe",
+		// Linux
+		"google-chrome",
+		"chrome",
+	}
+
+	for _, path := range locations {
+		found, err := exec.LookPath(path)
+		if err == nil {
+			execPath = found
+
+			break
+		}
+	}
+
+	// Skip test if chrome is not available
+	if execPath == "" {
+		t.Skip("Chrome not found. Skipping test")
+	}
+
+	Convey("When attaching files to a PDF printed by Chromium with named destinations", t, func() {
+		chromeInstance, err := chrome.NewLocalBrowserInstance(context.Background(), log.NewNullLogger(), true, 0)
+		defer chromeInstance.Close(log.NewNullLogger()) //nolint:staticcheck
+
+		So(err, ShouldBeNil)
+
+		tab, err := chromeInstance.NewTab(context.Background(), log.NewNullLogger(), &config.Config{})
+		So(err, ShouldBeNil)
+
+		defer tab.Close(log.NewNullLogger())
+
+		var buf bytes.Buffer
+
+		err = tab.PrintToPDF(chrome.PDFOptions{
+			Body:                `<html><body><a href="#panel-1">CPU</a><h1 id="panel-1" style="page-break-before: always">CPU</h1></body></html>`,
+			DisableHeaderFooter: true,
+			GenerateOutline:     true,
+		}, &buf)
+		So(err, ShouldBeNil)
+
+		pdf := buf.Bytes()
+		So(string(pdf), ShouldContainSubstring, "/Dests")
+
+		out, err := attachFiles(pdf, []attachment{{Name: "panel-1.csv", Data: []byte("c\n3\n")}})
+
+		Convey("Files should be attached keeping the named destinations", func() {
+			So(err, ShouldBeNil)
+
+			update := string(out[len(pdf):])
+			So(update, ShouldContainSubstring, "/EmbeddedFiles")
+			So(update, ShouldContainSubstring, "/Dests")
+		})
+	})
+}
+
+func TestCSVAttachments(t *testing.T) {
+	Convey("When creating attachments from panel data", t, func() {
+		panels := []dashboard.Panel{
+			{ID: "1", Title: "CPU"},
+			{ID: "2", Title: "Memory"},
+		}
+		data := []dashboard.CSVData{nil, {{"time", "value"}, {"1", "a,b"}}}
+
+		attachments := csvAttachments(panels, data)
+
+		Convey("Only panels with data should be attached", func() {
+			So(attachments, ShouldHaveLength, 1)
+			So(attachments[0].Name, ShouldEqual, "panel-2.csv")
+			So(attachments[0].Description, ShouldEqual, "Memory")
+			So(string(attachments[0].Data), ShouldEqual, "time,value\n1,\"a,b\"\n")
+		})
+	})
+}
+
+// mustStartXref returns the offset of last cross-reference section.
+func mustStartXref(pdf []byte) int {
+	offset, err := pdfStartXref(pdf)
+	So(err, ShouldBeNil)
+
+	return offset
+}
+
+// embeddedFile returns the decompressed content of embedded file stream num.
+func embeddedFile(pdf []byte, num int) string {
+	offset, found, err := pdfXrefEntry(pdf, mustStartXref(pdf), num)
+	So(err, ShouldBeNil)
+	So(found, ShouldBeTrue)
+
+	stream := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindSubmatch(pdf[offset:])
+	So(stream, ShouldNotBeNil)
+
+	r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+	So(err, ShouldBeNil)
+
+	data, err := io.ReadAll(r)
+	So(err, ShouldBeNil)
+
+	return string(data)
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// GenerateBundle generates the report of the dashboard and writes it as a ZIP
+// archive along with the PNGs and CSV data of its panels and a manifest
+// describing them.
+func (r *Report) GenerateBundle(ctx context.Context, writer http.ResponseWriter) error {
+	defer helpers.TimeTrack(time.Now(), "bundle report generation", r.logger)
+
+	htmlReport, dashboardData, err := r.generateHTMLReport(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pdf bytes.Buffer
+	if err := r.writePDF(ctx, htmlReport, dashboardData, &pdf); err != nil {
+		return err
+	}
+
+	var data []attachment
+	if !r.conf.FullPageScreenshot {
+		data = r.panelAttachments(ctx, dashboardData.Panels)
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundle(&buf, r.conf.CompressionLevel, Filename(dashboardData.Title, r.conf), pdf.Bytes(), dashboardData, data); err != nil {
+		return err
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", contentDisposition(sanitizeFilename(dashboardData.Title, r.conf.FilenamePolicy)+".zip"))
+
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ZIP archive: %w", err)
+	}
+
+	return nil
+}
+
+// writeBundle writes a ZIP archive with the PDF report named filename, PNGs of
+// panels, their CSV data and a manifest to writer. Files are compressed with
+// compressionLevel.
+func writeBundle(writer io.Writer, compressionLevel int, filename string, pdf []byte, dashboardData *dashboard.Data, data []attachment) error {
+	archive := newZipWriter(writer, compressionLevel)
+
+	if err := addZipFile(archive, filename, pdf); err != nil {
+		return err
+	}
+
+	m := newManifest(dashboardData)
+	m.Report = filename
+
+	if err := addPanelImages(archive, dashboardData.Panels); err != nil {
+		return err
+	}
+
+	for _, a := range data {
+		if err := addZipFile(archive, a.Name, a.Data); err != nil {
+			return err
+		}
+	}
+
+	m.Panels = bundlePanels(m.Panels, dashboardData.Panels, data)
+
+	if err := addManifest(archive, m); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close ZIP archive: %w", err)
+	}
+
+	return nil
+}
+
+// newZipWriter returns a ZIP archive writing to writer that compresses files with
+// deflate compression level. Level 0 stores files without compressing them.
+func newZipWriter(writer io.Writer, level int) *zip.Writer {
+	archive := zip.NewWriter(writer)
+	archive.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+
+	return archive
+}
+
+// addZipFile adds a file with name and content to archive.
+func addZipFile(archive *zip.Writer, name string, content []byte) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to ZIP archive: %w", name, err)
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to ZIP archive: %w", name, err)
+	}
+
+	return nil
+}
+
+// addPanelImages adds PNGs of panels to archive. Panels without image are skipped.
+func addPanelImages(archive *zip.Writer, panels []dashboard.Panel) error {
+	for _, p := range panels {
+		if p.EncodedImage.Image == "" {
+			continue
+		}
+
+		image, err := base64.StdEncoding.DecodeString(p.EncodedImage.Image)
+		if err != nil {
+			return fmt.Errorf("failed to decode image of panel %s: %w", p.ID, err)
+		}
+
+		if err := addZipFile(archive, panelFilename(p), image); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addManifest adds manifest m to archive.
+func addManifest(archive *zip.Writer, m manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	return addZipFile(archive, manifestFilename, b)
+}
+
+// bundlePanels adds CSV data files to the manifest panels. Panels having data
+// without an image, like stat panels rendered as values, are added as well.
+func bundlePanels(manifestPanels []manifestPanel, panels []dashboard.Panel, data []attachment) []manifestPanel {
+	names := make(map[string]bool, len(data))
+	for _, a := range data {
+		names[a.Name] = true
+	}
+
+	for _, p := range panels {
+		filename := panelDataFilename(p)
+		if !names[filename] {
+			continue
+		}
+
+		found := false
+
+		for i := range manifestPanels {
+			if manifestPanels[i].ID == p.ID {
+				manifestPanels[i].Data = filename
+				found = true
+			}
+		}
+
+		if !found {
+			manifestPanels = append(manifestPanels, manifestPanel{
+				Data:    filename,
+				ID:      p.ID,
+				Title:   p.Title,
+				GridPos: p.GridPos,
+			})
+		}
+	}
+
+	return manifestPanels
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteBundle(t *testing.T) {
+	Convey("When bundling report with data of panels", t, func() {
+		dashData := &dashboard.Data{
+			Title:     "My first dashboard",
+			UID:       "randomUID",
+			TimeRange: dashboard.TimeRange{From: "now-1h", To: "now"},
+			Panels: []dashboard.Panel{
+				{
+					ID:           "1",
+					Title:        "CPU",
+					GridPos:      dashboard.GridPos{H: 6, W: 12},
+					EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"},
+				},
+				{ID: "2", Title: "Uptime", StatValue: "99.9%", GridPos: dashboard.GridPos{H: 6, W: 12, X: 12}},
+			},
+		}
+
+		data := csvAttachments(dashData.Panels, []dashboard.CSVData{
+			{{"Time", "cpu"}, {"1", "0.5"}},
+			{{"Time", "uptime"}, {"1", "99.9"}},
+		})
+
+		var buf bytes.Buffer
+
+		err := writeBundle(&buf, flate.DefaultCompression, "My first dashboard.pdf", []byte("%PDF-1.4"), dashData, data)
+		So(err, ShouldBeNil)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		So(err, ShouldBeNil)
+
+		files := make(map[string][]byte)
+
+		for _, f := range archive.File {
+			r, err := f.Open()
+			So(err, ShouldBeNil)
+
+			files[f.Name], err = io.ReadAll(r)
+			So(err, ShouldBeNil)
+
+			r.Close()
+		}
+
+		Convey("Archive should contain the report, PNGs, data and manifest", func() {
+			So(files, ShouldContainKey, "My first dashboard.pdf")
+			So(files, ShouldContainKey, "1_CPU.png")
+			So(files, ShouldContainKey, "panel-1.csv")
+			So(files, ShouldContainKey, "panel-2.csv")
+			So(files, ShouldContainKey, "manifest.json")
+			So(files, ShouldHaveLength, 5)
+
+			So(string(files["My first dashboard.pdf"]), ShouldEqual, "%PDF-1.4")
+			So(string(files["panel-2.csv"]), ShouldEqual, "Time,uptime\n1,99.9\n")
+		})
+
+		Convey("Manifest should describe the report and files of panels", func() {
+			var m manifest
+
+			So(json.Unmarshal(files["manifest.json"], &m), ShouldBeNil)
+			So(m.Report, ShouldEqual, "My first dashboard.pdf")
+			So(m.Panels, ShouldResemble, []manifestPanel{
+				{Filename: "1_CPU.png", Data: "panel-1.csv", ID: "1", Title: "CPU", GridPos: dashboard.GridPos{H: 6, W: 12}},
+				{Data: "panel-2.csv", ID: "2", Title: "Uptime", GridPos: dashboard.GridPos{H: 6, W: 12, X: 12}},
+			})
+		})
+	})
+}
+
+func TestNewZipWriter(t *testing.T) {
+	Convey("When writing a ZIP archive with a compression level", t, func() {
+		content := bytes.Repeat([]byte("time,value\n"), 1000)
+
+		compressedSize := func(level int) int {
+			var buf bytes.Buffer
+
+			archive := newZipWriter(&buf, level)
+			So(addZipFile(archive, "data.csv", content), ShouldBeNil)
+			So(archive.Close(), ShouldBeNil)
+
+			r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+			So(r.File, ShouldHaveLength, 1)
+
+			f, err := r.File[0].Open()
+			So(err, ShouldBeNil)
+
+			data, err := io.ReadAll(f)
+			So(err, ShouldBeNil)
+			So(data, ShouldResemble, content)
+
+			return int(r.File[0].CompressedSize64)
+		}
+
+		Convey("Files should be stored without compression with level 0", func() {
+			So(compressedSize(flate.NoCompression), ShouldBeGreaterThanOrEqualTo, len(content))
+		})
+
+		Convey("Files should be compressed with best compression level", func() {
+			So(compressedSize(flate.BestCompression), ShouldBeLessThan, len(content)/10)
+		})
+	})
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// Number of consecutive overload failures after which the limit is halved.
+const overloadThreshold = 2
+
+// adaptiveLimiter limits the number of concurrent panel renders of a report. The
+// limit is adapted using additive increase and multiplicative decrease scheme:
+// it is halved after consecutive renders failed due to timeouts or an unavailable
+// renderer, which are signs of an overloaded Grafana, and increased by one on each
+// successful render up to the maximum. Other failures, like missing panels, do not
+// change the limit.
+//
+// All methods are no-op on a nil limiter.
+type adaptiveLimiter struct {
+	mx       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	max      int
+	active   int
+	failures int
+}
+
+// newAdaptiveLimiter returns a new adaptiveLimiter with max concurrent renders.
+func newAdaptiveLimiter(maxActive int) *adaptiveLimiter {
+	if maxActive <= 0 {
+		maxActive = runtime.NumCPU()
+	}
+
+	l := &adaptiveLimiter{limit: maxActive, max: maxActive}
+	l.cond = sync.NewCond(&l.mx)
+
+	return l
+}
+
+// acquire blocks until the number of active renders is below the current limit.
+func (l *adaptiveLimiter) acquire() {
+	if l == nil {
+		return
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+
+	l.active++
+}
+
+// release marks a render as finished and adapts the limit based on its error.
+func (l *adaptiveLimiter) release(err error) {
+	if l == nil {
+		return
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.active--
+
+	switch {
+	case err == nil:
+		l.failures = 0
+		l.limit = min(l.limit+1, l.max)
+	case isOverload(err):
+		l.failures++
+
+		if l.failures >= overloadThreshold {
+			l.failures = 0
+			l.limit = max(l.limit/2, 1)
+		}
+	}
+
+	l.cond.Broadcast()
+}
+
+// currentLimit returns the current limit of concurrent renders.
+func (l *adaptiveLimiter) currentLimit() int {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	return l.limit
+}
+
+// isOverload returns true when err is a sign of an overloaded Grafana.
+func isOverload(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, dashboard.ErrRendererUnavailable)
+}
+
+// datasourceLimiter limits the number of concurrent panel renders per datasource
+// type so that panels of a slow datasource do not monopolize the workers. Panels
+// of datasource types without a limit are not limited.
+type datasourceLimiter map[string]chan struct{}
+
+// newDatasourceLimiter returns a new datasourceLimiter with the given limits
+// per datasource type.
+func newDatasourceLimiter(limits map[string]int) datasourceLimiter {
+	l := make(datasourceLimiter, len(limits))
+
+	for dsType, limit := range limits {
+		if limit > 0 {
+			l[dsType] = make(chan struct{}, limit)
+		}
+	}
+
+	return l
+}
+
+// do dispatches f using dispatch once the number of active renders of panels
+// of dsType is below its limit. It never blocks the caller and hence, renders
+// of other datasource types can be dispatched in the meantime.
+func (l datasourceLimiter) do(dsType string, dispatch func(func()), f func()) {
+	sem, ok := l[dsType]
+	if !ok {
+		dispatch(f)
+
+		return
+	}
+
+	go func() {
+		sem <- struct{}{}
+
+		dispatch(func() {
+			defer func() { <-sem }()
+
+			f()
+		})
+	}()
+}
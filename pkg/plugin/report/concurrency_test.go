@@ -0,0 +1,181 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	Convey("When limiting concurrent renders adaptively", t, func() {
+		limiter := newAdaptiveLimiter(4)
+
+		errOverload := fmt.Errorf("failed to fetch PNG data: %w", dashboard.ErrRendererUnavailable)
+
+		Convey("Limit should start at maximum", func() {
+			So(limiter.currentLimit(), ShouldEqual, 4)
+		})
+
+		Convey("Limit should be halved on consecutive overload failures and increased on successes", func() {
+			for _, err := range []error{errOverload, errOverload, context.DeadlineExceeded, errOverload} {
+				limiter.acquire()
+				limiter.release(err)
+			}
+
+			So(limiter.currentLimit(), ShouldEqual, 1)
+
+			// Limit should never go below one
+			for range 2 {
+				limiter.acquire()
+				limiter.release(errOverload)
+			}
+
+			So(limiter.currentLimit(), ShouldEqual, 1)
+
+			// Limit should be increased additively up to maximum
+			for range 5 {
+				limiter.acquire()
+				limiter.release(nil)
+			}
+
+			So(limiter.currentLimit(), ShouldEqual, 4)
+		})
+
+		Convey("Limit should not be halved on a single overload failure", func() {
+			for _, err := range []error{errOverload, nil, errOverload} {
+				limiter.acquire()
+				limiter.release(err)
+			}
+
+			So(limiter.currentLimit(), ShouldEqual, 4)
+		})
+
+		Convey("Limit should not change on other failures", func() {
+			for range 4 {
+				limiter.acquire()
+				limiter.release(errors.New("panel not found"))
+			}
+
+			So(limiter.currentLimit(), ShouldEqual, 4)
+		})
+
+		Convey("Concurrent renders should not exceed limit after failures", func() {
+			// Simulate failures reducing limit to one
+			for range 4 {
+				limiter.acquire()
+				limiter.release(errOverload)
+			}
+
+			var (
+				active    atomic.Int32
+				maxActive atomic.Int32
+				wg        sync.WaitGroup
+			)
+
+			for range 4 {
+				limiter.acquire()
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					if n := active.Add(1); n > maxActive.Load() {
+						maxActive.Store(n)
+					}
+
+					time.Sleep(5 * time.Millisecond)
+					active.Add(-1)
+
+					// Keep failing so that limit is not increased
+					limiter.release(errOverload)
+				}()
+			}
+
+			wg.Wait()
+
+			So(maxActive.Load(), ShouldEqual, 1)
+		})
+
+		Convey("Nil limiter should not limit renders", func() {
+			var nilLimiter *adaptiveLimiter
+
+			So(func() {
+				nilLimiter.acquire()
+				nilLimiter.release(errOverload)
+			}, ShouldNotPanic)
+		})
+	})
+}
+
+func TestDatasourceLimiter(t *testing.T) {
+	Convey("When limiting renders per datasource type", t, func() {
+		limiter := newDatasourceLimiter(map[string]int{"elasticsearch": 2})
+
+		dispatch := func(f func()) { go f() }
+
+		// run dispatches n renders of dsType and returns the maximum number
+		// of concurrently active renders
+		run := func(dsType string, n int) int64 {
+			var (
+				wg        sync.WaitGroup
+				active    atomic.Int64
+				maxActive atomic.Int64
+			)
+
+			for range n {
+				wg.Add(1)
+
+				limiter.do(dsType, dispatch, func() {
+					defer wg.Done()
+
+					if n := active.Add(1); n > maxActive.Load() {
+						maxActive.Store(n)
+					}
+
+					time.Sleep(10 * time.Millisecond)
+					active.Add(-1)
+				})
+			}
+
+			wg.Wait()
+
+			return maxActive.Load()
+		}
+
+		Convey("Renders of limited datasource type should not exceed the limit", func() {
+			So(run("elasticsearch", 10), ShouldBeLessThanOrEqualTo, 2)
+		})
+
+		Convey("Renders of other datasource types should not be limited", func() {
+			So(run("prometheus", 10), ShouldBeGreaterThan, 2)
+		})
+
+		Convey("Dispatching renders of limited datasource type should not block", func() {
+			blocked := make(chan struct{})
+
+			var wg sync.WaitGroup
+
+			for range 3 {
+				wg.Add(1)
+
+				limiter.do("elasticsearch", dispatch, func() {
+					defer wg.Done()
+					<-blocked
+				})
+			}
+
+			// Prometheus renders proceed while Elasticsearch renders are waiting
+			So(run("prometheus", 2), ShouldEqual, 2)
+
+			close(blocked)
+			wg.Wait()
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// GenerateDiff generates a PDF page summarising the panels added, removed and
+// moved between two versions of the dashboard.
+func (r *Report) GenerateDiff(ctx context.Context, writer http.ResponseWriter, diff dashboard.VersionDiff) error {
+	defer helpers.TimeTrack(time.Now(), "diff report generation", r.logger)
+
+	body, err := generateDiffHTML(diff)
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Add("Content-Disposition", contentDisposition(diffFilename(diff, r.conf.FilenamePolicy)+".pdf"))
+
+	// Create a new tab
+	tab, err := r.chromeInstance.NewTab(ctx, r.logger, r.conf)
+	if err != nil {
+		return fmt.Errorf("error creating tab: %w", err)
+	}
+	defer tab.Close(r.logger)
+
+	paperWidth, paperHeight := r.conf.PaperDimensions()
+
+	if err := tab.PrintToPDF(chrome.PDFOptions{
+		Body:                body,
+		Orientation:         r.conf.Orientation,
+		PaperWidth:          paperWidth,
+		PaperHeight:         paperHeight,
+		DisableHeaderFooter: true,
+	}, writer); err != nil {
+		return fmt.Errorf("error rendering diff PDF: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateDiffHTML generates the summary of differences between two versions of
+// the dashboard as a standalone HTML page.
+func (r *Report) GenerateDiffHTML(_ context.Context, writer http.ResponseWriter, diff dashboard.VersionDiff) error {
+	body, err := generateDiffHTML(diff)
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Content-Disposition", contentDisposition(diffFilename(diff, r.conf.FilenamePolicy)+".html"))
+
+	if _, err := io.WriteString(writer, body); err != nil {
+		return fmt.Errorf("failed to write diff report: %w", err)
+	}
+
+	return nil
+}
+
+// generateDiffHTML renders the diff template.
+func generateDiffHTML(diff dashboard.VersionDiff) (string, error) {
+	tmpl, err := template.New("diff").ParseFS(templateFS, "templates/diff.gohtml")
+	if err != nil {
+		return "", fmt.Errorf("error parsing diff template: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = tmpl.ExecuteTemplate(buf, "diff.gohtml", diff); err != nil {
+		return "", fmt.Errorf("error executing diff template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// diffFilename returns the name of diff report file without extension.
+func diffFilename(diff dashboard.VersionDiff, policy string) string {
+	return sanitizeFilename(fmt.Sprintf("%s v%d-v%d", diff.Title, diff.FromVersion, diff.ToVersion), policy)
+}
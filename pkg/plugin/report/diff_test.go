@@ -0,0 +1,38 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerateDiffHTML(t *testing.T) {
+	Convey("When generating diff of dashboard versions", t, func() {
+		Convey("Changed panels should be listed", func() {
+			html, err := generateDiffHTML(dashboard.VersionDiff{
+				Title:       "My dashboard",
+				FromVersion: 2,
+				ToVersion:   5,
+				Added:       []dashboard.PanelChange{{ID: "5", Title: "Memory", Type: "gauge"}},
+				Moved: []dashboard.PanelChange{{
+					ID: "2", Title: "CPU", Type: "graph",
+					From: dashboard.GridPos{W: 12, H: 8, X: 6}, To: dashboard.GridPos{W: 12, H: 8},
+				}},
+			})
+
+			So(err, ShouldBeNil)
+			So(html, ShouldContainSubstring, "My dashboard: version 2 to 5")
+			So(html, ShouldContainSubstring, "<td>Memory</td><td>gauge</td>")
+			So(html, ShouldContainSubstring, "x=6 y=0 w=12 h=8")
+			So(html, ShouldNotContainSubstring, "Removed panels")
+		})
+
+		Convey("Unchanged versions should be reported as such", func() {
+			html, err := generateDiffHTML(dashboard.VersionDiff{Title: "My dashboard", FromVersion: 2, ToVersion: 3})
+
+			So(err, ShouldBeNil)
+			So(html, ShouldContainSubstring, "did not change")
+		})
+	})
+}
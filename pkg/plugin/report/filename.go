@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultFilenameExtension is the extension of report file when none is configured.
+const defaultFilenameExtension = "pdf"
+
+// Regex to match consecutive whitespace in file names.
+var whitespaceRegex = regexp.MustCompile(`\s+`)
+
+// Filename returns the name of the report file of a dashboard with the given
+// title based on the configured sanitization policy and extension.
+func Filename(title string, conf *config.Config) string {
+	ext := conf.FilenameExtension
+	if ext == "" {
+		ext = defaultFilenameExtension
+	}
+
+	return sanitizeFilename(title, conf.FilenamePolicy) + "." + ext
+}
+
+// ContentDisposition returns the value of Content-Disposition header of the
+// report of a dashboard with the given title.
+func ContentDisposition(title string, conf *config.Config) string {
+	// Sanitize title to escape non ASCII characters
+	// Ref: https://stackoverflow.com/questions/62705546/unicode-characters-in-attachment-name
+	// Ref: https://medium.com/@JeremyLaine/non-ascii-content-disposition-header-in-django-3a20acc05f0d
+	return contentDisposition(Filename(title, conf))
+}
+
+// contentDisposition returns the value of Content-Disposition header of the
+// given file name.
+func contentDisposition(filename string) string {
+	return fmt.Sprintf(`inline; filename*=UTF-8''%s`, url.PathEscape(filename))
+}
+
+// resolutionFilename returns the name of the report file of a dashboard with the
+// given title rendered at the given device scale factor.
+func resolutionFilename(title string, scale float64, conf *config.Config) string {
+	ext := conf.FilenameExtension
+	if ext == "" {
+		ext = defaultFilenameExtension
+	}
+
+	return fmt.Sprintf("%s@%sx.%s", sanitizeFilename(title, conf.FilenamePolicy), strconv.FormatFloat(scale, 'f', -1, 64), ext)
+}
+
+// sanitizeFilename sanitizes the name using given policy. Supported
+// policies are:
+//   - none: Name is used as it is
+//   - ascii: Accents are removed and other non ASCII characters are dropped
+//   - underscore: Whitespace is replaced by underscores
+//   - strict: ascii and all characters other than letters, digits, dots,
+//     hyphens and underscores are replaced by underscores
+func sanitizeFilename(name, policy string) string {
+	switch policy {
+	case "ascii":
+		name = toASCII(name)
+	case "underscore":
+		name = whitespaceRegex.ReplaceAllString(strings.TrimSpace(name), "_")
+	case "strict":
+		name = strings.Trim(unsafeFilenameRegex.ReplaceAllString(toASCII(name), "_"), "_")
+	}
+
+	// Never return an empty name
+	if strings.TrimSpace(name) == "" {
+		return "report"
+	}
+
+	return name
+}
+
+// toASCII removes accents from letters and drops remaining non ASCII characters.
+func toASCII(s string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFKD.String(s) {
+		if r <= unicode.MaxASCII {
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
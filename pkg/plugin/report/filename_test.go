@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFilename(t *testing.T) {
+	Convey("When making report file names", t, func() {
+		cases := []struct {
+			title    string
+			policy   string
+			ext      string
+			expected string
+		}{
+			{"My dashboard", "", "", "My dashboard.pdf"},
+			{"My dashboard", "none", "pdf", "My dashboard.pdf"},
+			{"Überwachung Çok", "ascii", "pdf", "Uberwachung Cok.pdf"},
+			{"Température 日本", "ascii", "pdf", "Temperature.pdf"},
+			{"  My   big dashboard ", "underscore", "pdf", "My_big_dashboard.pdf"},
+			{"Node: CPU / Memory (été)", "strict", "PDF", "Node_CPU_Memory_ete.PDF"},
+			{"日本", "strict", "pdf", "report.pdf"},
+			{"", "none", "pdf", "report.pdf"},
+		}
+
+		for _, c := range cases {
+			conf := &config.Config{FilenamePolicy: c.policy, FilenameExtension: c.ext}
+
+			Convey("File name should be sanitized: "+c.title+" "+c.policy, func() {
+				So(Filename(c.title, conf), ShouldEqual, c.expected)
+			})
+		}
+
+		Convey("Content disposition should escape file name", func() {
+			conf := &config.Config{FilenamePolicy: "none", FilenameExtension: "pdf"}
+
+			So(ContentDisposition("Überwachung", conf), ShouldEqual, `inline; filename*=UTF-8''%C3%9Cberwachung.pdf`)
+		})
+	})
+}
+
+func TestResolutionFilename(t *testing.T) {
+	Convey("When making report file names of resolutions", t, func() {
+		conf := &config.Config{FilenamePolicy: "underscore"}
+
+		So(resolutionFilename("My dashboard", 1, conf), ShouldEqual, "My_dashboard@1x.pdf")
+		So(resolutionFilename("My dashboard", 1.5, conf), ShouldEqual, "My_dashboard@1.5x.pdf")
+	})
+}
@@ -1,6 +1,13 @@
 package report
 
 import (
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -8,6 +15,64 @@ import (
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
 )
 
+var errNoStatValue = errors.New("no value found in panel data")
+
+// Regexes of basic formatting supported in glossary definitions.
+var (
+	boldRegex   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicRegex = regexp.MustCompile(`\*(.+?)\*`)
+	codeRegex   = regexp.MustCompile("`(.+?)`")
+)
+
+// Suffixes and prefixes of Grafana units.
+var (
+	unitSuffixes = map[string]string{
+		"percent":    "%",
+		"ns":         " ns",
+		"µs":         " µs",
+		"ms":         " ms",
+		"s":          " s",
+		"m":          " min",
+		"h":          " hour",
+		"d":          " day",
+		"hertz":      " Hz",
+		"reqps":      " req/s",
+		"rps":        " rd/s",
+		"wps":        " wr/s",
+		"iops":       " io/s",
+		"ops":        " ops/s",
+		"celsius":    "°C",
+		"fahrenheit": "°F",
+		"kelvin":     " K",
+		"volt":       " V",
+		"amp":        " A",
+		"watt":       " W",
+		"kwatt":      " kW",
+		"watth":      " Wh",
+		"kwatth":     " kWh",
+	}
+	unitPrefixes = map[string]string{
+		"currencyUSD": "$",
+		"currencyEUR": "€",
+		"currencyGBP": "£",
+		"currencyJPY": "¥",
+		"currencyINR": "₹",
+	}
+	unitScales = map[string]struct {
+		factor float64
+		names  []string
+	}{
+		"bytes":    {1024, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}},
+		"decbytes": {1000, []string{"B", "kB", "MB", "GB", "TB", "PB"}},
+		"bits":     {1024, []string{"b", "Kib", "Mib", "Gib", "Tib", "Pib"}},
+		"decbits":  {1000, []string{"b", "kb", "Mb", "Gb", "Tb", "Pb"}},
+		"binBps":   {1024, []string{"B/s", "KiB/s", "MiB/s", "GiB/s", "TiB/s", "PiB/s"}},
+		"Bps":      {1000, []string{"B/s", "kB/s", "MB/s", "GB/s", "TB/s", "PB/s"}},
+		"binbps":   {1024, []string{"b/s", "Kib/s", "Mib/s", "Gib/s", "Tib/s", "Pib/s"}},
+		"bps":      {1000, []string{"b/s", "kb/s", "Mb/s", "Gb/s", "Tb/s", "Pb/s"}},
+	}
+)
+
 // remove removes a element by value in slice and returns a new slice.
 func remove[T comparable](l []T, item T) []T {
 	out := make([]T, 0)
@@ -22,7 +87,10 @@ func remove[T comparable](l []T, item T) []T {
 }
 
 // selectPanels returns panel indexes to render based on IncludePanelIDs and ExcludePanelIDs
-// config parameters.
+// config parameters. Panels are matched using their base IDs so that repeated panels
+// are selected along with their source panel and IDs with and without panel- prefix
+// introduced in Grafana v11.3.0 match each other. This is needed as panels read from
+// the dashboard JSON model always have numeric IDs.
 func selectPanels(panels []dashboard.Panel, includeIDs, excludeIDs []string, defaultInclude bool) []int {
 	var renderPanels []int
 
@@ -30,17 +98,15 @@ func selectPanels(panels []dashboard.Panel, includeIDs, excludeIDs []string, def
 	// includeIDs
 	if len(includeIDs) == 0 && defaultInclude {
 		for _, p := range panels {
-			includeIDs = append(includeIDs, strings.Split(p.ID, "-clone")[0])
+			includeIDs = append(includeIDs, p.ID)
 		}
 	}
 
+	includeIDs = basePanelIDs(includeIDs)
+	excludeIDs = basePanelIDs(excludeIDs)
+
 	for iPanel, panel := range panels {
-		// Attempt to convert panel ID to int. If we succeed, do direct
-		// comparison else do prefix check
-		panelID := panel.ID
-		if _, err := strconv.ParseInt(panel.ID, 10, 0); err != nil {
-			panelID = strings.Split(panel.ID, "-clone")[0]
-		}
+		panelID := basePanelID(panel.ID)
 
 		for _, id := range includeIDs {
 			if panelID == id && !slices.Contains(renderPanels, iPanel) {
@@ -55,3 +121,367 @@ func selectPanels(panels []dashboard.Panel, includeIDs, excludeIDs []string, def
 
 	return renderPanels
 }
+
+// selectPanelTitles returns the panel indexes among indexes whose titles match
+// include regex and do not match exclude regex. Nil regexes match all the panels.
+func selectPanelTitles(panels []dashboard.Panel, indexes []int, include, exclude *regexp.Regexp) []int {
+	if include == nil && exclude == nil {
+		return indexes
+	}
+
+	var selected []int
+
+	for _, idx := range indexes {
+		title := panels[idx].Title
+
+		if include != nil && !include.MatchString(title) {
+			continue
+		}
+
+		if exclude != nil && exclude.MatchString(title) {
+			continue
+		}
+
+		selected = append(selected, idx)
+	}
+
+	return selected
+}
+
+// excludePanelTypes returns the panel indexes among indexes whose types are not
+// one of types. Panels of unknown type are always kept.
+func excludePanelTypes(panels []dashboard.Panel, indexes []int, types []string) []int {
+	if len(types) == 0 {
+		return indexes
+	}
+
+	var selected []int
+
+	for _, idx := range indexes {
+		if !slices.Contains(types, panels[idx].Type) {
+			selected = append(selected, idx)
+		}
+	}
+
+	return selected
+}
+
+// basePanelID returns the ID of panel without panel- prefix and clone suffix.
+func basePanelID(id string) string {
+	id, _ = dashboard.Panel{ID: id}.RepeatIndex()
+
+	return strings.TrimPrefix(id, "panel-")
+}
+
+// basePanelIDs returns base IDs of panels.
+func basePanelIDs(ids []string) []string {
+	baseIDs := make([]string, len(ids))
+	for i, id := range ids {
+		baseIDs[i] = basePanelID(id)
+	}
+
+	return baseIDs
+}
+
+// statValue returns the value of a stat panel from its CSV data. Stat panels show
+// the last value of the series by default and hence, we return the last non empty
+// value of the data. If format or unit is not empty and value is a number, it will
+// be formatted using format and unit.
+func statValue(data dashboard.CSVData, format, unit string) (string, error) {
+	value, _, ok := lastValue(data)
+	if !ok {
+		return "", errNoStatValue
+	}
+
+	if format != "" || unit != "" {
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return formatUnit(v, format, unit), nil
+		}
+	}
+
+	return value, nil
+}
+
+// statUnit returns the unit of the value of a stat panel. When overrides is true,
+// unit overrides of the field of the value are taken into account.
+func statUnit(panel dashboard.Panel, data dashboard.CSVData, overrides bool) string {
+	if !overrides {
+		return panel.Unit
+	}
+
+	if _, icol, ok := lastValue(data); ok && icol < len(data[0]) {
+		return panel.FieldUnit(data[0][icol])
+	}
+
+	return panel.Unit
+}
+
+// lastValue returns the last non empty value of CSV data and its column.
+func lastValue(data dashboard.CSVData) (string, int, bool) {
+	// First row is always header
+	for irow := len(data) - 1; irow >= 1; irow-- {
+		for icol := len(data[irow]) - 1; icol >= 0; icol-- {
+			if value := strings.TrimSpace(data[irow][icol]); value != "" {
+				return value, icol, true
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
+// formatUnit formats the value with Grafana unit. Values of units like bytes are
+// scaled to the largest unit in which they are at least one. If format is empty,
+// value is rounded to two decimals. Unknown units are ignored.
+func formatUnit(value float64, format, unit string) string {
+	number := func(v float64) string {
+		if format != "" {
+			return fmt.Sprintf(format, v)
+		}
+
+		return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	}
+
+	// Custom units are of form prefix:<prefix> and suffix:<suffix>
+	if prefix, ok := strings.CutPrefix(unit, "prefix:"); ok {
+		return prefix + number(value)
+	}
+
+	if suffix, ok := strings.CutPrefix(unit, "suffix:"); ok {
+		return number(value) + suffix
+	}
+
+	if unit == "percentunit" {
+		return number(value*100) + "%"
+	}
+
+	if prefix, ok := unitPrefixes[unit]; ok {
+		return prefix + number(value)
+	}
+
+	if scale, ok := unitScales[unit]; ok {
+		idx := 0
+		for math.Abs(value) >= scale.factor && idx < len(scale.names)-1 {
+			value /= scale.factor
+			idx++
+		}
+
+		return number(value) + " " + scale.names[idx]
+	}
+
+	return number(value) + unitSuffixes[unit]
+}
+
+// formatText escapes text and converts basic formatting like **bold**, *italic*
+// and `code` into HTML elements.
+func formatText(text string) template.HTML {
+	text = template.HTMLEscapeString(text)
+	text = codeRegex.ReplaceAllString(text, "<code>$1</code>")
+	text = boldRegex.ReplaceAllString(text, "<strong>$1</strong>")
+	text = italicRegex.ReplaceAllString(text, "<em>$1</em>")
+
+	return template.HTML(text) //nolint:gosec
+}
+
+// dedupePanels collapses the repeated panels that have identical images into the
+// first panel of the group. Titles of the collapsed panels are added to the
+// duplicates of the retained panel so that they can be listed in the report.
+func dedupePanels(panels []dashboard.Panel) []dashboard.Panel {
+	// Count number of panels in each repeat group
+	groups := make(map[string]int)
+
+	for _, p := range panels {
+		base, _ := p.RepeatIndex()
+		groups[base]++
+	}
+
+	// Index of first panel of each unique image within a repeat group
+	seen := make(map[string]int)
+
+	for ipanel, p := range panels {
+		base, _ := p.RepeatIndex()
+		if groups[base] < 2 || p.EncodedImage.Image == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s-%x", base, sha256.Sum256([]byte(p.EncodedImage.Image)))
+
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = ipanel
+
+			continue
+		}
+
+		panels[first].Duplicates = append(panels[first].Duplicates, p.Title)
+		panels[ipanel].EncodedImage = dashboard.PanelImage{}
+	}
+
+	return panels
+}
+
+// groupPanels groups panels by the prefix of their titles before delimiter. Panels
+// of each group are moved next to each other in the order of first appearance of
+// groups. In grid layout, rows of each group are stacked below the previous group
+// without gaps, leaving a row above the group for its heading.
+func groupPanels(panels []dashboard.Panel, delimiter string, gridLayout bool) []dashboard.Panel {
+	var names []string
+
+	groups := make(map[string][]dashboard.Panel)
+
+	for _, p := range panels {
+		if prefix, _, found := strings.Cut(p.Title, delimiter); found {
+			p.Group = strings.TrimSpace(prefix)
+		}
+
+		if _, ok := groups[p.Group]; !ok {
+			names = append(names, p.Group)
+		}
+
+		groups[p.Group] = append(groups[p.Group], p)
+	}
+
+	grouped := make([]dashboard.Panel, 0, len(panels))
+
+	var offset float64
+
+	for _, name := range names {
+		group := groups[name]
+
+		if gridLayout {
+			// Row of group heading
+			if name != "" {
+				offset++
+			}
+
+			offset += stackRows(group, offset)
+		}
+
+		grouped = append(grouped, group...)
+	}
+
+	return grouped
+}
+
+// stackRows moves the panels to start at row offset removing the rows that are
+// not covered by any of the panels. Removing uncovered rows keeps the relative
+// positions of panels and hence, panels never overlap. It returns the number
+// of rows covered by panels.
+func stackRows(panels []dashboard.Panel, offset float64) float64 {
+	type interval struct{ start, end float64 }
+
+	// Merge rows covered by panels into disjoint intervals
+	intervals := make([]interval, 0, len(panels))
+	for _, p := range panels {
+		intervals = append(intervals, interval{p.GridPos.Y, p.GridPos.Y + p.GridPos.H})
+	}
+
+	slices.SortFunc(intervals, func(a, b interval) int { return cmp.Compare(a.start, b.start) })
+
+	var merged []interval
+
+	for _, i := range intervals {
+		if n := len(merged); n > 0 && i.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, i.end)
+
+			continue
+		}
+
+		merged = append(merged, i)
+	}
+
+	for ipanel := range panels {
+		y := panels[ipanel].GridPos.Y
+
+		var covered float64
+
+		for _, i := range merged {
+			if i.start > y {
+				break
+			}
+
+			covered += min(i.end, y) - i.start
+		}
+
+		panels[ipanel].GridPos.Y = offset + covered
+	}
+
+	var total float64
+	for _, i := range merged {
+		total += i.end - i.start
+	}
+
+	return total
+}
+
+// tableStatsRow is a summary row of table columns.
+type tableStatsRow struct {
+	Label  string
+	Values []string
+}
+
+// tableColumnStats returns summary rows with sum, average, minimum and maximum of
+// each numeric column of the table data. A column is considered numeric when all
+// its non empty cells are finite numbers. Cells of non numeric columns are left
+// empty. If there are no numeric columns, nil is returned.
+func tableColumnStats(data dashboard.CSVData) []tableStatsRow {
+	if len(data) < 2 {
+		return nil
+	}
+
+	nCols := len(data[0])
+
+	labels := []string{"Sum", "Avg", "Min", "Max"}
+
+	rows := make([]tableStatsRow, len(labels))
+	for i, label := range labels {
+		rows[i] = tableStatsRow{Label: label, Values: make([]string, nCols)}
+	}
+
+	var hasNumeric bool
+
+	for col := range nCols {
+		values, ok := numericColumn(data[1:], col)
+		if !ok {
+			continue
+		}
+
+		hasNumeric = true
+
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+
+		for i, stat := range []float64{sum, sum / float64(len(values)), slices.Min(values), slices.Max(values)} {
+			rows[i].Values[col] = fmt.Sprintf("%s: %s", labels[i], strconv.FormatFloat(math.Round(stat*100)/100, 'f', -1, 64))
+		}
+	}
+
+	if !hasNumeric {
+		return nil
+	}
+
+	return rows
+}
+
+// numericColumn returns values of the column if all its non empty cells are
+// finite numbers.
+func numericColumn(rows dashboard.CSVData, col int) ([]float64, bool) {
+	var values []float64
+
+	for _, row := range rows {
+		if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+
+		values = append(values, v)
+	}
+
+	return values, len(values) > 0
+}
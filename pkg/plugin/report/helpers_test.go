@@ -1,6 +1,8 @@
 package report
 
 import (
+	"regexp"
+	"slices"
 	"testing"
 
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
@@ -114,4 +116,372 @@ func TestPanelSelector(t *testing.T) {
 			})
 		}
 	})
+
+	// Panels read from dashboard JSON model have numeric IDs even for Grafana >= v11.3.0
+	Convey("When selecting data panels across Grafana versions", t, func() {
+		cases := map[string]struct {
+			Panels     []dashboard.Panel
+			IncludeIDs []string
+			Result     []int
+		}{
+			"numeric_ids_before_v11.3": {
+				[]dashboard.Panel{{ID: "1"}, {ID: "5"}, {ID: "12"}},
+				[]string{"5"},
+				[]int{1},
+			},
+			"panel_ids_with_clones": {
+				[]dashboard.Panel{{ID: "panel-1"}, {ID: "panel-5-clone-0"}, {ID: "panel-5-clone-1"}, {ID: "panel-15"}},
+				[]string{"panel-5"},
+				[]int{1, 2},
+			},
+			"model_ids_with_converted_ids": {
+				[]dashboard.Panel{{ID: "1"}, {ID: "5"}, {ID: "15"}},
+				[]string{"panel-5"},
+				[]int{1},
+			},
+			"panel_ids_with_numeric_ids": {
+				[]dashboard.Panel{{ID: "panel-1"}, {ID: "panel-5"}, {ID: "panel-15"}},
+				[]string{"5", "15"},
+				[]int{1, 2},
+			},
+		}
+
+		for clName, cl := range cases {
+			dataPanels := selectPanels(cl.Panels, cl.IncludeIDs, nil, false)
+
+			Convey("Data panels should be selected by their base IDs: "+clName, func() {
+				So(dataPanels, ShouldResemble, cl.Result)
+			})
+		}
+	})
+}
+
+func TestSelectPanelTitles(t *testing.T) {
+	Convey("When filtering panels based on their titles", t, func() {
+		allPanels := []dashboard.Panel{
+			{ID: "1", Title: "CPU usage"}, {ID: "2", Title: "Memory usage"}, {ID: "3", Title: "Debug: CPU"},
+			{ID: "4", Title: "Network"}, {ID: "5", Title: ""},
+		}
+
+		cases := map[string]struct {
+			Indexes          []int
+			Include, Exclude *regexp.Regexp
+			Result           []int
+		}{
+			"no_regex": {
+				[]int{0, 1, 2},
+				nil,
+				nil,
+				[]int{0, 1, 2},
+			},
+			"include": {
+				[]int{0, 1, 2, 3, 4},
+				regexp.MustCompile(`(?i)cpu`),
+				nil,
+				[]int{0, 2},
+			},
+			"exclude": {
+				[]int{0, 1, 2, 3, 4},
+				nil,
+				regexp.MustCompile(`^Debug:`),
+				[]int{0, 1, 3, 4},
+			},
+			"include_and_exclude": {
+				[]int{0, 1, 2, 3, 4},
+				regexp.MustCompile(`CPU|usage`),
+				regexp.MustCompile(`^Debug:`),
+				[]int{0, 1},
+			},
+			"and_with_id_filters": {
+				selectPanels(allPanels, []string{"2", "3", "4"}, nil, true),
+				regexp.MustCompile(`CPU|usage`),
+				nil,
+				[]int{1, 2},
+			},
+		}
+
+		for clName, cl := range cases {
+			selected := selectPanelTitles(allPanels, cl.Indexes, cl.Include, cl.Exclude)
+
+			Convey("Panels should be properly selected: "+clName, func() {
+				So(selected, ShouldResemble, cl.Result)
+			})
+		}
+	})
+}
+
+func TestExcludePanelTypes(t *testing.T) {
+	Convey("When excluding panels based on their types", t, func() {
+		allPanels := []dashboard.Panel{
+			{ID: "1", Type: "timeseries"}, {ID: "2", Type: "text"}, {ID: "panel-3-clone-1", Type: "text"},
+			{ID: "4", Type: "table"}, {ID: "5"},
+		}
+
+		Convey("Panels should be kept without excluded types", func() {
+			So(excludePanelTypes(allPanels, []int{0, 1, 2, 3, 4}, nil), ShouldResemble, []int{0, 1, 2, 3, 4})
+		})
+
+		Convey("Panels of excluded types, including repeated ones, should be dropped", func() {
+			So(excludePanelTypes(allPanels, []int{0, 1, 2, 3, 4}, []string{"text", "row"}), ShouldResemble, []int{0, 3, 4})
+		})
+
+		Convey("Type filter should be combined with ID filters", func() {
+			indexes := selectPanels(allPanels, nil, []string{"4"}, true)
+
+			So(excludePanelTypes(allPanels, indexes, []string{"text"}), ShouldResemble, []int{0, 4})
+		})
+	})
+}
+
+func TestStatValue(t *testing.T) {
+	Convey("When extracting stat value from panel data", t, func() {
+		cases := map[string]struct {
+			Data   dashboard.CSVData
+			Format string
+			Value  string
+			Err    error
+		}{
+			"last_value": {
+				dashboard.CSVData{{"Time", "Value"}, {"2024-12-14 10:00:00", "10.12345"}, {"2024-12-14 10:01:00", "12.5 %"}},
+				"",
+				"12.5 %",
+				nil,
+			},
+			"skip_empty": {
+				dashboard.CSVData{{"Time", "Value"}, {"2024-12-14 10:00:00", "10.12345"}, {"", ""}},
+				"",
+				"10.12345",
+				nil,
+			},
+			"formatted": {
+				dashboard.CSVData{{"Time", "Value"}, {"2024-12-14 10:00:00", "10.12345"}},
+				"%.2f",
+				"10.12",
+				nil,
+			},
+			"formatted_non_numeric": {
+				dashboard.CSVData{{"Value"}, {"OK"}},
+				"%.2f",
+				"OK",
+				nil,
+			},
+			"only_header": {
+				dashboard.CSVData{{"Time", "Value"}},
+				"",
+				"",
+				errNoStatValue,
+			},
+		}
+
+		for clName, cl := range cases {
+			value, err := statValue(cl.Data, cl.Format, "")
+
+			Convey("Value should be properly extracted: "+clName, func() {
+				So(err, ShouldEqual, cl.Err)
+				So(value, ShouldEqual, cl.Value)
+			})
+		}
+	})
+}
+
+func TestFormatUnit(t *testing.T) {
+	Convey("When formatting values with Grafana units", t, func() {
+		cases := map[string]struct {
+			Value  float64
+			Format string
+			Unit   string
+			Result string
+		}{
+			"percent":      {85, "", "percent", "85%"},
+			"percent_unit": {0.853, "", "percentunit", "85.3%"},
+			"bytes":        {1536, "", "bytes", "1.5 KiB"},
+			"dec_bytes":    {2500000, "", "decbytes", "2.5 MB"},
+			"small_bytes":  {512, "", "bytes", "512 B"},
+			"bps":          {1200, "", "bps", "1.2 kb/s"},
+			"seconds":      {12.345, "", "s", "12.35 s"},
+			"celsius":      {21.5, "", "celsius", "21.5°C"},
+			"currency":     {12.5, "%.2f", "currencyUSD", "$12.50"},
+			"custom":       {3, "", "suffix: pods", "3 pods"},
+			"formatted":    {1536, "%.1f", "bytes", "1.5 KiB"},
+			"unknown":      {10.12345, "", "short", "10.12"},
+		}
+
+		for clName, cl := range cases {
+			Convey("Value should be formatted with unit: "+clName, func() {
+				So(formatUnit(cl.Value, cl.Format, cl.Unit), ShouldEqual, cl.Result)
+			})
+		}
+
+		Convey("Stat value should be formatted with unit", func() {
+			value, err := statValue(dashboard.CSVData{{"Value"}, {"85"}}, "", "percent")
+
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "85%")
+		})
+
+		Convey("Non numeric stat value should not be formatted with unit", func() {
+			value, err := statValue(dashboard.CSVData{{"Value"}, {"85 %"}}, "", "percent")
+
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "85 %")
+		})
+	})
+}
+
+func TestDedupePanels(t *testing.T) {
+	Convey("When deduplicating repeated panels", t, func() {
+		okImage := dashboard.PanelImage{Image: "iVBORw0KGgoOK", MimeType: "image/png"}
+		failImage := dashboard.PanelImage{Image: "iVBORw0KGgoFAIL", MimeType: "image/png"}
+
+		panels := []dashboard.Panel{
+			{ID: "panel-1", Title: "Status host1", EncodedImage: okImage},
+			{ID: "panel-1-clone-1", Title: "Status host2", EncodedImage: failImage},
+			{ID: "panel-1-clone-2", Title: "Status host3", EncodedImage: okImage},
+			{ID: "panel-1-clone-3", Title: "Status host4", EncodedImage: okImage},
+			{ID: "panel-2", Title: "Other", EncodedImage: okImage},
+			{ID: "panel-3", Title: "Another", EncodedImage: okImage},
+		}
+
+		panels = dedupePanels(panels)
+
+		Convey("Identical repeated panels should be collapsed into first panel", func() {
+			So(panels[0].Duplicates, ShouldResemble, []string{"Status host3", "Status host4"})
+			So(panels[0].EncodedImage, ShouldResemble, okImage)
+			So(panels[2].EncodedImage.Image, ShouldBeEmpty)
+			So(panels[3].EncodedImage.Image, ShouldBeEmpty)
+		})
+
+		Convey("Different repeated panels should be retained", func() {
+			So(panels[1].EncodedImage, ShouldResemble, failImage)
+			So(panels[1].Duplicates, ShouldBeEmpty)
+		})
+
+		Convey("Panels that are not repeated should be retained", func() {
+			So(panels[4].EncodedImage, ShouldResemble, okImage)
+			So(panels[5].EncodedImage, ShouldResemble, okImage)
+			So(panels[4].Duplicates, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestGroupPanels(t *testing.T) {
+	Convey("When grouping panels by title prefix", t, func() {
+		panels := []dashboard.Panel{
+			{ID: "1", Title: "DB: Connections", GridPos: dashboard.GridPos{X: 0, Y: 0, W: 12, H: 8}},
+			{ID: "2", Title: "Web: Requests", GridPos: dashboard.GridPos{X: 12, Y: 0, W: 12, H: 8}},
+			{ID: "3", Title: "Uptime", GridPos: dashboard.GridPos{X: 0, Y: 8, W: 24, H: 4}},
+			{ID: "4", Title: "DB : Queries", GridPos: dashboard.GridPos{X: 12, Y: 12, W: 12, H: 8}},
+			{ID: "5", Title: "Web: Errors", GridPos: dashboard.GridPos{X: 0, Y: 20, W: 12, H: 6}},
+		}
+
+		ids := func(panels []dashboard.Panel) []string {
+			var ids []string
+			for _, p := range panels {
+				ids = append(ids, p.ID)
+			}
+
+			return ids
+		}
+
+		Convey("Panels should be grouped by prefix in order of appearance of groups", func() {
+			grouped := groupPanels(slices.Clone(panels), ":", false)
+
+			So(ids(grouped), ShouldResemble, []string{"1", "4", "2", "5", "3"})
+			So(grouped[0].Group, ShouldEqual, "DB")
+			So(grouped[1].Group, ShouldEqual, "DB")
+			So(grouped[2].Group, ShouldEqual, "Web")
+			So(grouped[4].Group, ShouldBeEmpty)
+
+			// Titles and positions are kept in simple layout
+			So(grouped[1].Title, ShouldEqual, "DB : Queries")
+			So(grouped[1].GridPos, ShouldResemble, panels[3].GridPos)
+		})
+
+		Convey("Other delimiters should be supported", func() {
+			grouped := groupPanels(slices.Clone(panels), " - ", false)
+
+			So(ids(grouped), ShouldResemble, ids(panels))
+
+			for _, p := range grouped {
+				So(p.Group, ShouldBeEmpty)
+			}
+		})
+
+		Convey("Groups should be stacked with a heading row in grid layout", func() {
+			grouped := groupPanels(slices.Clone(panels), ":", true)
+
+			// DB group: heading at row 0 and panels without the gap between them
+			So(grouped[0].GridPos.Y, ShouldEqual, 1)
+			So(grouped[1].GridPos.Y, ShouldEqual, 9)
+
+			// Web group: heading at row 17
+			So(grouped[2].GridPos.Y, ShouldEqual, 18)
+			So(grouped[3].GridPos.Y, ShouldEqual, 26)
+
+			// Ungrouped panels without heading row
+			So(grouped[4].GridPos.Y, ShouldEqual, 32)
+
+			// Columns and sizes are unchanged
+			So(grouped[1].GridPos.X, ShouldEqual, 12)
+			So(grouped[1].GridPos.H, ShouldEqual, 8)
+		})
+	})
+}
+
+func TestTableColumnStats(t *testing.T) {
+	Convey("When computing summary statistics of table columns", t, func() {
+		Convey("Numeric columns should be aggregated", func() {
+			data := dashboard.CSVData{
+				{"Time", "Value", "Host", "Count"},
+				{"2024-12-14 10:00:00", "1.5", "host1", "10"},
+				{"2024-12-14 10:01:00", "2.5", "host2", ""},
+				{"2024-12-14 10:02:00", " -1 ", "host3", "20"},
+			}
+
+			So(tableColumnStats(data), ShouldResemble, []tableStatsRow{
+				{Label: "Sum", Values: []string{"", "Sum: 3", "", "Sum: 30"}},
+				{Label: "Avg", Values: []string{"", "Avg: 1", "", "Avg: 15"}},
+				{Label: "Min", Values: []string{"", "Min: -1", "", "Min: 10"}},
+				{Label: "Max", Values: []string{"", "Max: 2.5", "", "Max: 20"}},
+			})
+		})
+
+		Convey("Averages should be rounded", func() {
+			data := dashboard.CSVData{{"Value"}, {"1"}, {"1"}, {"2"}}
+
+			So(tableColumnStats(data)[1].Values, ShouldResemble, []string{"Avg: 1.33"})
+		})
+
+		Convey("Columns with non numeric values should be left blank", func() {
+			data := dashboard.CSVData{{"Value", "Ratio"}, {"1", "NaN"}, {"12 %", "0.5"}}
+
+			So(tableColumnStats(data), ShouldBeNil)
+		})
+
+		Convey("Tables without rows should have no statistics", func() {
+			So(tableColumnStats(dashboard.CSVData{{"Value"}}), ShouldBeNil)
+			So(tableColumnStats(dashboard.CSVData{{"Value"}, {""}}), ShouldBeNil)
+		})
+	})
+}
+
+func TestFormatText(t *testing.T) {
+	Convey("When formatting text with basic formatting", t, func() {
+		cases := map[string]struct {
+			Text   string
+			Result string
+		}{
+			"plain":   {"Service level objective", "Service level objective"},
+			"bold":    {"**Critical** alerts", "<strong>Critical</strong> alerts"},
+			"italic":  {"An *estimated* value", "An <em>estimated</em> value"},
+			"code":    {"Metric `up` of targets", "Metric <code>up</code> of targets"},
+			"escaped": {"<script>alert(1)</script> & **more**", "&lt;script&gt;alert(1)&lt;/script&gt; &amp; <strong>more</strong>"},
+		}
+
+		for clName, cl := range cases {
+			Convey("Text should be formatted: "+clName, func() {
+				So(string(formatText(cl.Text)), ShouldEqual, cl.Result)
+			})
+		}
+	})
 }
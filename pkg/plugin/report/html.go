@@ -0,0 +1,58 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Regexes to extract content of HTML documents.
+var (
+	bodyRegex     = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	bodyOpenRegex = regexp.MustCompile(`(?i)<body[^>]*>`)
+	styleRegex    = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+)
+
+// Page numbers are only known when printing the report and hence, they are
+// hidden in HTML reports.
+const hidePageNumbersStyle = `<style>.report-header .page-numbers, .report-footer .page-numbers { display: none; }</style>`
+
+// inlineHeaderFooter returns the body of HTML report with its header and footer
+// added at the start and end of the page, respectively.
+func inlineHeaderFooter(htmlReport HTML) string {
+	header, footer := htmlFragment(htmlReport.Header), htmlFragment(htmlReport.Footer)
+	if header == "" && footer == "" {
+		return htmlReport.Body
+	}
+
+	body := htmlReport.Body
+
+	loc := bodyOpenRegex.FindStringIndex(body)
+	end := strings.LastIndex(strings.ToLower(body), "</body>")
+
+	if loc == nil || end < loc[1] {
+		return body
+	}
+
+	if footer != "" {
+		body = body[:end] + `<div class="report-footer">` + footer + `</div>` + body[end:]
+	}
+
+	if header != "" {
+		header = `<div class="report-header">` + header + `</div>`
+	}
+
+	return body[:loc[1]] + hidePageNumbersStyle + header + body[loc[1]:]
+}
+
+// htmlFragment returns the styles and content of body of HTML document so that
+// it can be embedded in another page. Documents without body are returned as such.
+func htmlFragment(doc string) string {
+	match := bodyRegex.FindStringSubmatchIndex(doc)
+	if match == nil {
+		return strings.TrimSpace(doc)
+	}
+
+	styles := styleRegex.FindAllString(doc[:match[0]]+doc[match[1]:], -1)
+
+	return strings.TrimSpace(strings.Join(styles, "\n") + doc[match[2]:match[3]])
+}
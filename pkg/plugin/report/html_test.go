@@ -0,0 +1,43 @@
+package report
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInlineHeaderFooter(t *testing.T) {
+	Convey("When inlining header and footer into HTML report", t, func() {
+		htmlReport := HTML{
+			Header: `<html><style>.content-header { color: black; }</style><body><div class="content-header">My dashboard</div></body></html>`,
+			Body:   "<!DOCTYPE html>\n<html>\n<head></head>\n<body>\n<div>panels</div>\n</body>\n</html>",
+			Footer: `<html><body><div class="content-footer">Footer</div></body></html>`,
+		}
+
+		Convey("Header and footer should be added at the start and end of the page", func() {
+			body := inlineHeaderFooter(htmlReport)
+
+			So(body, ShouldEqual, "<!DOCTYPE html>\n<html>\n<head></head>\n<body>"+hidePageNumbersStyle+
+				`<div class="report-header"><style>.content-header { color: black; }</style><div class="content-header">My dashboard</div></div>`+
+				"\n<div>panels</div>\n"+
+				`<div class="report-footer"><div class="content-footer">Footer</div></div>`+
+				"</body>\n</html>")
+		})
+
+		Convey("Templates without body should be inlined as such", func() {
+			htmlReport.Header = `<div>Custom header</div>`
+			htmlReport.Footer = ""
+
+			body := inlineHeaderFooter(htmlReport)
+
+			So(body, ShouldContainSubstring, `<body>`+hidePageNumbersStyle+`<div class="report-header"><div>Custom header</div></div>`)
+			So(body, ShouldNotContainSubstring, `class="report-footer"`)
+		})
+
+		Convey("Body should be unchanged without header and footer", func() {
+			htmlReport.Header, htmlReport.Footer = "", ""
+
+			So(inlineHeaderFooter(htmlReport), ShouldEqual, htmlReport.Body)
+		})
+	})
+}
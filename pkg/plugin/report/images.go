@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// GenerateImages renders the panels of the dashboard and writes their PNGs as a
+// ZIP archive instead of the report. Panels that fail to render are left out of
+// the archive.
+func (r *Report) GenerateImages(ctx context.Context, writer http.ResponseWriter) error {
+	defer helpers.TimeTrack(time.Now(), "panel images generation", r.logger)
+
+	// Stat panels are rendered as PNGs like any other panel
+	defer func(asText bool) { r.conf.StatPanelsAsText = asText }(r.conf.StatPanelsAsText)
+	r.conf.StatPanelsAsText = false
+
+	dashboardData, err := r.dashboard.GetData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get dashboard data: %w", err)
+	}
+
+	if !r.conf.FullPageScreenshot {
+		pngPanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelIDs, r.conf.ExcludePanelIDs, true)
+		pngPanels = selectPanelTitles(dashboardData.Panels, pngPanels, r.conf.IncludePanelTitle, r.conf.ExcludePanelTitle)
+		pngPanels = excludePanelTypes(dashboardData.Panels, pngPanels, r.conf.ExcludePanelTypes)
+
+		if err := r.fetchPanels(ctx, dashboardData, pngPanels, nil); err != nil {
+			for _, pErr := range failedPanels(err) {
+				r.logger.Warn("failed to render panel, skipping it", "panel_id", dashboardData.Panels[pErr.idx].ID, "err", pErr.err)
+
+				dashboardData.Panels[pErr.idx].EncodedImage = dashboard.PanelImage{}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeImages(&buf, r.conf.CompressionLevel, dashboardData, r.conf.IncludeManifest); err != nil {
+		return err
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", contentDisposition(sanitizeFilename(dashboardData.Title, r.conf.FilenamePolicy)+".zip"))
+
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ZIP archive: %w", err)
+	}
+
+	return nil
+}
+
+// writeImages writes a ZIP archive with PNGs of panels compressed with
+// compressionLevel to writer. When includeManifest is true, a manifest
+// describing the panels is added as well.
+func writeImages(writer io.Writer, compressionLevel int, dashboardData *dashboard.Data, includeManifest bool) error {
+	archive := newZipWriter(writer, compressionLevel)
+
+	if err := addPanelImages(archive, dashboardData.Panels); err != nil {
+		return err
+	}
+
+	if includeManifest {
+		if err := addManifest(archive, newManifest(dashboardData)); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close ZIP archive: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteImages(t *testing.T) {
+	Convey("When writing PNGs of panels to an archive", t, func() {
+		dashData := &dashboard.Data{
+			Title: "My first dashboard",
+			UID:   "randomUID",
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "CPU usage", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"}},
+				{ID: "2", Title: "Failed panel"},
+				{ID: "panel-3-clone-1", Title: "Memory", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo=", MimeType: "image/png"}},
+			},
+		}
+
+		readArchive := func(includeManifest bool) map[string][]byte {
+			var buf bytes.Buffer
+
+			So(writeImages(&buf, flate.DefaultCompression, dashData, includeManifest), ShouldBeNil)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+
+			files := make(map[string][]byte)
+
+			for _, f := range archive.File {
+				r, err := f.Open()
+				So(err, ShouldBeNil)
+
+				files[f.Name], err = io.ReadAll(r)
+				So(err, ShouldBeNil)
+
+				r.Close()
+			}
+
+			return files
+		}
+
+		Convey("Archive should contain PNGs of rendered panels only", func() {
+			files := readArchive(false)
+
+			So(files, ShouldHaveLength, 2)
+			So(files, ShouldContainKey, "1_CPU_usage.png")
+			So(files, ShouldContainKey, "panel-3-clone-1_Memory.png")
+			So(files["1_CPU_usage.png"], ShouldResemble, []byte("\x89PNG\r\n\x1a\n"))
+		})
+
+		Convey("Archive should contain manifest when enabled", func() {
+			files := readArchive(true)
+
+			So(files, ShouldHaveLength, 3)
+
+			var m manifest
+
+			So(json.Unmarshal(files["manifest.json"], &m), ShouldBeNil)
+			So(m.Panels, ShouldHaveLength, 2)
+			So(m.Panels[0].Filename, ShouldEqual, "1_CPU_usage.png")
+		})
+
+		Convey("Invalid images should return error", func() {
+			dashData.Panels[0].EncodedImage.Image = "not base64"
+
+			So(writeImages(&bytes.Buffer{}, flate.DefaultCompression, dashData, false), ShouldNotBeNil)
+		})
+	})
+}
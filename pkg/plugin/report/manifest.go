@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+)
+
+// manifestFilename is the name of manifest file in the archives.
+const manifestFilename = "manifest.json"
+
+// Regex to replace characters that are not safe in file names.
+var unsafeFilenameRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// manifest describes the dashboard and its panels included in an archive.
+type manifest struct {
+	Report    string              `json:"report,omitempty"`
+	Title     string              `json:"title"`
+	UID       string              `json:"uid"`
+	TimeRange manifestTimeRange   `json:"timeRange"`
+	Variables map[string][]string `json:"variables"`
+	Panels    []manifestPanel     `json:"panels"`
+}
+
+// manifestTimeRange is the time range of the dashboard in manifest.
+type manifestTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// manifestPanel describes a panel file included in an archive.
+type manifestPanel struct {
+	Filename string            `json:"filename,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	GridPos  dashboard.GridPos `json:"gridPos"`
+}
+
+// newManifest returns manifest of the dashboard data. Only panels that have images
+// are included in the manifest.
+func newManifest(dashboardData *dashboard.Data) manifest {
+	m := manifest{
+		Title: dashboardData.Title,
+		UID:   dashboardData.UID,
+		TimeRange: manifestTimeRange{
+			From: dashboardData.TimeRange.From,
+			To:   dashboardData.TimeRange.To,
+		},
+		Variables: make(map[string][]string),
+		Panels:    []manifestPanel{},
+	}
+
+	for _, v := range dashboardData.VariableSummary {
+		m.Variables[v.Name] = v.Values
+	}
+
+	for _, p := range dashboardData.Panels {
+		if p.EncodedImage.Image == "" {
+			continue
+		}
+
+		m.Panels = append(m.Panels, manifestPanel{
+			Filename: panelFilename(p),
+			ID:       p.ID,
+			Title:    p.Title,
+			GridPos:  p.GridPos,
+		})
+	}
+
+	return m
+}
+
+// panelFilename returns the file name of panel PNG in archives based on its ID
+// and title.
+func panelFilename(p dashboard.Panel) string {
+	title := strings.Trim(unsafeFilenameRegex.ReplaceAllString(p.Title, "_"), "_")
+	if title == "" {
+		return fmt.Sprintf("%s.png", p.ID)
+	}
+
+	return fmt.Sprintf("%s_%s.png", p.ID, title)
+}
+
+// panelDataFilename returns the file name of panel CSV data in archives and PDF
+// attachments based on its ID.
+func panelDataFilename(p dashboard.Panel) string {
+	return fmt.Sprintf("panel-%s.csv", p.ID)
+}
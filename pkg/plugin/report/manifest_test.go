@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManifest(t *testing.T) {
+	Convey("When creating manifest of dashboard data", t, func() {
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			UID:   "randomUID",
+			TimeRange: dashboard.TimeRange{
+				From: "now-1h",
+				To:   "now",
+			},
+			VariableSummary: []dashboard.VariableValue{
+				{Name: "Host", Values: []string{"host1", "host2"}},
+			},
+			Panels: []dashboard.Panel{
+				{
+					ID:           "panel-1",
+					Title:        "CPU usage / host",
+					GridPos:      dashboard.GridPos{H: 6, W: 12, X: 0, Y: 0},
+					EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"},
+				},
+				{ID: "panel-2", Title: "Data only", CSVData: dashboard.CSVData{{"Time", "Value"}}},
+				{
+					ID:           "panel-3",
+					GridPos:      dashboard.GridPos{H: 6, W: 12, X: 12, Y: 0},
+					EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"},
+				},
+			},
+		}
+
+		m := newManifest(&dashData)
+
+		Convey("Manifest should describe the dashboard", func() {
+			So(m.Title, ShouldEqual, "My first dashboard")
+			So(m.UID, ShouldEqual, "randomUID")
+			So(m.TimeRange, ShouldResemble, manifestTimeRange{From: "now-1h", To: "now"})
+			So(m.Variables, ShouldResemble, map[string][]string{"Host": {"host1", "host2"}})
+		})
+
+		Convey("Manifest should describe only the panels with images", func() {
+			So(m.Panels, ShouldResemble, []manifestPanel{
+				{
+					Filename: "panel-1_CPU_usage_host.png",
+					ID:       "panel-1",
+					Title:    "CPU usage / host",
+					GridPos:  dashboard.GridPos{H: 6, W: 12, X: 0, Y: 0},
+				},
+				{
+					Filename: "panel-3.png",
+					ID:       "panel-3",
+					GridPos:  dashboard.GridPos{H: 6, W: 12, X: 12, Y: 0},
+				},
+			})
+		})
+
+		Convey("Manifest should be marshaled to JSON", func() {
+			b, err := json.Marshal(m)
+
+			So(err, ShouldBeNil)
+			So(string(b), ShouldContainSubstring, `"timeRange":{"from":"now-1h","to":"now"}`)
+			So(string(b), ShouldContainSubstring, `"filename":"panel-1_CPU_usage_host.png"`)
+			So(string(b), ShouldContainSubstring, `"gridPos":{"h":6,"w":12,"x":0,"y":0}`)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// ndjsonPanelIDKey is the key of panel ID in each NDJSON record.
+const ndjsonPanelIDKey = "panelId"
+
+// Regex to match numbers as defined by JSON grammar. Values like 007, 0x10
+// or 1e are not considered numbers and kept as strings.
+var jsonNumberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// GenerateNDJSON writes data of table panels of the dashboard as newline delimited
+// JSON. When no panels are selected using IncludePanelDataIDs, data of all table
+// panels is exported.
+func (r *Report) GenerateNDJSON(ctx context.Context, writer http.ResponseWriter) error {
+	defer helpers.TimeTrack(time.Now(), "NDJSON generation", r.logger)
+
+	dashboardData, tablePanels, err := r.tablePanelsData(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	for _, idx := range tablePanels {
+		panel := dashboardData.Panels[idx]
+
+		records, err := csvToNDJSON(panel.ID, panel.CSVData, r.conf.NDJSONParseNumbers)
+		if err != nil {
+			return fmt.Errorf("failed to convert data of panel %s: %w", panel.ID, err)
+		}
+
+		buf.Write(records)
+	}
+
+	writer.Header().Set("Content-Type", "application/x-ndjson")
+
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write NDJSON: %w", err)
+	}
+
+	return nil
+}
+
+// tablePanelsData fetches dashboard data along with the data of panels selected
+// using IncludePanelDataIDs and returns the indexes of those panels. When no panels
+// are selected, data of all table panels is fetched.
+func (r *Report) tablePanelsData(ctx context.Context) (*dashboard.Data, []int, error) {
+	// Get panel data from dashboard
+	dashboardData, err := r.dashboard.GetData(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get dashboard data: %w", err)
+	}
+
+	tablePanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelDataIDs, nil, false)
+	if len(r.conf.IncludePanelDataIDs) == 0 {
+		for idx, p := range dashboardData.Panels {
+			if p.Is(dashboard.Table) {
+				tablePanels = append(tablePanels, idx)
+			}
+		}
+	}
+
+	if err := r.fetchPanels(ctx, dashboardData, nil, tablePanels); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch panel data: %w", err)
+	}
+
+	return dashboardData, tablePanels, nil
+}
+
+// csvToNDJSON converts CSV data of a panel into newline delimited JSON. Each row
+// is converted into a JSON object using header row as keys and panel ID is added
+// to each object. Values are kept as strings unless parseNumbers is true in which
+// case numeric values are converted to numbers.
+func csvToNDJSON(panelID string, data dashboard.CSVData, parseNumbers bool) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// First row is always header
+	if len(data) < 2 {
+		return nil, nil
+	}
+
+	header := data[0]
+
+	for _, row := range data[1:] {
+		record := make(map[string]any, len(header)+1)
+
+		for icol, value := range row {
+			if icol >= len(header) {
+				break
+			}
+
+			record[header[icol]] = ndjsonValue(value, parseNumbers)
+		}
+
+		record[ndjsonPanelIDKey] = panelID
+
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ndjsonValue returns the JSON value of a CSV cell.
+func ndjsonValue(value string, parseNumbers bool) any {
+	if parseNumbers && jsonNumberRegex.MatchString(value) {
+		return json.Number(value)
+	}
+
+	return value
+}
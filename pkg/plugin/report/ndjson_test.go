@@ -0,0 +1,46 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSVToNDJSON(t *testing.T) {
+	Convey("When converting CSV data to NDJSON", t, func() {
+		data := dashboard.CSVData{
+			{"Host", "Value", "Code"},
+			{"host1", "1.5", "007"},
+			{"host2", "-2e3", "0x10"},
+			{"host3"},
+		}
+
+		Convey("Values should be kept as strings by default", func() {
+			ndjson, err := csvToNDJSON("12", data, false)
+
+			So(err, ShouldBeNil)
+			So(string(ndjson), ShouldEqual, `{"Code":"007","Host":"host1","Value":"1.5","panelId":"12"}
+{"Code":"0x10","Host":"host2","Value":"-2e3","panelId":"12"}
+{"Host":"host3","panelId":"12"}
+`)
+		})
+
+		Convey("Numeric values should be parsed when enabled", func() {
+			ndjson, err := csvToNDJSON("12", data, true)
+
+			So(err, ShouldBeNil)
+			So(string(ndjson), ShouldEqual, `{"Code":"007","Host":"host1","Value":1.5,"panelId":"12"}
+{"Code":"0x10","Host":"host2","Value":-2e3,"panelId":"12"}
+{"Host":"host3","panelId":"12"}
+`)
+		})
+
+		Convey("Data without rows should give no records", func() {
+			ndjson, err := csvToNDJSON("12", dashboard.CSVData{{"Host"}}, true)
+
+			So(err, ShouldBeNil)
+			So(ndjson, ShouldBeEmpty)
+		})
+	})
+}
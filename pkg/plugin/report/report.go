@@ -9,7 +9,6 @@ import (
 	"html/template"
 	"io"
 	"net/http"
-	"net/url"
 	"slices"
 	"strings"
 	"sync"
@@ -38,6 +37,12 @@ var popularSignatures = map[string]string{
 	"Qk02U":       "image/bmp",
 }
 
+// Minimum page margins in inches that leave room for header and footer.
+const (
+	minHeaderMargin = 1.18
+	minFooterMargin = 0.39
+)
+
 func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chromeInstance chrome.Instance,
 	pools worker.Pools, dashboard *dashboard.Dashboard,
 ) *Report {
@@ -48,43 +53,109 @@ func New(logger log.Logger, conf *config.Config, httpClient *http.Client, chrome
 		chromeInstance,
 		pools,
 		dashboard,
+		newDatasourceLimiter(conf.DatasourceConcurrency),
 	}
 }
 
 func (r *Report) Generate(ctx context.Context, writer http.ResponseWriter) error {
 	defer helpers.TimeTrack(time.Now(), "report generation", r.logger)
 
-	// Get panel data from dashboard
-	dashboardData, err := r.dashboard.GetData(ctx)
+	htmlReport, dashboardData, err := r.generateHTMLReport(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Add("Content-Disposition", ContentDisposition(dashboardData.Title, r.conf))
+
+	return r.writePDF(ctx, htmlReport, dashboardData, writer)
+}
+
+// GenerateHTML generates the report of the dashboard as a standalone HTML page
+// without rendering it into PDF. Header and footer of the PDF are inlined into
+// the page so that it is self-contained.
+func (r *Report) GenerateHTML(ctx context.Context, writer http.ResponseWriter) error {
+	defer helpers.TimeTrack(time.Now(), "HTML report generation", r.logger)
+
+	htmlReport, dashboardData, err := r.generateHTMLReport(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get dashboard data: %w", err)
+		return err
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Content-Disposition", contentDisposition(sanitizeFilename(dashboardData.Title, r.conf.FilenamePolicy)+".html"))
+
+	body := htmlReport.Body
+	if !r.conf.DisableHeaderFooter {
+		body = inlineHeaderFooter(htmlReport)
+	}
+
+	if _, err := io.WriteString(writer, body); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
 	}
 
-	// Populate panels with PNG and tabular data
-	if err := r.populatePanels(ctx, dashboardData); err != nil {
-		return fmt.Errorf("failed to populate panels: %w", err)
+	return nil
+}
+
+// generateHTMLReport fetches dashboard data and panels and returns the HTML
+// report along with the dashboard data.
+func (r *Report) generateHTMLReport(ctx context.Context) (HTML, *dashboard.Data, error) {
+	// Get panel data from dashboard
+	dashboardData, err := r.dashboard.GetData(ctx)
+	if err != nil {
+		return HTML{}, nil, fmt.Errorf("failed to get dashboard data: %w", err)
 	}
 
-	// panelTables = slices.DeleteFunc(panelTables, func(panelTable dashboard.PanelTable) bool {
-	// 	return panelTable.Data == nil
-	// })
+	// Populate panels with PNG and tabular data. Full page screenshot is
+	// already captured while getting dashboard data
+	if !r.conf.FullPageScreenshot {
+		if err := r.populatePanels(ctx, dashboardData); err != nil {
+			return HTML{}, nil, fmt.Errorf("failed to populate panels: %w", err)
+		}
 
-	// Sanitize title to escape non ASCII characters
-	// Ref: https://stackoverflow.com/questions/62705546/unicode-characters-in-attachment-name
-	// Ref: https://medium.com/@JeremyLaine/non-ascii-content-disposition-header-in-django-3a20acc05f0d
-	filename := url.PathEscape(dashboardData.Title)
-	header := fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, filename)
-	writer.Header().Add("Content-Disposition", header)
+		// Group panels into sections based on their title prefix
+		if r.conf.GroupByTitlePrefix != "" {
+			dashboardData.Panels = groupPanels(dashboardData.Panels, r.conf.GroupByTitlePrefix, r.conf.Layout == "grid")
+		}
+	}
 
 	htmlReport, err := r.generateHTMLFile(dashboardData)
 	if err != nil {
-		return fmt.Errorf("failed to generate HTML file: %w", err)
+		return HTML{}, nil, fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	return htmlReport, dashboardData, nil
+}
+
+// writePDF renders the HTML report into PDF and writes it to writer. When
+// enabled, data of rendered panels is embedded in the PDF as CSV files.
+func (r *Report) writePDF(ctx context.Context, htmlReport HTML, dashboardData *dashboard.Data, writer io.Writer) error {
+	var attachments []attachment
+	if r.conf.AttachPanelData && !r.conf.FullPageScreenshot {
+		attachments = r.panelAttachments(ctx, dashboardData.Panels)
 	}
 
-	if err = r.renderPDF(htmlReport, writer); err != nil {
+	if len(attachments) == 0 {
+		if err := r.renderPDF(ctx, htmlReport, dashboardData, writer); err != nil {
+			return fmt.Errorf("failed to render PDF: %w", err)
+		}
+
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := r.renderPDF(ctx, htmlReport, dashboardData, &buf); err != nil {
 		return fmt.Errorf("failed to render PDF: %w", err)
 	}
 
+	pdf, err := attachFiles(buf.Bytes(), attachments)
+	if err != nil {
+		return fmt.Errorf("failed to attach panel data to PDF: %w", err)
+	}
+
+	if _, err := writer.Write(pdf); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
 	return nil
 }
 
@@ -94,24 +165,123 @@ func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Da
 
 	// Get the indexes of PNG panels that need to be included in the report
 	pngPanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelIDs, r.conf.ExcludePanelIDs, true)
+	pngPanels = selectPanelTitles(dashboardData.Panels, pngPanels, r.conf.IncludePanelTitle, r.conf.ExcludePanelTitle)
+	pngPanels = excludePanelTypes(dashboardData.Panels, pngPanels, r.conf.ExcludePanelTypes)
 
 	// Get the indexes of table panels that need to be included in the report
 	tablePanels := selectPanels(dashboardData.Panels, r.conf.IncludePanelDataIDs, nil, false)
 
+	// Combined panels need both PNG and tabular data
+	for _, idx := range selectPanels(dashboardData.Panels, r.conf.CombinedPanels, nil, false) {
+		if !slices.Contains(pngPanels, idx) {
+			pngPanels = append(pngPanels, idx)
+		}
+
+		if !slices.Contains(tablePanels, idx) {
+			tablePanels = append(tablePanels, idx)
+		}
+	}
+
+	if err := r.fetchPanels(ctx, dashboardData, pngPanels, tablePanels); err != nil {
+		// Tolerate failures of a fraction of panels and render placeholders for them
+		total := len(pngPanels)
+
+		for _, idx := range tablePanels {
+			if !slices.Contains(pngPanels, idx) {
+				total++
+			}
+		}
+
+		failed := failedPanels(err)
+		if !panelFailuresTolerated(failed, total, r.conf.MaxPanelFailureRatio) {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+
+		r.logger.Warn("failed to fetch some panels, rendering placeholders", "total", total, "err", err)
+
+		for _, pErr := range failed {
+			if pErr.data {
+				dashboardData.Panels[pErr.idx].DataFailed = true
+			} else {
+				dashboardData.Panels[pErr.idx].ImageFailed = true
+			}
+		}
+	}
+
+	// Collect values of stat panels for executive summary
+	if r.conf.ExecutiveSummary {
+		dashboardData.Summary = r.executiveSummary(ctx, dashboardData.Panels)
+	}
+
+	// Collapse repeated panels with identical images
+	if r.conf.DedupeRepeatedPanels {
+		dashboardData.Panels = dedupePanels(dashboardData.Panels)
+	}
+
+	return nil
+}
+
+// fetchPanels fetches PNG data of panels at pngPanels indexes and tabular data of
+// panels at tablePanels indexes using worker pools.
+func (r *Report) fetchPanels(ctx context.Context, dashboardData *dashboard.Data, pngPanels, tablePanels []int) error {
 	errorCh := make(chan error, len(pngPanels)+len(tablePanels))
 
 	wg := sync.WaitGroup{}
 
+	// When deterministic rendering is enabled, panels are processed sequentially
+	// in the order they appear on the dashboard instead of dispatching them to
+	// worker pools
+	do := func(pool *worker.Pool, dsType string, f func()) {
+		if r.conf.DeterministicRender {
+			f()
+
+			return
+		}
+
+		r.dsLimiter.do(dsType, pool.Do, f)
+	}
+
+	// When adaptive concurrency is enabled, number of concurrent panel renders
+	// is reduced when Grafana is overloaded and increased back when they succeed
+	var limiter *adaptiveLimiter
+	if r.conf.AdaptiveConcurrency {
+		limiter = newAdaptiveLimiter(r.conf.MaxRenderWorkers)
+	}
+
 	for idx, panel := range dashboardData.Panels {
 		if slices.Contains(pngPanels, idx) {
 			wg.Add(1)
+			limiter.acquire()
+
+			// Stat panels rendered as text need browser to fetch their values
+			asText := r.conf.StatPanelsAsText && panel.IsStat()
+
+			pool := r.pools[worker.Renderer]
+			if asText {
+				pool = r.pools[worker.Browser]
+			}
 
-			r.pools[worker.Renderer].Do(func() {
+			do(pool, panel.DatasourceType, func() {
 				defer wg.Done()
 
+				if asText {
+					value, err := r.panelStatValue(ctx, panel)
+					if err == nil {
+						dashboardData.Panels[idx].StatValue = value
+
+						limiter.release(nil)
+
+						return
+					}
+
+					r.logger.Debug("failed to extract stat value, falling back to PNG", "panel_id", panel.ID, "err", err)
+				}
+
 				panelPNG, err := r.dashboard.PanelPNG(ctx, panel)
+				limiter.release(err)
+
 				if err != nil {
-					errorCh <- fmt.Errorf("failed to fetch PNG data for panel %s: %w", panel.ID, err)
+					errorCh <- &panelError{idx, false, fmt.Errorf("failed to fetch PNG data for panel %s: %w", panel.ID, err)}
 				}
 
 				dashboardData.Panels[idx].EncodedImage = panelPNG
@@ -121,12 +291,12 @@ func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Da
 		if slices.Contains(tablePanels, idx) {
 			wg.Add(1)
 
-			r.pools[worker.Browser].Do(func() {
+			do(r.pools[worker.Browser], panel.DatasourceType, func() {
 				defer wg.Done()
 
 				panelData, err := r.dashboard.PanelCSV(ctx, panel)
 				if err != nil {
-					errorCh <- fmt.Errorf("failed to fetch CSV data for panel %s: %w", panel.ID, err)
+					errorCh <- &panelError{idx, true, fmt.Errorf("failed to fetch CSV data for panel %s: %w", panel.ID, err)}
 				}
 
 				dashboardData.Panels[idx].CSVData = panelData
@@ -143,11 +313,77 @@ func (r *Report) populatePanels(ctx context.Context, dashboardData *dashboard.Da
 		errs = append(errs, err)
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("failed to generate report: %w", errors.Join(errs...))
+	return errors.Join(errs...)
+}
+
+// panelError is the error of fetching PNG or tabular data of the panel at index idx.
+type panelError struct {
+	idx  int
+	data bool
+	err  error
+}
+
+func (e *panelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *panelError) Unwrap() error {
+	return e.err
+}
+
+// failedPanels returns the panel errors joined in err.
+func failedPanels(err error) []*panelError {
+	var failed []*panelError
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+		for _, e := range joined.Unwrap() {
+			failed = append(failed, failedPanels(e)...)
+		}
+
+		return failed
 	}
 
-	return nil
+	var pErr *panelError
+	if errors.As(err, &pErr) {
+		failed = append(failed, pErr)
+	}
+
+	return failed
+}
+
+// panelFailuresTolerated returns true when the number of failed panels is less
+// than the fraction maxRatio of total panels. Panels whose PNG and tabular data
+// both failed count once.
+func panelFailuresTolerated(failed []*panelError, total int, maxRatio float64) bool {
+	if len(failed) == 0 {
+		return true
+	}
+
+	if total == 0 || maxRatio <= 0 {
+		return false
+	}
+
+	panels := make(map[int]struct{}, len(failed))
+	for _, pErr := range failed {
+		panels[pErr.idx] = struct{}{}
+	}
+
+	return float64(len(panels))/float64(total) < maxRatio
+}
+
+// panelStatValue returns the value displayed by a stat panel from its data.
+func (r *Report) panelStatValue(ctx context.Context, panel dashboard.Panel) (string, error) {
+	panelData, err := r.dashboard.PanelCSV(ctx, panel)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch CSV data for panel %s: %w", panel.ID, err)
+	}
+
+	var unit string
+	if r.conf.StatUnits {
+		unit = statUnit(panel, panelData, r.conf.ApplyFieldOverrides)
+	}
+
+	return statValue(panelData, r.conf.StatNumberFormat, unit)
 }
 
 // generateHTMLFile generates HTML files for PDF.
@@ -186,19 +422,42 @@ func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
 		"url": func(url string) template.URL {
 			return template.URL(template.HTMLEscapeString(url)) //nolint:gosec
 		},
+
+		"join": strings.Join,
+
+		"columnStats": tableColumnStats,
+
+		"now": func() time.Time {
+			return time.Now().In(r.conf.Location)
+		},
+
+		// Times are formatted in the configured time zone and format unless
+		// a layout is given
+		"formatTime": func(t time.Time, layout ...string) string {
+			if len(layout) > 0 {
+				return t.In(r.conf.Location).Format(layout[0])
+			}
+
+			return t.In(r.conf.Location).Format(r.conf.TimeFormat)
+		},
+
+		"dashboardURL": func() template.URL {
+			return template.URL(dashboardData.URL) //nolint:gosec
+		},
 	}
 
 	// Make a new template for Body of the PDF
-	if tmpl, err = template.New("report").Funcs(funcMap).ParseFS(templateFS, "templates/report.gohtml"); err != nil {
+	if tmpl, err = template.New("report").Funcs(funcMap).ParseFS(templateFS, "templates/report.gohtml", "templates/cover.gohtml"); err != nil {
 		return HTML{}, fmt.Errorf("error parsing PDF template: %w", err)
 	}
 
 	// Template data
 	data := templateData{
-		time.Now().Local().In(r.conf.Location).Format(r.conf.TimeFormat),
-		dashboardData,
-		r.conf,
+		Date:      time.Now().Local().In(r.conf.Location).Format(r.conf.TimeFormat),
+		Dashboard: dashboardData,
+		Conf:      r.conf,
 	}
+	data.paginate()
 
 	// Render the template for Body of the PDF
 	bufBody := &bytes.Buffer{}
@@ -249,21 +508,56 @@ func (r *Report) generateHTMLFile(dashboardData *dashboard.Data) (HTML, error) {
 	return html, nil
 }
 
+// pageMargins returns top, right, bottom and left margins of pages in inches.
+// When header and footer are printed, margins are at least as large as they
+// are so that they do not overlap with panels.
+func pageMargins(conf *config.Config) (float64, float64, float64, float64) {
+	top, bottom := conf.MarginTop, conf.MarginBottom
+
+	if !conf.DisableHeaderFooter {
+		top = max(top, minHeaderMargin)
+		bottom = max(bottom, minFooterMargin)
+	}
+
+	return top, conf.MarginRight, bottom, conf.MarginLeft
+}
+
 // renderPDF renders HTML page into PDF using Chromium.
-func (r *Report) renderPDF(htmlReport HTML, writer io.Writer) error {
+func (r *Report) renderPDF(ctx context.Context, htmlReport HTML, dashboardData *dashboard.Data, writer io.Writer) error {
 	defer helpers.TimeTrack(time.Now(), "pdf rendering", r.logger)
 
+	_, span := helpers.StartSpan(ctx, r.conf.TracingEnabled, "report.renderPDF",
+		helpers.SpanDashboardUID.String(dashboardData.UID),
+	)
+	defer span.End()
+
 	// Create a new tab
-	tab := r.chromeInstance.NewTab(r.logger, r.conf)
+	tab, err := r.chromeInstance.NewTab(ctx, r.logger, r.conf)
+	if err != nil {
+		return fmt.Errorf("error creating tab: %w", err)
+	}
 	defer tab.Close(r.logger)
 
-	err := tab.PrintToPDF(chrome.PDFOptions{
-		Header:      htmlReport.Header,
-		Body:        htmlReport.Body,
-		Footer:      htmlReport.Footer,
-		Orientation: r.conf.Orientation,
+	paperWidth, paperHeight := r.conf.PaperDimensions()
+	top, right, bottom, left := pageMargins(r.conf)
+
+	err = tab.PrintToPDF(chrome.PDFOptions{
+		Header:              htmlReport.Header,
+		Body:                htmlReport.Body,
+		Footer:              htmlReport.Footer,
+		Orientation:         r.conf.Orientation,
+		PaperWidth:          paperWidth,
+		PaperHeight:         paperHeight,
+		MarginTop:           top,
+		MarginBottom:        bottom,
+		MarginLeft:          left,
+		MarginRight:         right,
+		DisableHeaderFooter: r.conf.DisableHeaderFooter,
+		GenerateOutline:     r.conf.GenerateOutline,
 	}, writer)
 	if err != nil {
+		helpers.SpanError(span, err)
+
 		return fmt.Errorf("error rendering PDF: %w", err)
 	}
 
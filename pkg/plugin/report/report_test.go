@@ -2,7 +2,15 @@ package report
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,8 +37,11 @@ func TestReport(t *testing.T) {
 		rep := New(
 			logger,
 			&config.Config{
-				TimeFormat: time.UnixDate,
-				Location:   time.Now().Location(),
+				TimeFormat:           time.UnixDate,
+				Location:             time.Now().Location(),
+				VariableSummaryTable: true,
+				IncludePanelIndex:    true,
+				TableColumnStats:     true,
 			},
 			nil,
 			&chrome.LocalInstance{},
@@ -42,10 +53,15 @@ func TestReport(t *testing.T) {
 		dashData := dashboard.Data{
 			Title: "My first dashboard",
 			Panels: []dashboard.Panel{
-				{ID: "1", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgofsdfsdfsdf", MimeType: "image/png"}},
+				{ID: "1", Title: "Traffic", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgofsdfsdfsdf", MimeType: "image/png"}},
 				{ID: "2", CSVData: [][]string{{"1", "2", "3"}, {"value1", "value2", "value3"}}},
+				{ID: "3", Title: "Uptime", StatValue: "99.95 %"},
+				{ID: "4", CSVData: [][]string{{"Host", "Requests"}, {"host1", "10"}, {"host2", "30"}}},
 			},
 			Variables: "testvarvalue",
+			VariableSummary: []dashboard.VariableValue{
+				{Name: "Host", Values: []string{"host1", "host2"}},
+			},
 			TimeRange: dashboard.TimeRange{
 				From: "1734194455000",
 				To:   "1734194465000",
@@ -76,6 +92,25 @@ func TestReport(t *testing.T) {
 
 					So(s, ShouldContainSubstring, "image1")
 				})
+				Convey("and the stat values as text", func() {
+					So(s, ShouldContainSubstring, "stat3")
+					So(s, ShouldContainSubstring, "99.95 %")
+				})
+				Convey("and the variable summary table", func() {
+					So(s, ShouldContainSubstring, "<td>Host</td>")
+					So(s, ShouldContainSubstring, "host1, host2")
+				})
+				Convey("and the table column statistics", func() {
+					So(s, ShouldContainSubstring, "<tfoot>")
+					So(strings.Count(s, "<tfoot>"), ShouldEqual, 1)
+					So(s, ShouldContainSubstring, "Sum: 40")
+					So(s, ShouldContainSubstring, "Avg: 20")
+				})
+				Convey("and the panel index", func() {
+					So(s, ShouldContainSubstring, "panelIndex")
+					So(s, ShouldContainSubstring, "<td>1</td>\n                    <td>Traffic</td>")
+					So(s, ShouldContainSubstring, "<td>2</td>\n                    <td>Uptime</td>")
+				})
 				Convey("and the time range", func() {
 					// server time zone by shift hours timestamp
 					// so just test for day and year
@@ -86,3 +121,919 @@ func TestReport(t *testing.T) {
 		})
 	})
 }
+
+func TestDeterministicRender(t *testing.T) {
+	Convey("When populating panels with deterministic rendering", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var (
+			mu       sync.Mutex
+			panelIDs []string
+		)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			panelIDs = append(panelIDs, r.URL.Query().Get("panelId"))
+			mu.Unlock()
+
+			w.Write([]byte("PNG")) //nolint:errcheck
+		}))
+		defer ts.Close()
+
+		conf := &config.Config{
+			Layout:              "simple",
+			DashboardMode:       "default",
+			DeterministicRender: true,
+		}
+
+		dash, err := dashboard.New(
+			logger,
+			conf,
+			http.DefaultClient,
+			&chrome.LocalInstance{},
+			ts.URL,
+			"v11.1.0",
+			&dashboard.Model{Dashboard: dashboard.Spec{UID: "randomUID", Variables: url.Values{}}},
+			http.Header{},
+		)
+		So(err, ShouldBeNil)
+
+		rep := New(
+			logger,
+			conf,
+			nil,
+			&chrome.LocalInstance{},
+			worker.Pools{
+				worker.Browser:  worker.New(ctx, 6),
+				worker.Renderer: worker.New(ctx, 6),
+			},
+			dash,
+		)
+
+		dashData := dashboard.Data{
+			Panels: []dashboard.Panel{
+				{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}, {ID: "6"}, {ID: "7"}, {ID: "8"},
+			},
+		}
+
+		err = rep.populatePanels(ctx, &dashData)
+
+		Convey("Panels should be rendered sequentially in dashboard order", func() {
+			So(err, ShouldBeNil)
+			So(panelIDs, ShouldResemble, []string{"1", "2", "3", "4", "5", "6", "7", "8"})
+
+			for _, panel := range dashData.Panels {
+				So(panel.EncodedImage.Image, ShouldNotBeEmpty)
+			}
+		})
+	})
+}
+
+func TestPanelFailureRatio(t *testing.T) {
+	Convey("When some panels fail to render", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		failing := map[string]bool{}
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if failing[r.URL.Query().Get("panelId")] {
+				// Close connection so that request fails without retries
+				conn, _, _ := w.(http.Hijacker).Hijack() //nolint:forcetypeassert
+				conn.Close()
+
+				return
+			}
+
+			w.Write([]byte("PNG")) //nolint:errcheck
+		}))
+		defer ts.Close()
+
+		conf := &config.Config{
+			Layout:              "simple",
+			DashboardMode:       "default",
+			DeterministicRender: true,
+			TimeFormat:          time.UnixDate,
+			Location:            time.Now().Location(),
+		}
+
+		dash, err := dashboard.New(
+			logger,
+			conf,
+			http.DefaultClient,
+			&chrome.LocalInstance{},
+			ts.URL,
+			"v11.1.0",
+			&dashboard.Model{Dashboard: dashboard.Spec{UID: "randomUID", Variables: url.Values{}}},
+			http.Header{},
+		)
+		So(err, ShouldBeNil)
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, dash)
+
+		newData := func() *dashboard.Data {
+			return &dashboard.Data{
+				TimeRange: dashboard.TimeRange{From: "1734194455000", To: "1734194465000"},
+				Panels: []dashboard.Panel{
+					{ID: "1"}, {ID: "2", Title: "Flaky"}, {ID: "3"}, {ID: "4"}, {ID: "5"}, {ID: "6"}, {ID: "7"}, {ID: "8"},
+				},
+			}
+		}
+
+		Convey("Report should fail by default", func() {
+			failing["2"] = true
+
+			So(rep.populatePanels(ctx, newData()), ShouldNotBeNil)
+		})
+
+		Convey("Report should succeed with placeholders when failures are below ratio", func() {
+			failing["2"] = true
+			conf.MaxPanelFailureRatio = 0.2
+
+			dashData := newData()
+			So(rep.populatePanels(ctx, dashData), ShouldBeNil)
+			So(dashData.Panels[1].ImageFailed, ShouldBeTrue)
+			So(dashData.Panels[0].ImageFailed, ShouldBeFalse)
+			So(dashData.Panels[0].EncodedImage.Image, ShouldNotBeEmpty)
+
+			html, err := rep.generateHTMLFile(dashData)
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `id="placeholder2"`)
+			So(html.Body, ShouldContainSubstring, "Panel could not be rendered")
+			So(html.Body, ShouldContainSubstring, ".grid-image-1 {")
+		})
+
+		Convey("Report should fail when failures reach ratio", func() {
+			failing["2"] = true
+			failing["5"] = true
+			conf.MaxPanelFailureRatio = 0.2
+
+			So(rep.populatePanels(ctx, newData()), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When checking if panel failures are tolerated", t, func() {
+		failed := func(idxs ...int) []*panelError {
+			errs := make([]*panelError, len(idxs))
+			for i, idx := range idxs {
+				errs[i] = &panelError{idx: idx}
+			}
+
+			return errs
+		}
+
+		tests := []struct {
+			name     string
+			failed   []*panelError
+			total    int
+			ratio    float64
+			expected bool
+		}{
+			{"no failures", nil, 10, 0, true},
+			{"failure without ratio", failed(1), 10, 0, false},
+			{"failures below ratio", failed(1), 10, 0.2, true},
+			{"failures at ratio", failed(1, 2), 10, 0.2, false},
+			{"failures above ratio", failed(1, 2, 3), 10, 0.2, false},
+			{"PNG and data failures of same panel", failed(1, 1), 10, 0.2, true},
+			{"all panels failed", failed(1, 2), 2, 1, false},
+			{"all but one panels failed", failed(1, 2), 3, 1, true},
+		}
+
+		for _, test := range tests {
+			So(panelFailuresTolerated(test.failed, test.total, test.ratio), ShouldEqual, test.expected)
+		}
+	})
+
+	Convey("When collecting panel errors", t, func() {
+		err := errors.Join(
+			&panelError{1, false, errors.New("png")},
+			fmt.Errorf("wrapped: %w", &panelError{2, true, errors.New("csv")}),
+			errors.New("other"),
+		)
+
+		failed := failedPanels(err)
+
+		So(failed, ShouldHaveLength, 2)
+		So(failed[0].idx, ShouldEqual, 1)
+		So(failed[1].data, ShouldBeTrue)
+	})
+}
+
+func TestPanelStyle(t *testing.T) {
+	Convey("When generating HTML with panel styles", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.Now().Location(),
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			Panels: []dashboard.Panel{
+				{ID: "1", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgofsdfsdfsdf", MimeType: "image/png"}},
+			},
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Panels should be borderless by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, "border: 2px")
+			So(html.Body, ShouldNotContainSubstring, "box-shadow")
+		})
+
+		Convey("Panels should have border and shadow when enabled", func() {
+			conf.PanelBorderWidth = 2
+			conf.PanelBorderColor = "#FF0000"
+			conf.PanelShadow = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "border: 2px solid #FF0000;")
+			So(html.Body, ShouldContainSubstring, "box-shadow")
+		})
+
+		Convey("Panel images should not be wrapped by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `class="panel-background"`)
+		})
+
+		Convey("Panel images should be wrapped in a uniform background when enabled", func() {
+			conf.PanelBackground = "#1F1F20"
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "background-color: #1F1F20;")
+			So(html.Body, ShouldContainSubstring, `<div class="panel-background">`)
+			So(html.Body, ShouldContainSubstring, `id="image1"`)
+		})
+
+		Convey("Panel images should not be linked by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `class="panel-link"`)
+		})
+
+		Convey("Panel images should be wrapped in a link to live panel when enabled", func() {
+			conf.LinkPanelsToLive = true
+			dashData.Panels[0].LiveURL = "https://localhost:3000/d/abc/_?from=now-1h&to=now&viewPanel=1"
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `<a href="https://localhost:3000/d/abc/_?from=now-1h&amp;to=now&amp;viewPanel=1" class="panel-link">
+                <img src="data:image/png;base64,iVBORw0KGgofsdfsdfsdf" id="image1"`)
+		})
+	})
+}
+
+func TestPageMargins(t *testing.T) {
+	Convey("When generating HTML with page margins", t, func() {
+		conf := &config.Config{
+			TimeFormat:   time.UnixDate,
+			Location:     time.Now().Location(),
+			MarginTop:    0.5,
+			MarginBottom: 0.2,
+			MarginLeft:   0.25,
+			MarginRight:  0.75,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Margins should leave room for header and footer", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "margin: 1.18in 0.75in 0.39in 0.25in;")
+		})
+
+		Convey("Margins should be used as such without header and footer", func() {
+			conf.DisableHeaderFooter = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "margin: 0.5in 0.75in 0.2in 0.25in;")
+		})
+	})
+}
+
+func TestCoverPage(t *testing.T) {
+	Convey("When generating report with a cover page", t, func() {
+		conf := &config.Config{
+			TimeFormat:  time.UnixDate,
+			Location:    time.Now().Location(),
+			EncodedLogo: "iVBORw0KGgo",
+			PaperSize:   "A4",
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Variables: "host=node1",
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Graph", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}},
+			},
+		}
+
+		Convey("Cover page should not be added by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `<div class="cover">`)
+		})
+
+		Convey("Cover page should be added before panels when enabled", func() {
+			conf.CoverPage = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `<div class="cover-title">My first dashboard</div>`)
+			So(html.Body, ShouldContainSubstring, `<div class="cover-variables">host=node1</div>`)
+			So(html.Body, ShouldContainSubstring, `<img class="cover-logo" src="data:image/png;base64,iVBORw0KGgo"`)
+			So(html.Body, ShouldContainSubstring, "height: 10.12in;")
+
+			cover := strings.Index(html.Body, `<div class="cover">`)
+			So(cover, ShouldBeLessThan, strings.Index(html.Body, `id="image1"`))
+			So(html.Body[cover:strings.Index(html.Body, `id="image1"`)], ShouldContainSubstring, "break-after:page")
+		})
+
+		Convey("Cover page should fill the page in landscape orientation", func() {
+			conf.CoverPage = true
+			conf.Orientation = "landscape"
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "height: 6.7in;")
+		})
+	})
+}
+
+func TestTableOfContents(t *testing.T) {
+	Convey("When generating report with a table of contents", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.Now().Location(),
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Graph", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}},
+				{ID: "2", StatValue: "42"},
+				{ID: "3", Title: "Traffic table", CSVData: dashboard.CSVData{{"Host", "Value"}, {"node1", "1"}}},
+				{ID: "4", Title: "Broken", ImageFailed: true},
+			},
+		}
+
+		Convey("Table of contents should not be added by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `id="tableOfContents"`)
+		})
+
+		Convey("Table of contents should link to all panels when enabled", func() {
+			conf.TableOfContents = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `<li><a href="#image1">Graph</a></li>`)
+			So(html.Body, ShouldContainSubstring, `<li><a href="#stat2">Panel 2</a></li>`)
+			So(html.Body, ShouldContainSubstring, `<li><a href="#placeholder4">Broken</a></li>`)
+			So(html.Body, ShouldContainSubstring, `<li><a href="#table3">Traffic table</a></li>`)
+
+			// Every link must resolve to an element of the report
+			for _, id := range []string{"image1", "stat2", "table3", "placeholder4"} {
+				So(html.Body, ShouldContainSubstring, fmt.Sprintf(`id="%s"`, id))
+			}
+
+			// Table of contents must be on its own page before panels
+			toc := strings.Index(html.Body, `id="tableOfContents"`)
+			So(toc, ShouldBeLessThan, strings.Index(html.Body, `id="image1"`))
+			So(html.Body[toc:strings.Index(html.Body, `id="image1"`)], ShouldContainSubstring, "break-after:page")
+		})
+
+		Convey("Table of contents should follow the cover page", func() {
+			conf.TableOfContents = true
+			conf.CoverPage = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Index(html.Body, `<div class="cover">`), ShouldBeLessThan, strings.Index(html.Body, `id="tableOfContents"`))
+		})
+	})
+}
+
+func TestPanelsPerPage(t *testing.T) {
+	Convey("When generating report with a number of panels per page", t, func() {
+		conf := &config.Config{
+			TimeFormat:  time.UnixDate,
+			Location:    time.Now().Location(),
+			Layout:      "grid",
+			GridColumns: config.DefaultGridColumns,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		image := dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Panels: []dashboard.Panel{
+				{ID: "1", EncodedImage: image, GridPos: dashboard.GridPos{X: 0, Y: 0, W: 12, H: 8}},
+				{ID: "2", EncodedImage: image, GridPos: dashboard.GridPos{X: 12, Y: 0, W: 12, H: 8}},
+				{ID: "3", EncodedImage: image, GridPos: dashboard.GridPos{X: 0, Y: 8, W: 24, H: 8}},
+			},
+		}
+
+		Convey("All panels should be in a single grid by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<div class="grid">`), ShouldEqual, 1)
+			So(html.Body, ShouldContainSubstring, "grid-row: 9 / span 8;")
+		})
+
+		Convey("Page breaks should be added after every N panels in grid layout", func() {
+			conf.PanelsPerPage = 2
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<div class="grid">`), ShouldEqual, 2)
+
+			// Third panel must start at the top of its page after a page break
+			second := strings.LastIndex(html.Body, `<div class="grid">`)
+			So(html.Body[strings.Index(html.Body, `id="image2"`):second], ShouldContainSubstring, "break-after:page")
+			So(second, ShouldBeLessThan, strings.Index(html.Body, `id="image3"`))
+			So(html.Body, ShouldNotContainSubstring, "grid-row: 9 / span 8;")
+			So(strings.Count(html.Body, "grid-row: 1 / span 8;"), ShouldEqual, 3)
+		})
+
+		Convey("Panels should restart at the top of each page in simple layout", func() {
+			conf.Layout = "simple"
+			conf.PanelsPerPage = 1
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<div class="grid">`), ShouldEqual, 3)
+			So(strings.Count(html.Body, "grid-row: 5 / span 30;"), ShouldEqual, 3)
+		})
+	})
+}
+
+func TestHeaderTemplateFunctions(t *testing.T) {
+	Convey("When generating report with a custom header template", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.UTC,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			UID:   "randomUID",
+			URL:   "http://localhost:3000/d/randomUID/_?from=now-1h&to=now",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Dashboard URL should link to the dashboard", func() {
+			conf.HeaderTemplate = `<a href="{{ dashboardURL }}">{{ .Title }}</a>`
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Header, ShouldEqual, `<a href="http://localhost:3000/d/randomUID/_?from=now-1h&amp;to=now">My first dashboard</a>`)
+		})
+
+		Convey("Current time should be formatted with configured or given layout", func() {
+			conf.HeaderTemplate = `{{ formatTime now "2006" }}|{{ formatTime now }}`
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+
+			year, formatted, _ := strings.Cut(html.Header, "|")
+			So(year, ShouldEqual, strconv.Itoa(time.Now().UTC().Year()))
+			So(formatted, ShouldEndWith, "UTC "+year)
+		})
+	})
+}
+
+func TestFooterPageNumbers(t *testing.T) {
+	Convey("When generating footer of the report", t, func() {
+		conf := &config.Config{
+			TimeFormat:      time.UnixDate,
+			Location:        time.Now().Location(),
+			ShowPageNumbers: true,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Page numbers should be included when enabled", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Footer, ShouldContainSubstring, `<span class="pageNumber"></span>`)
+			So(html.Footer, ShouldContainSubstring, `<span class="totalPages"></span>`)
+		})
+
+		Convey("Page numbers should be omitted when disabled", func() {
+			conf.ShowPageNumbers = false
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Footer, ShouldNotContainSubstring, "pageNumber")
+		})
+
+		Convey("Page numbers should not be injected in custom footer", func() {
+			conf.FooterTemplate = `<div>My footer</div>`
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Footer, ShouldEqual, `<div>My footer</div>`)
+		})
+	})
+}
+
+func TestSectionSeparatorPage(t *testing.T) {
+	Convey("When generating report with sections", t, func() {
+		conf := &config.Config{
+			TimeFormat:           time.UnixDate,
+			Location:             time.Now().Location(),
+			IncludePanelIndex:    true,
+			VariableSummaryTable: true,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			VariableSummary: []dashboard.VariableValue{{Name: "host", Values: []string{"node1"}}},
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Graph", EncodedImage: dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}},
+				{ID: "2", Title: "Traffic table", CSVData: dashboard.CSVData{{"Host", "Value"}, {"node1", "1"}}},
+			},
+		}
+
+		Convey("Separator pages should not be added by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `class="section-separator"`)
+		})
+
+		Convey("Separator pages should be added between sections when enabled", func() {
+			conf.SectionSeparatorPage = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<div class="section-separator">`), ShouldEqual, 3)
+			So(html.Body, ShouldNotContainSubstring, "<h1>Traffic table</h1>")
+
+			// Separator must be between variables and panels sections
+			separator := strings.Index(html.Body, `<div class="section-separator">`)
+			So(separator, ShouldBeGreaterThan, strings.Index(html.Body, "<h2>Variables</h2>"))
+			So(separator, ShouldBeLessThan, strings.Index(html.Body, `id="image1"`))
+		})
+
+		Convey("Separator pages should show section titles when enabled", func() {
+			conf.SectionSeparatorPage = true
+			conf.SectionSeparatorTitle = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, "<h1>Panels</h1>")
+			So(html.Body, ShouldContainSubstring, "<h1>Traffic table</h1>")
+			So(html.Body, ShouldContainSubstring, "<h1>Panel index</h1>")
+		})
+	})
+}
+
+func TestCombinedPanels(t *testing.T) {
+	Convey("When generating report with combined panels", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.Now().Location(),
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		image := dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}
+		data := dashboard.CSVData{{"Host", "Requests"}, {"host1", "10"}}
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Overview", EncodedImage: image},
+				{ID: "2", Title: "Requests", EncodedImage: image, CSVData: data},
+				{ID: "3", Title: "Errors", CSVData: data},
+			},
+		}
+
+		Convey("Images and data should be rendered separately by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `id="combined2"`)
+			So(html.Body, ShouldContainSubstring, ".grid-image-1 {")
+			So(strings.Count(html.Body, "<table>"), ShouldEqual, 2)
+		})
+
+		Convey("Image and data of combined panels should be rendered together", func() {
+			conf.CombinedPanels = []string{"2", "3"}
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `id="image2"`), ShouldEqual, 1)
+			So(strings.Count(html.Body, "<table>"), ShouldEqual, 2)
+
+			// Panel must not be part of the grid
+			So(html.Body, ShouldNotContainSubstring, ".grid-image-1 {")
+			So(html.Body, ShouldNotContainSubstring, "grid-image-1\"")
+
+			// Image must be followed by the table of the panel
+			combined := html.Body[strings.Index(html.Body, `id="combined2"`):]
+			So(strings.Index(combined, `id="image2"`), ShouldBeLessThan, strings.Index(combined, "<td>host1</td>"))
+			So(strings.Index(combined, "<td>host1</td>"), ShouldBeLessThan, strings.Index(combined, "</div>"))
+
+			// Panels without image are rendered as usual
+			So(html.Body, ShouldNotContainSubstring, `id="combined3"`)
+			So(html.Body, ShouldContainSubstring, "<h2>Errors</h2>")
+		})
+
+		Convey("Images of combined panels should be rendered like the ones in the grid", func() {
+			conf.CombinedPanels = []string{"2"}
+			conf.PanelBackground = "#ffffff"
+			conf.GenerateOutline = true
+			dashData.Panels[1].LiveURL = "https://localhost:3000/d/abc/_?viewPanel=2"
+
+			html, err := rep.generateHTMLFile(&dashData)
+			So(err, ShouldBeNil)
+
+			combined := html.Body[strings.Index(html.Body, `id="combined2"`):]
+			combined = combined[:strings.Index(combined, "</figure>")]
+
+			So(combined, ShouldContainSubstring, `<h2 class="outline-heading">Requests</h2>`)
+			So(combined, ShouldContainSubstring, `<a href="https://localhost:3000/d/abc/_?viewPanel=2" class="panel-link">`)
+			So(combined, ShouldContainSubstring, `<div class="panel-background">`)
+		})
+	})
+}
+
+func TestOutline(t *testing.T) {
+	Convey("When generating report with outline", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.Now().Location(),
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		image := dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Panels: []dashboard.Panel{
+				{ID: "1", Title: "Overview", EncodedImage: image},
+				{ID: "2", Title: "CPU", Row: "Compute", EncodedImage: image},
+				{ID: "3", Title: "Hidden", Row: "Compute"},
+				{ID: "4", Title: "Memory", Row: "Compute", StatValue: "10 GB"},
+				{ID: "5", Title: "Requests", Row: "Network", EncodedImage: image},
+			},
+		}
+
+		Convey("Outline headings should not be added by default", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `class="outline-heading"`)
+		})
+
+		Convey("Headings should follow dashboard rows and panels when enabled", func() {
+			conf.GenerateOutline = true
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+
+			headings := regexp.MustCompile(`<h(\d) class="outline-heading">([^<]+)</h\d>`).FindAllStringSubmatch(html.Body, -1)
+
+			var outline []string
+			for _, h := range headings {
+				outline = append(outline, h[1]+":"+h[2])
+			}
+
+			So(outline, ShouldResemble, []string{
+				"1:My first dashboard",
+				"2:Overview",
+				"2:Compute",
+				"3:CPU",
+				"3:Memory",
+				"2:Network",
+				"3:Requests",
+			})
+		})
+	})
+}
+
+func TestGlossary(t *testing.T) {
+	Convey("When generating report with glossary", t, func() {
+		conf := &config.Config{
+			TimeFormat: time.UnixDate,
+			Location:   time.Now().Location(),
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Glossary should not be rendered without terms", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, `id="glossary"`)
+		})
+
+		Convey("Glossary should be rendered as sorted definition list", func() {
+			conf.Glossary = map[string]string{
+				"SLO":  "Service level **objective**",
+				"MTTR": "Mean time to recovery",
+			}
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `id="glossary"`)
+			So(html.Body, ShouldContainSubstring, "<dd>Service level <strong>objective</strong></dd>")
+			So(strings.Index(html.Body, "<dt>MTTR</dt>"), ShouldBeLessThan, strings.Index(html.Body, "<dt>SLO</dt>"))
+		})
+	})
+}
+
+func TestReportMetadata(t *testing.T) {
+	Convey("When generating report with custom metadata", t, func() {
+		conf := &config.Config{
+			TimeFormat:     time.UnixDate,
+			Location:       time.Now().Location(),
+			HeaderTemplate: `<div id="env">{{ .Metadata.environment }}</div>`,
+			FooterTemplate: `<div id="team">{{ index .Metadata "team" }}</div>`,
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+		}
+
+		Convey("Templates should render without metadata", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Header, ShouldContainSubstring, `<div id="env"></div>`)
+		})
+
+		Convey("Metadata should be accessible in header and footer templates", func() {
+			conf.ReportMetadata = map[string]string{
+				"environment": "production",
+				"team":        "SRE <ops>",
+			}
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Header, ShouldContainSubstring, `<div id="env">production</div>`)
+			So(html.Footer, ShouldContainSubstring, `<div id="team">SRE &lt;ops&gt;</div>`)
+		})
+	})
+}
+
+func TestGroupHeadings(t *testing.T) {
+	Convey("When generating report with panels grouped by title prefix", t, func() {
+		conf := &config.Config{
+			TimeFormat:         time.UnixDate,
+			Location:           time.Now().Location(),
+			Layout:             "grid",
+			GroupByTitlePrefix: ":",
+		}
+
+		rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+		image := dashboard.PanelImage{Image: "iVBORw0KGgo", MimeType: "image/png"}
+		panels := groupPanels([]dashboard.Panel{
+			{ID: "1", Title: "DB: Connections", EncodedImage: image, GridPos: dashboard.GridPos{W: 12, H: 8}},
+			{ID: "2", Title: "Web: Requests", EncodedImage: image, GridPos: dashboard.GridPos{X: 12, W: 12, H: 8}},
+			{ID: "3", Title: "DB: Queries", EncodedImage: image, GridPos: dashboard.GridPos{Y: 8, W: 12, H: 8}},
+			{ID: "4", Title: "Uptime", StatValue: "99 %", GridPos: dashboard.GridPos{Y: 16, W: 12, H: 4}},
+		}, ":", true)
+
+		dashData := dashboard.Data{
+			Title: "My first dashboard",
+			TimeRange: dashboard.TimeRange{
+				From: "1734194455000",
+				To:   "1734194465000",
+			},
+			Panels: panels,
+		}
+
+		Convey("Each group should have a heading before its panels in grid layout", func() {
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<h2 class="group-heading group-heading-`), ShouldEqual, 2)
+			So(html.Body, ShouldContainSubstring, `<h2 class="group-heading group-heading-0">DB</h2>`)
+			So(html.Body, ShouldContainSubstring, `<h2 class="group-heading group-heading-2">Web</h2>`)
+			So(html.Body, ShouldContainSubstring, ".group-heading-2 {\n        grid-column: 1 / -1;\n        grid-row: 18 / span 1;")
+
+			// Headings precede panels of their group
+			So(strings.Index(html.Body, ">DB</h2>"), ShouldBeLessThan, strings.Index(html.Body, `id="image1"`))
+			So(strings.Index(html.Body, `id="image3"`), ShouldBeLessThan, strings.Index(html.Body, ">Web</h2>"))
+		})
+
+		Convey("Headings should be rendered inside first panel of group in simple layout", func() {
+			conf.Layout = "simple"
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(strings.Count(html.Body, `<h2 class="group-heading">`), ShouldEqual, 2)
+			So(html.Body, ShouldNotContainSubstring, "group-heading-")
+		})
+	})
+}
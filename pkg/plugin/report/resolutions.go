@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// GenerateResolutions generates a report of the dashboard for each of the given
+// device scale factors and writes them as a ZIP archive. The dashboard must share
+// the config of the report so that panels are rendered at each scale.
+func (r *Report) GenerateResolutions(ctx context.Context, writer http.ResponseWriter, scales []float64) error {
+	defer helpers.TimeTrack(time.Now(), "multi resolution report generation", r.logger)
+
+	var (
+		buf   bytes.Buffer
+		title string
+	)
+
+	err := writeResolutions(&buf, r.conf, scales, func(w io.Writer) (string, error) {
+		htmlReport, dashboardData, err := r.generateHTMLReport(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if err := r.writePDF(ctx, htmlReport, dashboardData, w); err != nil {
+			return "", err
+		}
+
+		title = dashboardData.Title
+
+		return title, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", contentDisposition(sanitizeFilename(title, r.conf.FilenamePolicy)+".zip"))
+
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ZIP archive: %w", err)
+	}
+
+	return nil
+}
+
+// writeResolutions sets device scale factor of config to each of the scales
+// in turn, renders the report using render and adds it to a ZIP archive written
+// to writer. render returns the title of the dashboard used to name the file.
+// Device scale factor of config is restored once done.
+func writeResolutions(writer io.Writer, conf *config.Config, scales []float64, render func(io.Writer) (string, error)) error {
+	defer func(scale float64) { conf.DeviceScaleFactor = scale }(conf.DeviceScaleFactor)
+
+	archive := newZipWriter(writer, conf.CompressionLevel)
+
+	for _, scale := range scales {
+		conf.DeviceScaleFactor = scale
+
+		var buf bytes.Buffer
+
+		title, err := render(&buf)
+		if err != nil {
+			return fmt.Errorf("failed to generate report at scale %v: %w", scale, err)
+		}
+
+		f, err := archive.Create(resolutionFilename(title, scale, conf))
+		if err != nil {
+			return fmt.Errorf("failed to add report at scale %v to ZIP archive: %w", scale, err)
+		}
+
+		if _, err := f.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to add report at scale %v to ZIP archive: %w", scale, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close ZIP archive: %w", err)
+	}
+
+	return nil
+}
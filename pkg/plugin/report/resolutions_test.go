@@ -0,0 +1,70 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteResolutions(t *testing.T) {
+	Convey("When writing reports at multiple resolutions", t, func() {
+		conf := &config.Config{DeviceScaleFactor: 1.5}
+
+		// Fake renderer writes the scale at which report is rendered
+		render := func(w io.Writer) (string, error) {
+			_, err := fmt.Fprintf(w, "scale=%v", conf.DeviceScaleFactor)
+
+			return "My dashboard", err
+		}
+
+		Convey("A report should be produced for each scale", func() {
+			var buf bytes.Buffer
+
+			err := writeResolutions(&buf, conf, []float64{1, 2}, render)
+			So(err, ShouldBeNil)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			So(err, ShouldBeNil)
+			So(archive.File, ShouldHaveLength, 2)
+
+			contents := make(map[string]string)
+
+			for _, f := range archive.File {
+				r, err := f.Open()
+				So(err, ShouldBeNil)
+
+				b, err := io.ReadAll(r)
+				So(err, ShouldBeNil)
+
+				contents[f.Name] = string(b)
+			}
+
+			So(contents, ShouldResemble, map[string]string{
+				"My dashboard@1x.pdf": "scale=1",
+				"My dashboard@2x.pdf": "scale=2",
+			})
+		})
+
+		Convey("Device scale factor of config should be restored", func() {
+			err := writeResolutions(io.Discard, conf, []float64{1, 2}, render)
+
+			So(err, ShouldBeNil)
+			So(conf.DeviceScaleFactor, ShouldEqual, 1.5)
+		})
+
+		Convey("Render errors should be returned", func() {
+			err := writeResolutions(io.Discard, conf, []float64{1, 2}, func(io.Writer) (string, error) {
+				return "", errors.New("render failed")
+			})
+
+			So(err, ShouldNotBeNil)
+			So(conf.DeviceScaleFactor, ShouldEqual, 1.5)
+		})
+	})
+}
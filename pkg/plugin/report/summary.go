@@ -0,0 +1,66 @@
+package report
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
+)
+
+// executiveSummary returns the KPIs of all stat panels of the dashboard in the
+// order they appear on the dashboard. Data of stat panels is fetched using
+// browser worker pool.
+func (r *Report) executiveSummary(ctx context.Context, panels []dashboard.Panel) []dashboard.KPI {
+	data := make([]dashboard.CSVData, len(panels))
+
+	wg := sync.WaitGroup{}
+
+	for idx, panel := range panels {
+		if !panel.IsStat() {
+			continue
+		}
+
+		wg.Add(1)
+
+		r.pools[worker.Browser].Do(func() {
+			defer wg.Done()
+
+			panelData, err := r.dashboard.PanelCSV(ctx, panel)
+			if err != nil {
+				r.logger.Debug("failed to fetch data of stat panel for summary", "panel_id", panel.ID, "err", err)
+
+				return
+			}
+
+			data[idx] = panelData
+		})
+	}
+
+	wg.Wait()
+
+	return summaryKPIs(panels, data, r.conf.StatNumberFormat, r.conf.ApplyFieldOverrides)
+}
+
+// summaryKPIs returns the KPIs of stat panels from their data. data must be
+// indexed like panels. Values are formatted using format and unit of the
+// panel taking its field overrides into account when overrides is true. Panels
+// without a value are skipped.
+func summaryKPIs(panels []dashboard.Panel, data []dashboard.CSVData, format string, overrides bool) []dashboard.KPI {
+	var kpis []dashboard.KPI
+
+	for idx, panel := range panels {
+		if !panel.IsStat() || idx >= len(data) {
+			continue
+		}
+
+		value, err := statValue(data[idx], format, statUnit(panel, data[idx], overrides))
+		if err != nil {
+			continue
+		}
+
+		kpis = append(kpis, dashboard.KPI{Title: panel.Title, Value: value})
+	}
+
+	return kpis
+}
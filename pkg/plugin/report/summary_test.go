@@ -0,0 +1,96 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/chrome"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExecutiveSummary(t *testing.T) {
+	Convey("When extracting KPIs of stat panels", t, func() {
+		panels := []dashboard.Panel{
+			{ID: "1", Type: "stat", Title: "Uptime", Unit: "percent"},
+			{ID: "2", Type: "timeseries", Title: "CPU"},
+			{ID: "3", Type: "gauge", Title: "Memory", Unit: "bytes"},
+			{ID: "4", Type: "stat", Title: "Empty"},
+		}
+
+		data := []dashboard.CSVData{
+			{{"Time", "Value"}, {"1", "99.95"}},
+			{{"Time", "Value"}, {"1", "10"}},
+			{{"Time", "Value"}, {"1", "2048"}},
+			{{"Time", "Value"}},
+		}
+
+		Convey("Only stat panels with values should be included", func() {
+			kpis := summaryKPIs(panels, data, "", false)
+
+			So(kpis, ShouldResemble, []dashboard.KPI{
+				{Title: "Uptime", Value: "99.95%"},
+				{Title: "Memory", Value: "2 KiB"},
+			})
+		})
+
+		Convey("Unit overrides of value field should be used when enabled", func() {
+			panels[2].Overrides = []dashboard.FieldOverride{{Matcher: "byName", Pattern: "Value", Unit: "decbytes"}}
+
+			So(summaryKPIs(panels, data, "", false)[1].Value, ShouldEqual, "2 KiB")
+			So(summaryKPIs(panels, data, "", true)[1].Value, ShouldEqual, "2.05 kB")
+		})
+
+		Convey("KPIs should be rendered at the top of the report", func() {
+			conf := &config.Config{
+				TimeFormat:       time.UnixDate,
+				Location:         time.Now().Location(),
+				ExecutiveSummary: true,
+			}
+
+			rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+			dashData := dashboard.Data{
+				Title:   "My first dashboard",
+				Summary: summaryKPIs(panels, data, "", false),
+				TimeRange: dashboard.TimeRange{
+					From: "1734194455000",
+					To:   "1734194465000",
+				},
+			}
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldContainSubstring, `<div class="kpi-grid">`)
+			So(html.Body, ShouldContainSubstring, `<div class="kpi-value">99.95%</div>
+                <div class="kpi-title">Uptime</div>`)
+			So(html.Body, ShouldContainSubstring, `<div class="kpi-title">Memory</div>`)
+		})
+
+		Convey("Summary should be omitted when disabled", func() {
+			conf := &config.Config{
+				TimeFormat: time.UnixDate,
+				Location:   time.Now().Location(),
+			}
+
+			rep := New(logger, conf, nil, &chrome.LocalInstance{}, worker.Pools{}, &dashboard.Dashboard{})
+
+			dashData := dashboard.Data{
+				Title:   "My first dashboard",
+				Summary: summaryKPIs(panels, data, "", false),
+				TimeRange: dashboard.TimeRange{
+					From: "1734194455000",
+					To:   "1734194465000",
+				},
+			}
+
+			html, err := rep.generateHTMLFile(&dashData)
+
+			So(err, ShouldBeNil)
+			So(html.Body, ShouldNotContainSubstring, "kpi-grid")
+		})
+	})
+}
@@ -1,7 +1,12 @@
 package report
 
 import (
+	"fmt"
+	"html/template"
+	"maps"
+	"math"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -18,6 +23,9 @@ type Report struct {
 	chromeInstance chrome.Instance
 	pools          worker.Pools
 	dashboard      *dashboard.Dashboard
+
+	// Number of concurrent fetches of panels can be limited per datasource type
+	dsLimiter datasourceLimiter
 }
 
 type HTML struct {
@@ -31,6 +39,60 @@ type templateData struct {
 	Date      string
 	Dashboard *dashboard.Data
 	Conf      *config.Config
+
+	// Pages of panels in grid set by paginate
+	pages     [][]int
+	pageTops  []float64
+	positions map[int]pagePosition
+}
+
+// pagePosition represents the page of a panel and its position in the page.
+type pagePosition struct {
+	page int
+	pos  int
+}
+
+// glossaryEntry represents a term and its definition in the glossary.
+type glossaryEntry struct {
+	Term       string
+	Definition template.HTML
+}
+
+// Glossary returns glossary entries sorted by their terms.
+func (t templateData) Glossary() []glossaryEntry {
+	entries := make([]glossaryEntry, 0, len(t.Conf.Glossary))
+
+	for _, term := range slices.Sorted(maps.Keys(t.Conf.Glossary)) {
+		entries = append(entries, glossaryEntry{
+			Term:       term,
+			Definition: formatText(t.Conf.Glossary[term]),
+		})
+	}
+
+	return entries
+}
+
+// Metadata returns the custom metadata of the report like environment or team
+// that can be used in templates as {{ .Metadata.environment }}.
+func (t templateData) Metadata() map[string]string {
+	return t.Conf.ReportMetadata
+}
+
+// section represents a section of the report used in separator pages.
+type section struct {
+	Title     string
+	Enabled   bool
+	ShowTitle bool
+}
+
+// Section returns the section with title that is used to render separator before
+// the section.
+func (t templateData) Section(title string) section {
+	return section{
+		Title:     title,
+		Enabled:   t.Conf.SectionSeparatorPage,
+		ShowTitle: t.Conf.SectionSeparatorTitle,
+	}
 }
 
 // IsGridLayout returns true if layout config is grid.
@@ -38,14 +100,57 @@ func (t templateData) IsGridLayout() bool {
 	return t.Conf.Layout == "grid"
 }
 
+// GridColumns returns number of columns of grid layout.
+func (t templateData) GridColumns() int {
+	if t.Conf.GridColumns <= 0 {
+		return config.DefaultGridColumns
+	}
+
+	return t.Conf.GridColumns
+}
+
+// PanelBorder returns CSS border of panels.
+func (t templateData) PanelBorder() string {
+	if t.Conf.PanelBorderWidth <= 0 {
+		return ""
+	}
+
+	color := t.Conf.PanelBorderColor
+	if color == "" {
+		color = "#CCC"
+	}
+
+	return fmt.Sprintf("%dpx solid %s", t.Conf.PanelBorderWidth, color)
+}
+
+// PageMargin returns CSS margin of pages.
+func (t templateData) PageMargin() string {
+	top, right, bottom, left := pageMargins(t.Conf)
+
+	return fmt.Sprintf("%gin %gin %gin %gin", top, right, bottom, left)
+}
+
+// CoverHeight returns CSS height of the cover page which fills the printable
+// area of the first page in the configured orientation.
+func (t templateData) CoverHeight() string {
+	width, height := t.Conf.PaperDimensions()
+	if t.Conf.Orientation == "landscape" {
+		height = width
+	}
+
+	top, _, bottom, _ := pageMargins(t.Conf)
+
+	return fmt.Sprintf("%gin", math.Round((height-top-bottom)*100)/100)
+}
+
 // From returns from time string.
 func (t templateData) From() string {
-	return t.Dashboard.TimeRange.FromFormatted(t.Conf.Location, t.Conf.TimeFormat)
+	return t.Dashboard.TimeRange.FromFormatted(t.Conf.Location, t.Conf.TimeFormat, t.Conf.ShowTimeZoneInLabels, t.Conf.WeekStart, t.Conf.FiscalYearStart())
 }
 
 // To returns to time string.
 func (t templateData) To() string {
-	return t.Dashboard.TimeRange.ToFormatted(t.Conf.Location, t.Conf.TimeFormat)
+	return t.Dashboard.TimeRange.ToFormatted(t.Conf.Location, t.Conf.TimeFormat, t.Conf.ShowTimeZoneInLabels, t.Conf.WeekStart, t.Conf.FiscalYearStart())
 }
 
 // Logo returns encoded logo.
@@ -73,3 +178,320 @@ func (t templateData) Title() string {
 func (t templateData) VariableValues() string {
 	return t.Dashboard.Variables
 }
+
+// VariableSummary returns dashboards template variables and their values when
+// variable summary table is enabled.
+func (t templateData) VariableSummary() []dashboard.VariableValue {
+	if !t.Conf.VariableSummaryTable {
+		return nil
+	}
+
+	return t.Dashboard.VariableSummary
+}
+
+// Summary returns KPIs of stat panels when executive summary is enabled.
+func (t templateData) Summary() []dashboard.KPI {
+	if !t.Conf.ExecutiveSummary {
+		return nil
+	}
+
+	return t.Dashboard.Summary
+}
+
+// outlineEntry represents the headings of a panel in the outline of the report.
+type outlineEntry struct {
+	Row   string
+	Title string
+	// Heading level of panel title
+	Level int
+}
+
+// Outline returns the outline headings of the rendered panel at index i when outline
+// is enabled. Row is only set for the first panel of each dashboard row so that panels
+// are nested under their row in the outline.
+func (t templateData) Outline(i int) *outlineEntry {
+	if !t.Conf.GenerateOutline || i < 0 || i >= len(t.Dashboard.Panels) {
+		return nil
+	}
+
+	panel := t.Dashboard.Panels[i]
+	entry := &outlineEntry{Title: panel.Title, Level: 2}
+
+	if panel.Row == "" {
+		return entry
+	}
+
+	entry.Level = 3
+
+	// Row of the previous rendered panel
+	var previous *dashboard.Panel
+
+	for j := i - 1; j >= 0; j-- {
+		if p := t.Dashboard.Panels[j]; p.EncodedImage.Image != "" || p.StatValue != "" {
+			previous = &t.Dashboard.Panels[j]
+
+			break
+		}
+	}
+
+	if previous == nil || previous.Row != panel.Row {
+		entry.Row = panel.Row
+	}
+
+	return entry
+}
+
+// groupHeading represents the heading of a group of panels in the report.
+type groupHeading struct {
+	Title string
+	// Grid row of heading in grid layout
+	Row float64
+	// Heading is rendered inside the first panel of group in simple layout
+	Inline bool
+}
+
+// GroupHeading returns the heading of the group when panel at index i is the
+// first rendered panel of its group.
+func (t templateData) GroupHeading(i int) *groupHeading {
+	if t.Conf.GroupByTitlePrefix == "" || i < 0 || i >= len(t.Dashboard.Panels) {
+		return nil
+	}
+
+	panel := t.Dashboard.Panels[i]
+	if panel.Group == "" || !t.inGrid(panel) {
+		return nil
+	}
+
+	for j := i - 1; j >= 0; j-- {
+		if p := t.Dashboard.Panels[j]; t.inGrid(p) {
+			if p.Group == panel.Group {
+				return nil
+			}
+
+			break
+		}
+	}
+
+	heading := &groupHeading{Title: panel.Group, Inline: !t.IsGridLayout()}
+
+	// Heading is in the row above the top most panel of the group
+	heading.Row = panel.GridPos.Y
+
+	for _, p := range t.Dashboard.Panels {
+		if p.Group == panel.Group {
+			heading.Row = min(heading.Row, p.GridPos.Y)
+		}
+	}
+
+	heading.Row--
+
+	return heading
+}
+
+// PanelPages returns the indexes of panels rendered in the grid split into pages
+// of PanelsPerPage panels. All panels are on a single page when it is not set.
+func (t templateData) PanelPages() [][]int {
+	return t.pages
+}
+
+// paginate splits panels rendered in the grid into pages and computes the page
+// and position of each panel along with the top most grid row of each page. It
+// must be called before executing templates so that panels can be looked up in
+// their pages without scanning all the panels.
+func (t *templateData) paginate() {
+	var (
+		pages [][]int
+		page  []int
+	)
+
+	for i, p := range t.Dashboard.Panels {
+		if !t.inGrid(p) {
+			continue
+		}
+
+		page = append(page, i)
+
+		if t.Conf.PanelsPerPage > 0 && len(page) == t.Conf.PanelsPerPage {
+			pages = append(pages, page)
+			page = nil
+		}
+	}
+
+	if len(page) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+
+	t.pages = pages
+	t.pageTops = make([]float64, len(pages))
+	t.positions = make(map[int]pagePosition)
+
+	for ipage, page := range pages {
+		top := math.Inf(1)
+
+		for pos, j := range page {
+			t.positions[j] = pagePosition{ipage, pos}
+
+			top = min(top, t.Dashboard.Panels[j].GridPos.Y)
+
+			if heading := t.GroupHeading(j); heading != nil {
+				top = min(top, heading.Row)
+			}
+		}
+
+		t.pageTops[ipage] = top
+	}
+}
+
+// PagePosition returns the position of panel at index i in its page.
+func (t templateData) PagePosition(i int) int {
+	return t.positions[i].pos
+}
+
+// GridRow returns the CSS grid line of the dashboard grid row of panel at index i.
+// As each page has its own grid, rows are counted from the top of the page.
+func (t templateData) GridRow(i int, row float64) float64 {
+	if t.Conf.PanelsPerPage == 0 {
+		return row + 1
+	}
+
+	top := row
+
+	if position, ok := t.positions[i]; ok {
+		top = min(top, t.pageTops[position.page])
+	}
+
+	return row - top + 1
+}
+
+// inGrid returns true when panel is rendered in the grid of panels.
+func (t templateData) inGrid(p dashboard.Panel) bool {
+	return (p.EncodedImage.Image != "" || p.StatValue != "" || p.ImageFailed) && !t.Combined(p)
+}
+
+// panelTable represents the tabular data of a panel in the report.
+type panelTable struct {
+	Data        dashboard.CSVData
+	ColumnStats bool
+}
+
+// panelFigure represents the figure of a rendered panel image.
+type panelFigure struct {
+	Index        int
+	Panel        dashboard.Panel
+	Outline      *outlineEntry
+	GroupHeading *groupHeading
+	// Figure is placed in the grid of panels
+	Grid            bool
+	PanelBackground bool
+}
+
+// Figure returns the figure of the rendered panel image at index i. Figures of
+// panels in the grid and of combined panels share the same markup.
+func (t templateData) Figure(i int) panelFigure {
+	panel := t.Dashboard.Panels[i]
+
+	return panelFigure{
+		Index:           i,
+		Panel:           panel,
+		Outline:         t.Outline(i),
+		GroupHeading:    t.GroupHeading(i),
+		Grid:            t.inGrid(panel),
+		PanelBackground: t.Conf.PanelBackground != "",
+	}
+}
+
+// Table returns the table of panel's data.
+func (t templateData) Table(p dashboard.Panel) panelTable {
+	return panelTable{Data: p.CSVData, ColumnStats: t.Conf.TableColumnStats}
+}
+
+// Combined returns true when panel's image and data are rendered together. Panels
+// that are missing either of them are rendered as usual.
+func (t templateData) Combined(p dashboard.Panel) bool {
+	if len(p.CSVData) == 0 || (p.EncodedImage.Image == "" && p.StatValue == "") {
+		return false
+	}
+
+	return len(selectPanels([]dashboard.Panel{p}, t.Conf.CombinedPanels, nil, false)) > 0
+}
+
+// tocEntry represents an entry of the table of contents linking to a panel.
+type tocEntry struct {
+	Title  string
+	Anchor string
+}
+
+// TableOfContents returns the entries of table of contents when it is enabled.
+// Each panel is listed once in the order it appears in the report and links to
+// the element rendering it. Panels without title are labelled by their ID.
+func (t templateData) TableOfContents() []tocEntry {
+	if !t.Conf.TableOfContents {
+		return nil
+	}
+
+	var entries []tocEntry
+
+	listed := make(map[string]bool)
+
+	add := func(p dashboard.Panel, anchor string) {
+		title := p.Title
+		if title == "" {
+			title = "Panel " + p.ID
+		}
+
+		entries = append(entries, tocEntry{Title: title, Anchor: anchor + p.ID})
+		listed[p.ID] = true
+	}
+
+	// Panels in the grid
+	for _, p := range t.Dashboard.Panels {
+		if !t.inGrid(p) {
+			continue
+		}
+
+		switch {
+		case p.StatValue != "":
+			add(p, "stat")
+		case p.EncodedImage.Image != "":
+			add(p, "image")
+		default:
+			add(p, "placeholder")
+		}
+	}
+
+	// Panels rendered with their data after the grid
+	for _, p := range t.Dashboard.Panels {
+		if listed[p.ID] {
+			continue
+		}
+
+		switch {
+		case t.Combined(p):
+			add(p, "combined")
+		case len(p.CSVData) > 0:
+			add(p, "table")
+		case p.DataFailed:
+			add(p, "placeholder")
+		}
+	}
+
+	return entries
+}
+
+// PanelIndex returns rendered panels of the dashboard in the order they appear
+// in the report when panel index is enabled.
+func (t templateData) PanelIndex() []dashboard.Panel {
+	if !t.Conf.IncludePanelIndex {
+		return nil
+	}
+
+	var panels []dashboard.Panel
+
+	for _, p := range t.Dashboard.Panels {
+		if p.EncodedImage.Image != "" || p.StatValue != "" {
+			panels = append(panels, p)
+		}
+	}
+
+	return panels
+}
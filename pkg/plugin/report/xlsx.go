@@ -0,0 +1,254 @@
+package report
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
+)
+
+// maxSheetNameLength is the maximum number of characters in sheet names of
+// XLSX workbooks.
+const maxSheetNameLength = 31
+
+// Namespaces of XLSX parts.
+const (
+	xlsxMainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPkgNS  = "http://schemas.openxmlformats.org/package/2006/relationships"
+	xlsxTypeNS = "http://schemas.openxmlformats.org/package/2006/content-types"
+)
+
+// xlsxStyles defines the default cell style and a bold one for headers.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="` + xlsxMainNS + `">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// Characters that are not allowed in sheet names.
+var sheetNameReplacer = strings.NewReplacer(":", "_", `\`, "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_")
+
+// GenerateXLSX writes data of table panels of the dashboard as a XLSX workbook
+// with one sheet per panel. When no panels are selected using IncludePanelDataIDs,
+// data of all table panels is exported. Workbook is built in memory before writing
+// the response so that errors do not result in a truncated workbook.
+func (r *Report) GenerateXLSX(ctx context.Context, writer http.ResponseWriter) error {
+	defer helpers.TimeTrack(time.Now(), "XLSX generation", r.logger)
+
+	dashboardData, tablePanels, err := r.tablePanelsData(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	workbook := newXLSXWriter(&buf, r.conf.CompressionLevel)
+
+	for _, idx := range tablePanels {
+		panel := dashboardData.Panels[idx]
+
+		if err := workbook.addSheet(panel.Title, panel.ID, panel.CSVData); err != nil {
+			return fmt.Errorf("failed to write data of panel %s: %w", panel.ID, err)
+		}
+	}
+
+	if err := workbook.Close(); err != nil {
+		return fmt.Errorf("failed to write XLSX workbook: %w", err)
+	}
+
+	writer.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	writer.Header().Set("Content-Disposition", contentDisposition(sanitizeFilename(dashboardData.Title, r.conf.FilenamePolicy)+".xlsx"))
+
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write XLSX workbook: %w", err)
+	}
+
+	return nil
+}
+
+// xlsxWriter writes a XLSX workbook. Sheets are compressed into the workbook as
+// they are added.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheets  []string
+}
+
+// newXLSXWriter returns a new XLSX workbook writing to w compressed with
+// compressionLevel.
+func newXLSXWriter(w io.Writer, compressionLevel int) *xlsxWriter {
+	return &xlsxWriter{archive: newZipWriter(w, compressionLevel)}
+}
+
+// addSheet adds a sheet with data to the workbook. Sheet is named after title,
+// or the ID of the panel when title is empty. First row of data is the header
+// and it is written in bold. Numeric cells are written as numbers.
+func (x *xlsxWriter) addSheet(title, panelID string, data dashboard.CSVData) error {
+	name := x.sheetName(title, panelID)
+
+	f, err := x.archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)+1))
+	if err != nil {
+		return err
+	}
+
+	x.sheets = append(x.sheets, name)
+
+	w := bufio.NewWriter(f)
+
+	w.WriteString(xml.Header + `<worksheet xmlns="` + xlsxMainNS + `"><sheetData>`)
+
+	for irow, row := range data {
+		fmt.Fprintf(w, `<row r="%d">`, irow+1)
+
+		for icol, value := range row {
+			ref := xlsxColumn(icol) + strconv.Itoa(irow+1)
+
+			switch {
+			case irow == 0:
+				fmt.Fprintf(w, `<c r="%s" t="inlineStr" s="1"><is>`, ref)
+				writeXLSXText(w, value)
+				w.WriteString(`</is></c>`)
+			case jsonNumberRegex.MatchString(value):
+				fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, value)
+			case value != "":
+				fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is>`, ref)
+				writeXLSXText(w, value)
+				w.WriteString(`</is></c>`)
+			}
+		}
+
+		w.WriteString(`</row>`)
+	}
+
+	w.WriteString(`</sheetData></worksheet>`)
+
+	return w.Flush()
+}
+
+// sheetName returns a unique valid sheet name made from title.
+func (x *xlsxWriter) sheetName(title, panelID string) string {
+	base := strings.TrimSpace(sheetNameReplacer.Replace(title))
+	base = strings.Trim(base, "'")
+
+	if base == "" {
+		base = "Panel " + panelID
+	}
+
+	name := truncateRunes(base, maxSheetNameLength)
+
+	for i := 2; x.hasSheet(name); i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = truncateRunes(base, maxSheetNameLength-len(suffix)) + suffix
+	}
+
+	return name
+}
+
+// hasSheet returns true if workbook has a sheet with name. Sheet names are
+// case insensitive.
+func (x *xlsxWriter) hasSheet(name string) bool {
+	for _, s := range x.sheets {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Close writes the workbook parts and closes the archive. Workbooks must have at
+// least one sheet and hence, an empty sheet is added when there are none.
+func (x *xlsxWriter) Close() error {
+	if len(x.sheets) == 0 {
+		if err := x.addSheet("Sheet1", "", nil); err != nil {
+			return err
+		}
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+
+	contentTypes.WriteString(xml.Header + `<Types xmlns="` + xlsxTypeNS + `">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="` + xlsxMainNS + `" xmlns:r="` + xlsxRelNS + `"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="` + xlsxPkgNS + `">`)
+
+	for i, name := range x.sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+
+		workbook.WriteString(`<sheet name="`)
+		xml.EscapeText(&workbook, []byte(name)) //nolint:errcheck
+		fmt.Fprintf(&workbook, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, xlsxRelNS, i+1)
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/></Relationships>`, len(x.sheets)+1, xlsxRelNS)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="` + xlsxPkgNS + `">` +
+			`<Relationship Id="rId1" Type="` + xlsxRelNS + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, part := range parts {
+		if err := addZipFile(x.archive, part.name, []byte(part.content)); err != nil {
+			return err
+		}
+	}
+
+	return x.archive.Close()
+}
+
+// writeXLSXText writes inline string value of a cell.
+func writeXLSXText(w *bufio.Writer, value string) {
+	if strings.TrimSpace(value) != value {
+		w.WriteString(`<t xml:space="preserve">`)
+	} else {
+		w.WriteString(`<t>`)
+	}
+
+	xml.EscapeText(w, []byte(value)) //nolint:errcheck
+	w.WriteString(`</t>`)
+}
+
+// xlsxColumn returns the name of column at zero based index i, like A, Z or AA.
+func xlsxColumn(i int) string {
+	var name []byte
+
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+
+	return string(name)
+}
+
+// truncateRunes returns the first n characters of s.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	Convey("When writing panel data as XLSX workbook", t, func() {
+		var buf bytes.Buffer
+
+		workbook := newXLSXWriter(&buf, flate.DefaultCompression)
+
+		So(workbook.addSheet("CPU usage", "1", dashboard.CSVData{
+			{"Time", "Host", "Value"},
+			{"2024-12-14 10:00:00", "node1", "12.5"},
+			{"2024-12-14 10:01:00", "007", "-3e2"},
+			{"2024-12-14 10:02:00", "a < b & c", ""},
+		}), ShouldBeNil)
+		So(workbook.addSheet("Memory: used/free [bytes] per host and per instance", "2", nil), ShouldBeNil)
+		So(workbook.addSheet("cpu USAGE", "3", nil), ShouldBeNil)
+		So(workbook.addSheet("", "4", nil), ShouldBeNil)
+		So(workbook.Close(), ShouldBeNil)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		So(err, ShouldBeNil)
+
+		files := make(map[string]string)
+
+		for _, f := range archive.File {
+			r, err := f.Open()
+			So(err, ShouldBeNil)
+
+			b, err := io.ReadAll(r)
+			So(err, ShouldBeNil)
+
+			r.Close()
+
+			files[f.Name] = string(b)
+		}
+
+		Convey("Workbook should contain all parts", func() {
+			for _, name := range []string{
+				"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels",
+				"xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet4.xml",
+			} {
+				So(files, ShouldContainKey, name)
+			}
+
+			for _, content := range files {
+				So(strings.HasPrefix(content, xml.Header), ShouldBeTrue)
+				So(xml.Unmarshal([]byte(content), new(any)), ShouldBeNil)
+			}
+		})
+
+		Convey("Sheets should be named after panel titles", func() {
+			So(workbook.sheets, ShouldResemble, []string{
+				"CPU usage", "Memory_ used_free _bytes_ per h", "cpu USAGE (2)", "Panel 4",
+			})
+			So(files["xl/workbook.xml"], ShouldContainSubstring, `<sheet name="cpu USAGE (2)" sheetId="3" r:id="rId3"/>`)
+		})
+
+		Convey("Header should be bold and numbers should be written as numbers", func() {
+			sheet := files["xl/worksheets/sheet1.xml"]
+
+			So(sheet, ShouldContainSubstring, `<c r="A1" t="inlineStr" s="1"><is><t>Time</t></is></c>`)
+			So(sheet, ShouldContainSubstring, `<c r="C2"><v>12.5</v></c>`)
+			So(sheet, ShouldContainSubstring, `<c r="C3"><v>-3e2</v></c>`)
+			So(sheet, ShouldContainSubstring, `<c r="B3" t="inlineStr"><is><t>007</t></is></c>`)
+			So(sheet, ShouldContainSubstring, `<t>a &lt; b &amp; c</t>`)
+			So(sheet, ShouldNotContainSubstring, `r="C4"`)
+		})
+	})
+
+	Convey("When writing a workbook without data", t, func() {
+		var buf bytes.Buffer
+
+		workbook := newXLSXWriter(&buf, flate.DefaultCompression)
+		So(workbook.Close(), ShouldBeNil)
+
+		Convey("An empty sheet should be added", func() {
+			So(workbook.sheets, ShouldResemble, []string{"Sheet1"})
+		})
+	})
+
+	Convey("When writing a workbook without compression", t, func() {
+		var buf bytes.Buffer
+
+		workbook := newXLSXWriter(&buf, flate.NoCompression)
+		So(workbook.addSheet("CPU usage", "1", dashboard.CSVData{{"Time", "Value"}, {"2024-12-14 10:00:00", "12.5"}}), ShouldBeNil)
+		So(workbook.Close(), ShouldBeNil)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		So(err, ShouldBeNil)
+
+		Convey("Parts of workbook should be stored as is", func() {
+			for _, f := range archive.File {
+				So(f.CompressedSize64, ShouldBeGreaterThanOrEqualTo, f.UncompressedSize64)
+			}
+		})
+	})
+}
+
+func TestXLSXColumn(t *testing.T) {
+	Convey("When naming XLSX columns", t, func() {
+		So(xlsxColumn(0), ShouldEqual, "A")
+		So(xlsxColumn(25), ShouldEqual, "Z")
+		So(xlsxColumn(26), ShouldEqual, "AA")
+		So(xlsxColumn(701), ShouldEqual, "ZZ")
+		So(xlsxColumn(702), ShouldEqual, "AAA")
+	})
+}
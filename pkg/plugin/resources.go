@@ -1,14 +1,20 @@
 package plugin
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -17,6 +23,7 @@ import (
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/helpers"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/report"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
 )
 
 // GrafanaUserSignInTokenHeaderName the header name used for forwarding
@@ -24,6 +31,19 @@ import (
 // Requires idForwarded feature toggle enabled.
 const GrafanaUserSignInTokenHeaderName = "X-Grafana-Id" //nolint:gosec
 
+var (
+	errResourceNotFound  = errors.New("resource not found")
+	errInvalidResolution = errors.New("invalid resolution")
+	errInvalidLogLevel   = errors.New("invalid log level")
+	errInvalidVersions   = errors.New("invalid dashboard versions")
+	errInvalidTimeout    = errors.New("invalid timeout")
+	errInvalidMargin     = errors.New("invalid margin")
+	errInvalidProfile    = errors.New("invalid profile")
+)
+
+// Maximum number of resolutions of a report in a single request.
+const maxResolutions = 4
+
 // Required feature flags.
 const (
 	accessControlFeatureFlag = "accessControlOnCall" // added in Grafana 10.4.0
@@ -50,8 +70,162 @@ func (app *App) convertPanelIDs(ids []string) []string {
 	return panelIDs
 }
 
-// updateConfig updates the default config from query parameters.
-func (app *App) updateConfig(req *http.Request, conf *config.Config) {
+// boolQueryParam sets value to boolean query parameter name, if it is present
+// in query and valid.
+func boolQueryParam(query url.Values, name string, value *bool) {
+	if !query.Has(name) {
+		return
+	}
+
+	if v, err := strconv.ParseBool(query.Get(name)); err == nil {
+		*value = v
+	}
+}
+
+// floatQueryParam sets value to non-negative float query parameter name, if it
+// is present in query. An error is returned when the query parameter is invalid.
+func floatQueryParam(query url.Values, name string, value *float64) error {
+	if !query.Has(name) {
+		return nil
+	}
+
+	v, err := strconv.ParseFloat(query.Get(name), 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return fmt.Errorf("%s: %s", name, query.Get(name))
+	}
+
+	*value = v
+
+	return nil
+}
+
+// resolutionsQueryParam returns the device scale factors listed in comma separated
+// resolutions query parameter. Duplicate scales are ignored.
+func resolutionsQueryParam(query url.Values) ([]float64, error) {
+	if !query.Has("resolutions") {
+		return nil, nil
+	}
+
+	var scales []float64
+
+	for _, v := range strings.Split(query.Get("resolutions"), ",") {
+		scale, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || scale <= 0 || scale > config.MaxDeviceScaleFactor {
+			return nil, fmt.Errorf("%w: %s", errInvalidResolution, v)
+		}
+
+		if !slices.Contains(scales, scale) {
+			scales = append(scales, scale)
+		}
+	}
+
+	if len(scales) > maxResolutions {
+		return nil, fmt.Errorf("%w: at most %d resolutions are allowed", errInvalidResolution, maxResolutions)
+	}
+
+	return scales, nil
+}
+
+// logLevelQueryParam returns the log level requested in logLevel query parameter.
+// As messages are still filtered by the plugin logger, the level can only lower
+// the verbosity of request logs.
+// The returned bool is false when the query parameter is absent.
+func logLevelQueryParam(query url.Values) (log.Level, bool, error) {
+	if !query.Has("logLevel") {
+		return log.NoLevel, false, nil
+	}
+
+	level, ok := helpers.LogLevels[strings.ToLower(query.Get("logLevel"))]
+	if !ok {
+		return log.NoLevel, false, fmt.Errorf("%w: %s", errInvalidLogLevel, query.Get("logLevel"))
+	}
+
+	return level, true, nil
+}
+
+// diffVersionsQueryParam returns the two dashboard versions listed in comma separated
+// diffVersions query parameter. The returned bool is false when the query parameter
+// is absent.
+func diffVersionsQueryParam(query url.Values) (int, int, bool, error) {
+	if !query.Has("diffVersions") {
+		return 0, 0, false, nil
+	}
+
+	from, to, found := strings.Cut(query.Get("diffVersions"), ",")
+	if !found {
+		return 0, 0, false, fmt.Errorf("%w: %s", errInvalidVersions, query.Get("diffVersions"))
+	}
+
+	fromVersion, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || fromVersion <= 0 {
+		return 0, 0, false, fmt.Errorf("%w: %s", errInvalidVersions, from)
+	}
+
+	toVersion, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || toVersion <= 0 {
+		return 0, 0, false, fmt.Errorf("%w: %s", errInvalidVersions, to)
+	}
+
+	return fromVersion, toVersion, true, nil
+}
+
+// timeRangeQuery returns query parameters with time range. When from and/or to
+// are absent in query parameters, they are set from the saved time range of the
+// dashboard when enabled or from the default time range of config so that API
+// model and render URLs always use an explicit time range.
+func timeRangeQuery(query url.Values, conf *config.Config, saved dashboard.TimeRange) url.Values {
+	values := maps.Clone(query)
+	if values == nil {
+		values = url.Values{}
+	}
+
+	from, to := conf.DefaultTimeRange[0], conf.DefaultTimeRange[1]
+
+	if conf.UseDashboardSavedTime && saved.From != "" && saved.To != "" {
+		from, to = savedTime(saved.From), savedTime(saved.To)
+	}
+
+	if !values.Has("from") {
+		values.Set("from", from)
+	}
+
+	if !values.Has("to") {
+		values.Set("to", to)
+	}
+
+	return values
+}
+
+// savedTime returns the time of saved time range of dashboard in a format
+// accepted in URLs. Absolute times are saved as ISO strings in dashboard model
+// and they are converted to epoch milliseconds.
+func savedTime(value string) string {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+
+	return value
+}
+
+// updateConfig updates the default config from query parameters. Report profile
+// in profile query parameter is applied first so that other query parameters can
+// override its settings.
+func (app *App) updateConfig(req *http.Request, conf *config.Config) error {
+	if req.URL.Query().Has("profile") {
+		if err := app.applyProfile(req, conf, req.URL.Query().Get("profile")); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidProfile, err)
+		}
+	}
+
+	if req.URL.Query().Has("timeout") {
+		timeout, err := time.ParseDuration(req.URL.Query().Get("timeout"))
+		if err != nil || timeout <= 0 || timeout > time.Duration(conf.MaxRequestTimeout)*time.Second {
+			return fmt.Errorf("%w: %s", errInvalidTimeout, req.URL.Query().Get("timeout"))
+		}
+
+		conf.RequestTimeout = timeout
+	}
+
 	if req.URL.Query().Has("theme") {
 		conf.Theme = req.URL.Query().Get("theme")
 	}
@@ -64,6 +238,10 @@ func (app *App) updateConfig(req *http.Request, conf *config.Config) {
 		conf.Orientation = req.URL.Query().Get("orientation")
 	}
 
+	if req.URL.Query().Has("paperSize") {
+		conf.PaperSize = req.URL.Query().Get("paperSize")
+	}
+
 	if req.URL.Query().Has("dashboardMode") {
 		conf.DashboardMode = req.URL.Query().Get("dashboardMode")
 	}
@@ -90,6 +268,23 @@ func (app *App) updateConfig(req *http.Request, conf *config.Config) {
 		conf.TimeFormat = req.URL.Query().Get("timeFormat")
 	}
 
+	boolQueryParam(req.URL.Query(), "orderRepeatsByValue", &conf.OrderRepeatsByValue)
+	boolQueryParam(req.URL.Query(), "coverPage", &conf.CoverPage)
+	boolQueryParam(req.URL.Query(), "tableOfContents", &conf.TableOfContents)
+	boolQueryParam(req.URL.Query(), "variableSummaryTable", &conf.VariableSummaryTable)
+	boolQueryParam(req.URL.Query(), "executiveSummary", &conf.ExecutiveSummary)
+	boolQueryParam(req.URL.Query(), "disableHeaderFooter", &conf.DisableHeaderFooter)
+	boolQueryParam(req.URL.Query(), "generateOutline", &conf.GenerateOutline)
+
+	if err := errors.Join(
+		floatQueryParam(req.URL.Query(), "marginTop", &conf.MarginTop),
+		floatQueryParam(req.URL.Query(), "marginBottom", &conf.MarginBottom),
+		floatQueryParam(req.URL.Query(), "marginLeft", &conf.MarginLeft),
+		floatQueryParam(req.URL.Query(), "marginRight", &conf.MarginRight),
+	); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidMargin, err)
+	}
+
 	if req.URL.Query().Has("includePanelID") {
 		conf.IncludePanelIDs = app.convertPanelIDs(req.URL.Query()["includePanelID"])
 	}
@@ -98,9 +293,51 @@ func (app *App) updateConfig(req *http.Request, conf *config.Config) {
 		conf.ExcludePanelIDs = app.convertPanelIDs(req.URL.Query()["excludePanelID"])
 	}
 
+	if req.URL.Query().Has("includePanelTitleRegex") {
+		conf.IncludePanelTitleRegex = req.URL.Query().Get("includePanelTitleRegex")
+	}
+
+	if req.URL.Query().Has("excludePanelTitleRegex") {
+		conf.ExcludePanelTitleRegex = req.URL.Query().Get("excludePanelTitleRegex")
+	}
+
+	if req.URL.Query().Has("excludePanelType") {
+		conf.ExcludePanelTypes = req.URL.Query()["excludePanelType"]
+	}
+
 	if req.URL.Query().Has("includePanelDataID") {
 		conf.IncludePanelDataIDs = app.convertPanelIDs(req.URL.Query()["includePanelDataID"])
 	}
+
+	if req.URL.Query().Has("combinedPanelID") {
+		conf.CombinedPanels = app.convertPanelIDs(req.URL.Query()["combinedPanelID"])
+	}
+
+	return nil
+}
+
+// applyProfile applies report profile name to the config. Variables of the profile
+// are added to the query of request unless they are already set.
+func (app *App) applyProfile(req *http.Request, conf *config.Config, name string) error {
+	if err := conf.ApplyProfile(name); err != nil {
+		return err
+	}
+
+	conf.IncludePanelIDs = app.convertPanelIDs(conf.IncludePanelIDs)
+	conf.ExcludePanelIDs = app.convertPanelIDs(conf.ExcludePanelIDs)
+	conf.IncludePanelDataIDs = app.convertPanelIDs(conf.IncludePanelDataIDs)
+
+	query := req.URL.Query()
+
+	for variable, value := range conf.ReportProfiles[name].Variables {
+		if !query.Has("var-" + variable) {
+			query.Set("var-"+variable, value)
+		}
+	}
+
+	req.URL.RawQuery = query.Encode()
+
+	return nil
 }
 
 // featureTogglesEnabled checks if the necessary feature toogles are enabled on Grafana server.
@@ -141,14 +378,75 @@ func (app *App) grafanaAppURL(grafanaConfig *backend.GrafanaCfg) (string, error)
 	return strings.TrimSuffix(grafanaAppURL, "/"), nil
 }
 
-// dashboardModel fetches dashboard JSON model from Grafana API.
-func (app *App) dashboardModel(ctx context.Context, appURL, dashUID string, authHeader http.Header, values url.Values) (*dashboard.Model, error) {
+// dashboardModel fetches dashboard JSON model from Grafana API. If version is
+// greater than zero, dashboard model of that specific version will be returned.
+func (app *App) dashboardModel(ctx context.Context, appURL, dashUID string, version int, authHeader http.Header, values url.Values) (*dashboard.Model, error) {
 	dashURL := fmt.Sprintf("%s/api/dashboards/uid/%s", appURL, dashUID)
 
+	body, err := app.grafanaAPIRequest(ctx, dashURL, authHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dashboard model: %w", err)
+	}
+
+	var model dashboard.Model
+
+	// Read data into dashboard.Model
+	err = json.Unmarshal(body, &model) //nolint:musttag
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body into dashboard model: %w", err)
+	}
+
+	// Dashboard versions do not contain the dashboard meta data and hence
+	// we always fetch current dashboard first and replace the dashboard
+	// model with the one of the requested version.
+	if version > 0 {
+		versionURL := fmt.Sprintf("%s/api/dashboards/uid/%s/versions/%d", appURL, dashUID, version)
+
+		body, err := app.grafanaAPIRequest(ctx, versionURL, authHeader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch dashboard version: %w", err)
+		}
+
+		var dashVersion struct {
+			Data json.RawMessage `json:"data"`
+		}
+
+		if err = json.Unmarshal(body, &dashVersion); err != nil {
+			return nil, fmt.Errorf("error reading response body into dashboard version: %w", err)
+		}
+
+		if err = json.Unmarshal(dashVersion.Data, &model.Dashboard); err != nil { //nolint:musttag
+			return nil, fmt.Errorf("error reading dashboard version into dashboard model: %w", err)
+		}
+	}
+
+	// Add template variables to model
+	model.Dashboard.Variables = values
+
+	return &model, nil
+}
+
+// datasources fetches the datasources of the organization from Grafana API.
+func (app *App) datasources(ctx context.Context, appURL string, authHeader http.Header) ([]dashboard.Datasource, error) {
+	body, err := app.grafanaAPIRequest(ctx, appURL+"/api/datasources", authHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch datasources: %w", err)
+	}
+
+	var datasources []dashboard.Datasource
+	if err = json.Unmarshal(body, &datasources); err != nil {
+		return nil, fmt.Errorf("error reading response body into datasources: %w", err)
+	}
+
+	return datasources, nil
+}
+
+// grafanaAPIRequest makes a GET request to Grafana API and returns response body.
+func (app *App) grafanaAPIRequest(ctx context.Context, apiURL string, authHeader http.Header) ([]byte, error) {
 	// Create a new GET request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dashURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request for %s: %w", dashURL, err)
+		return nil, fmt.Errorf("error creating request for %s: %w", apiURL, err)
 	}
 
 	// Forward auth headers
@@ -161,52 +459,141 @@ func (app *App) dashboardModel(ctx context.Context, appURL, dashUID string, auth
 	// Make request
 	resp, err := app.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request for %s: %w", dashURL, err)
+		return nil, fmt.Errorf("error executing request for %s: %w", apiURL, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body from %s: %w", dashURL, err)
+		return nil, fmt.Errorf("error reading response body from %s: %w", apiURL, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"failed to fetch dashboard model: URL: %s. Status: %s, message: %s",
-			dashURL,
-			resp.Status,
-			string(body),
-		)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: URL: %s, message: %s", errResourceNotFound, apiURL, string(body))
+	default:
+		return nil, fmt.Errorf("URL: %s. Status: %s, message: %s", apiURL, resp.Status, string(body))
 	}
+}
 
-	var model dashboard.Model
+// authHeader returns the header name value pairs that will be used in API requests to Grafana.
+func (app *App) authHeader(req *http.Request, conf *config.Config, grafanaConfig *backend.GrafanaCfg, ctxLogger log.Logger) (http.Header, error) {
+	authHeader := http.Header{}
 
-	// Read data into dashboard.Model
-	err = json.Unmarshal(body, &model) //nolint:musttag
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body into dashboard model: %w", err)
+	switch {
+	// When Grafana allows anonymous access, dashboards are publicly accessible and
+	// there is no need to forward any credentials.
+	case conf.AnonymousAccess:
+		ctxLogger.Debug("using anonymous access")
+	// This case is irrelevant starting from Grafana 10.4.4.
+	// This commit https://github.com/grafana/grafana/commit/56a4af87d706087ea42780a79f8043df1b5bc3ea
+	// made changes to not forward the cookies to app plugins.
+	// So we will not be able to use cookies to make requests to Grafana to fetch
+	// dashboards.
+	case req.Header.Get(backend.CookiesHeaderName) != "":
+		ctxLogger.Debug("using user cookie")
+
+		authHeader.Add(backend.CookiesHeaderName, req.Header.Get(backend.CookiesHeaderName))
+	case conf.Token != "":
+		ctxLogger.Debug("using user configured token")
+
+		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+conf.Token)
+	default:
+		ctxLogger.Debug("using service account token")
+
+		saToken, err := grafanaConfig.PluginAppClientSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		if saToken == "" {
+			return nil, errors.New("empty client secret")
+		}
+
+		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+saToken)
 	}
 
-	// Add template variables to model
-	model.Dashboard.Variables = values
+	// Credentials of reverse proxy in front of Grafana, if any
+	conf.SetBasicAuth(authHeader)
 
-	return &model, nil
+	return authHeader, nil
 }
 
-// handleReport handles creating a PDF report from a given dashboard UID
-// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/report.
-func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+// allowRequest returns true if the user making the request has not exceeded the rate
+// limit. If not, it returns the duration after which the next request will be allowed.
+func (app *App) allowRequest(pluginConfig backend.PluginContext, conf *config.Config) (bool, time.Duration) {
+	if app.rateLimiter == nil {
+		return true, 0
+	}
 
-		return
+	// Service accounts in Grafana have logins prefixed by sa-
+	if conf.RateLimitExemptServiceAccounts && strings.HasPrefix(pluginConfig.User.Login, "sa-") {
+		return true, 0
+	}
+
+	return app.rateLimiter.allow(fmt.Sprintf("%d/%s", pluginConfig.OrgID, pluginConfig.User.Login))
+}
+
+// Priorities of report requests.
+const (
+	priorityInteractive = "interactive"
+	priorityBatch       = "batch"
+)
+
+// workerPoolsFor returns the worker pools to use for the request based on its priority.
+// Priority is taken from priority query parameter and when absent, requests made
+// by service accounts are considered as batch requests and others as interactive.
+// When dedicated pools for interactive requests are not configured, shared pools
+// are always returned.
+func (app *App) workerPoolsFor(priority string, pluginConfig backend.PluginContext) worker.Pools {
+	if app.interactivePools == nil {
+		return app.workerPools
+	}
+
+	if priority == "" {
+		priority = priorityInteractive
+
+		// Service accounts in Grafana have logins prefixed by sa-
+		if pluginConfig.User != nil && strings.HasPrefix(pluginConfig.User.Login, "sa-") {
+			priority = priorityBatch
+		}
 	}
 
+	if priority == priorityInteractive {
+		return app.interactivePools
+	}
+
+	return app.workerPools
+}
+
+// dashboardRequest contains the state of a validated request on a dashboard.
+type dashboardRequest struct {
+	conf       *config.Config
+	logger     log.Logger
+	model      *dashboard.Model
+	dashboard  *dashboard.Dashboard
+	pools      worker.Pools
+	appURL     string
+	authHeader http.Header
+}
+
+// prepareDashboard validates the query parameters, authenticates and checks permissions
+// of the request on the dashboard and returns the dashboard. Errors are written to
+// the response and false is returned.
+func (app *App) prepareDashboard(w http.ResponseWriter, req *http.Request) (*dashboardRequest, bool) {
 	var err error
 
 	// Always start with an instance of current app's config
 	conf := app.conf
 
+	// Render panels natively when grafana-image-renderer has been detected to be
+	// unavailable and auto detection of renderer is enabled
+	if app.currentRenderer() == rendererNative {
+		conf.NativeRendering = true
+	}
+
 	// Get context logger which we will use everywhere
 	ctxLogger := log.DefaultLogger.FromContext(req.Context())
 
@@ -214,18 +601,54 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 	pluginConfig := backend.PluginConfigFromContext(req.Context())
 	currentUser := pluginConfig.User.Login
 
+	// Check if user has exceeded the rate limit
+	if allowed, retryAfter := app.allowRequest(pluginConfig, &conf); !allowed {
+		ctxLogger.Debug("rate limit exceeded", "user", currentUser, "retry_after", retryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+		return nil, false
+	}
+
 	// Get Dashboard ID
 	dashboardUID := req.URL.Query().Get("dashUid")
 	if dashboardUID == "" {
 		ctxLogger.Debug("Query parameter dashUid not found")
 		http.Error(w, "missing dashUid query parameter", http.StatusBadRequest)
 
-		return
+		return nil, false
 	}
 
 	// Add dash uid and user to logger
 	ctxLogger = ctxLogger.With("user", currentUser, "dash_uid", dashboardUID)
 
+	// Redact URLs in logs, if enabled
+	if conf.LogRedaction {
+		ctxLogger = helpers.NewRedactingLogger(ctxLogger)
+	}
+
+	// Override log level of request, if requested
+	level, ok, err := logLevelQueryParam(req.URL.Query())
+	if err != nil {
+		ctxLogger.Debug("invalid logLevel query parameter", "err", err)
+		http.Error(w, "logLevel query parameter must be one of [debug,info,warn,error] and it can only lower the verbosity of the plugin log level", http.StatusBadRequest)
+
+		return nil, false
+	}
+
+	if ok {
+		ctxLogger = helpers.NewLevelLogger(ctxLogger, level)
+	}
+
+	// Get priority of request, if requested
+	priority := req.URL.Query().Get("priority")
+	if priority != "" && priority != priorityInteractive && priority != priorityBatch {
+		ctxLogger.Debug("invalid priority query parameter", "priority", priority)
+		http.Error(w, "priority query parameter must be one of [interactive,batch]", http.StatusBadRequest)
+
+		return nil, false
+	}
+
 	grafanaConfig := backend.GrafanaConfigFromContext(req.Context())
 
 	// Get Grafana App URL by looking both at passed config and user defined config
@@ -234,67 +657,92 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 		ctxLogger.Error("failed to get app URL", "err", err)
 		http.Error(w, "error generating report", http.StatusInternalServerError)
 
-		return
+		return nil, false
 	}
 
 	// Update plugin's config from query params
-	app.updateConfig(req, &conf)
+	if err := app.updateConfig(req, &conf); err != nil {
+		if errors.Is(err, errInvalidTimeout) {
+			ctxLogger.Debug("invalid timeout query parameter", "err", err)
+			http.Error(w, fmt.Sprintf("timeout query parameter must be a positive duration of at most %ds", conf.MaxRequestTimeout), http.StatusBadRequest)
+
+			return nil, false
+		}
+
+		if errors.Is(err, errInvalidMargin) {
+			ctxLogger.Debug("invalid margin query parameter", "err", err)
+			http.Error(w, "margin query parameters must be non-negative numbers of inches", http.StatusBadRequest)
+
+			return nil, false
+		}
+
+		if errors.Is(err, errInvalidProfile) {
+			ctxLogger.Debug("invalid profile query parameter", "profile", req.URL.Query().Get("profile"), "err", err)
+			http.Error(w, "profile query parameter must be one of the configured report profiles", http.StatusBadRequest)
+
+			return nil, false
+		}
+
+		ctxLogger.Debug("invalid query parameters", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return nil, false
+	}
 
 	// Validate new updated config
 	if err := conf.Validate(); err != nil {
 		ctxLogger.Debug("invalid config: "+conf.String(), "err", err)
 		http.Error(w, "invalid query parameters found", http.StatusBadRequest)
 
-		return
+		return nil, false
 	}
 
 	ctxLogger.Info("generate report using config: " + conf.String())
 
 	// authHeader is header name value pair that will be used in API requests
-	authHeader := http.Header{}
-
-	switch {
-	// This case is irrelevant starting from Grafana 10.4.4.
-	// This commit https://github.com/grafana/grafana/commit/56a4af87d706087ea42780a79f8043df1b5bc3ea
-	// made changes to not forward the cookies to app plugins.
-	// So we will not be able to use cookies to make requests to Grafana to fetch
-	// dashboards.
-	case req.Header.Get(backend.CookiesHeaderName) != "":
-		ctxLogger.Debug("using user cookie")
+	authHeader, err := app.authHeader(req, &conf, grafanaConfig, ctxLogger)
+	if err != nil {
+		ctxLogger.Error("failed to get plugin app client secret", "err", err)
+		http.Error(w, "error generating report", http.StatusInternalServerError)
 
-		authHeader.Add(backend.CookiesHeaderName, req.Header.Get(backend.CookiesHeaderName))
-	case conf.Token != "":
-		ctxLogger.Debug("using user configured token")
+		return nil, false
+	}
 
-		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+conf.Token)
-	default:
-		ctxLogger.Debug("using service account token")
+	// Get dashboard JSON model from API
+	model, err := app.dashboardModel(req.Context(), grafanaAppURL, dashboardUID, 0, authHeader, req.URL.Query())
+	if err != nil {
+		ctxLogger.Error("failed to get dashboard JSON model", "err", err)
 
-		saToken, err := grafanaConfig.PluginAppClientSecret()
-		if err != nil {
-			ctxLogger.Error("failed to get plugin app client secret", "err", err)
-			http.Error(w, "error generating report", http.StatusInternalServerError)
+		if errors.Is(err, errCircuitOpen) {
+			http.Error(w, "grafana is unavailable, try again later", http.StatusServiceUnavailable)
 
-			return
+			return nil, false
 		}
 
-		if saToken == "" {
-			ctxLogger.Error("failed to get plugin app client secret", "err", "empty client secret")
-			http.Error(w, "error generating report", http.StatusInternalServerError)
+		http.Error(w, "error generating report", http.StatusInternalServerError)
 
-			return
-		}
+		return nil, false
+	}
 
-		authHeader.Add(backend.OAuthIdentityTokenHeaderName, "Bearer "+saToken)
+	// Fiscal year set in the dashboard takes precedence over the one of plugin
+	// so that fiscal ranges match the ones shown in Grafana
+	if month, ok := model.Dashboard.FiscalYearStart(); ok {
+		conf.FiscalYearStartMonth = int(month)
 	}
 
-	// Get dashboard JSON model from API
-	model, err := app.dashboardModel(req.Context(), grafanaAppURL, dashboardUID, authHeader, req.URL.Query())
-	if err != nil {
-		ctxLogger.Error("failed to get dashboard JSON model", "err", err)
-		http.Error(w, "error generating report", http.StatusInternalServerError)
+	// Set time range when it is not provided in query parameters
+	model.Dashboard.Variables = timeRangeQuery(model.Dashboard.Variables, &conf, model.Dashboard.Time)
 
-		return
+	// Resolve datasource variables to concrete datasource UIDs. Failing to resolve
+	// them is not fatal as Grafana might still be able to render the panels
+	if conf.ResolveDatasourceVariables && slices.ContainsFunc(model.Dashboard.Templating.List, func(v dashboard.Variable) bool {
+		return v.Type == "datasource"
+	}) {
+		if datasources, err := app.datasources(req.Context(), grafanaAppURL, authHeader); err != nil {
+			ctxLogger.Warn("failed to resolve datasource variables", "err", err)
+		} else {
+			model.Dashboard.Variables = dashboard.ResolveDatasourceVariables(model.Dashboard.Templating.List, model.Dashboard.Variables, datasources)
+		}
 	}
 
 	// If dashboard is in a folder, check if user has permissions on either the dashboard
@@ -319,19 +767,36 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 	// Here we check if user has permissions to do an action "dashboards:read" on
 	// dashboards resource of a given dashboard UID
 	if app.featureTogglesEnabled(req.Context()) {
-		if hasAccess, err := app.HasAccess(
-			req, "dashboards:read",
-			resources...,
-		); err != nil || !hasAccess {
-			if err != nil {
-				ctxLogger.Error("failed to check permissions", "err", err)
-			} else {
-				ctxLogger.Error("user does not have necessary permissions to view dashboard")
-			}
-
+		if !app.checkAccess(req, &conf, ctxLogger, "dashboards:read", resources...) {
 			http.Error(w, "permission denied", http.StatusForbidden)
 
-			return
+			return nil, false
+		}
+	}
+
+	// If asked, add absolute time range of the report to response headers
+	if conf.TimeRangeHeaders {
+		timeRange := dashboard.NewTimeRange(model.Dashboard.Variables.Get("from"), model.Dashboard.Variables.Get("to"))
+
+		from, to, err := timeRange.Absolute(conf.Location, conf.WeekStart, conf.FiscalYearStart())
+		if err != nil {
+			ctxLogger.Debug("failed to resolve time range", "err", err)
+			http.Error(w, "invalid time range", http.StatusBadRequest)
+
+			return nil, false
+		}
+
+		w.Header().Set("X-Report-Time-From", from.In(conf.Location).Format(time.RFC3339))
+		w.Header().Set("X-Report-Time-To", to.In(conf.Location).Format(time.RFC3339))
+	}
+
+	// Restart browser, if it has crashed since the last request
+	if app.chromeInstance != nil {
+		if err := app.chromeInstance.CheckHealth(ctxLogger); err != nil {
+			ctxLogger.Error("browser is unavailable", "err", err)
+			http.Error(w, "browser is unavailable, try again later", http.StatusServiceUnavailable)
+
+			return nil, false
 		}
 	}
 
@@ -349,6 +814,143 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 		ctxLogger.Error("failed to create a new dashboard", "err", err)
 		http.Error(w, "error generating report", http.StatusInternalServerError)
 
+		return nil, false
+	}
+
+	return &dashboardRequest{
+		&conf, ctxLogger, model, grafanaDashboard, app.workerPoolsFor(priority, pluginConfig), grafanaAppURL, authHeader,
+	}, true
+}
+
+// handleReport handles creating a PDF report from a given dashboard UID
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/report.
+//
+// Output format is selected by format query parameter or negotiated using Accept
+// header. Reports at multiple resolutions and reports bundled with the data of
+// their panels are always returned as ZIP archives.
+//
+// HEAD requests go through the same validation of query parameters, authentication
+// and permissions and respond with the headers of the report without generating it.
+func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	// Trace report generation as a child of the span of request, if enabled
+	ctx, span := helpers.StartSpan(req.Context(), app.conf.TracingEnabled, "handleReport",
+		helpers.SpanDashboardUID.String(req.URL.Query().Get("dashUid")),
+	)
+	defer span.End()
+
+	req = req.WithContext(ctx)
+
+	// Get device scale factors of reports, if requested
+	scales, err := resolutionsQueryParam(req.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("resolutions query parameter must be a comma separated list of at most %d scales between 0 and %d", maxResolutions, config.MaxDeviceScaleFactor), http.StatusBadRequest)
+
+		return
+	}
+
+	// Bundle report with data of its panels, if requested
+	var bundle bool
+
+	boolQueryParam(req.URL.Query(), "bundle", &bundle)
+
+	// Archive PNGs of panels instead of the report, if requested
+	var panelImages bool
+
+	boolQueryParam(req.URL.Query(), "panelImages", &panelImages)
+
+	// Get output format of report
+	w.Header().Add("Vary", "Accept")
+
+	format, err := reportFormat(req)
+
+	switch {
+	case errors.Is(err, errNotAcceptable):
+		http.Error(w, "Accept header must allow one of [application/pdf,text/html,application/zip]", http.StatusNotAcceptable)
+
+		return
+	case err != nil:
+		http.Error(w, "format query parameter must be one of ["+strings.Join(reportFormats, ",")+"]", http.StatusBadRequest)
+
+		return
+	case len(scales) > 0 && req.URL.Query().Has("format") && format != formatZIP:
+		http.Error(w, "resolutions query parameter is only supported with zip format", http.StatusBadRequest)
+
+		return
+	case bundle && len(scales) > 0:
+		http.Error(w, "bundle and resolutions query parameters cannot be used together", http.StatusBadRequest)
+
+		return
+	case bundle && req.URL.Query().Has("format") && format != formatZIP:
+		http.Error(w, "bundle query parameter is only supported with zip format", http.StatusBadRequest)
+
+		return
+	case panelImages && (bundle || len(scales) > 0):
+		http.Error(w, "panelImages query parameter cannot be used with bundle or resolutions query parameters", http.StatusBadRequest)
+
+		return
+	case panelImages && req.URL.Query().Has("format") && format != formatZIP:
+		http.Error(w, "panelImages query parameter is only supported with zip format", http.StatusBadRequest)
+
+		return
+	case bundle, panelImages:
+		format = formatZIP
+	case len(scales) > 0:
+		format = formatZIP
+	}
+
+	// Get dashboard versions to diff, if requested
+	fromVersion, toVersion, diff, err := diffVersionsQueryParam(req.URL.Query())
+
+	switch {
+	case err != nil:
+		http.Error(w, "diffVersions query parameter must be two comma separated positive integers", http.StatusBadRequest)
+
+		return
+	case diff && format == formatZIP:
+		http.Error(w, "diffVersions query parameter is only supported with pdf and html formats", http.StatusBadRequest)
+
+		return
+	}
+
+	dashReq, ok := app.prepareDashboard(w, req)
+	if !ok {
+		return
+	}
+
+	// Summarise differences between dashboard versions instead of the report
+	if diff {
+		app.handleDiff(w, req, dashReq, format, fromVersion, toVersion)
+
+		return
+	}
+
+	conf, ctxLogger := dashReq.conf, dashReq.logger
+
+	// ZIP archive without resolutions contains report at configured resolution
+	if format == formatZIP && len(scales) == 0 {
+		scales = []float64{conf.DeviceScaleFactor}
+	}
+
+	// For HEAD requests, return headers of the report without generating it
+	if req.Method == http.MethodHead {
+		switch format {
+		case formatZIP:
+			w.Header().Set("Content-Type", "application/zip")
+		case formatHTML:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", report.ContentDisposition(dashReq.model.Dashboard.Title, conf))
+		}
+
+		w.WriteHeader(http.StatusOK)
+
 		return
 	}
 
@@ -357,16 +959,72 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 	// Make app new Report to put all PNGs into app HTML template and print it into app PDF
 	pdfReport := report.New(
 		ctxLogger,
-		&conf,
+		conf,
 		app.httpClient,
 		app.chromeInstance,
-		app.workerPools,
-		grafanaDashboard,
+		dashReq.pools,
+		dashReq.dashboard,
 	)
 
+	switch {
+	// Generate PNGs of panels as a ZIP archive
+	case panelImages:
+		if err := pdfReport.GenerateImages(req.Context(), w); err != nil {
+			ctxLogger.Error("error generating panel images", "err", err)
+			helpers.SpanError(span, err)
+			http.Error(w, "error generating report", http.StatusInternalServerError)
+
+			return
+		}
+
+		ctxLogger.Info("report generated", "format", "panel images")
+
+		return
+	// Generate report along with PNGs and data of panels as a ZIP archive
+	case bundle:
+		if err := pdfReport.GenerateBundle(req.Context(), w); err != nil {
+			ctxLogger.Error("error generating report", "err", err)
+			helpers.SpanError(span, err)
+			http.Error(w, "error generating report", http.StatusInternalServerError)
+
+			return
+		}
+
+		ctxLogger.Info("report generated", "format", "bundle")
+
+		return
+	// Generate report at each of the requested resolutions as a ZIP archive
+	case format == formatZIP:
+		if err := pdfReport.GenerateResolutions(req.Context(), w, scales); err != nil {
+			ctxLogger.Error("error generating report", "err", err)
+			helpers.SpanError(span, err)
+			http.Error(w, "error generating report", http.StatusInternalServerError)
+
+			return
+		}
+
+		ctxLogger.Info("report generated", "resolutions", scales)
+
+		return
+	// Generate report as HTML page
+	case format == formatHTML:
+		if err := pdfReport.GenerateHTML(req.Context(), w); err != nil {
+			ctxLogger.Error("error generating report", "err", err)
+			helpers.SpanError(span, err)
+			http.Error(w, "error generating report", http.StatusInternalServerError)
+
+			return
+		}
+
+		ctxLogger.Info("report generated", "format", format)
+
+		return
+	}
+
 	// Generate report
-	if err = pdfReport.Generate(req.Context(), w); err != nil {
+	if err := pdfReport.Generate(req.Context(), w); err != nil {
 		ctxLogger.Error("error generating report", "err", err)
+		helpers.SpanError(span, err)
 		http.Error(w, "error generating report", http.StatusInternalServerError)
 
 		return
@@ -375,6 +1033,143 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 	ctxLogger.Info("report generated")
 }
 
+// handleDiff writes the summary of panels added, removed and moved between two
+// versions of the dashboard of request in the given format.
+func (app *App) handleDiff(w http.ResponseWriter, req *http.Request, dashReq *dashboardRequest, format string, fromVersion, toVersion int) {
+	ctxLogger := dashReq.logger
+
+	if req.Method == http.MethodHead {
+		if format == formatHTML {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/pdf")
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	dashboardUID := req.URL.Query().Get("dashUid")
+	models := make([]*dashboard.Model, 2)
+
+	for i, version := range []int{fromVersion, toVersion} {
+		model, err := app.dashboardModel(req.Context(), dashReq.appURL, dashboardUID, version, dashReq.authHeader, nil)
+		if err != nil {
+			ctxLogger.Error("failed to get dashboard JSON model", "version", version, "err", err)
+
+			switch {
+			case errors.Is(err, errResourceNotFound):
+				http.Error(w, "dashboard version not found", http.StatusNotFound)
+			case errors.Is(err, errCircuitOpen):
+				http.Error(w, "grafana is unavailable, try again later", http.StatusServiceUnavailable)
+			default:
+				http.Error(w, "error generating report", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		models[i] = model
+	}
+
+	versionDiff := dashboard.VersionDiff{
+		Title:       models[1].Dashboard.Title,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	}
+	versionDiff.Added, versionDiff.Removed, versionDiff.Moved = dashboard.DiffPanels(
+		models[0].Dashboard.RowOrPanels, models[1].Dashboard.RowOrPanels,
+	)
+
+	diffReport := report.New(
+		ctxLogger,
+		dashReq.conf,
+		app.httpClient,
+		app.chromeInstance,
+		dashReq.pools,
+		dashReq.dashboard,
+	)
+
+	var err error
+	if format == formatHTML {
+		err = diffReport.GenerateDiffHTML(req.Context(), w, versionDiff)
+	} else {
+		err = diffReport.GenerateDiff(req.Context(), w, versionDiff)
+	}
+
+	if err != nil {
+		ctxLogger.Error("error generating diff report", "err", err)
+		http.Error(w, "error generating report", http.StatusInternalServerError)
+
+		return
+	}
+
+	ctxLogger.Info("diff report generated", "from_version", fromVersion, "to_version", toVersion)
+}
+
+// handleData handles exporting data of table panels from a given dashboard UID
+// GET /api/plugins/mahendrapaipuri-dashboardreporter-app/resources/data.
+//
+// Only newline delimited JSON format (format=ndjson) is supported.
+func (app *App) handleData(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	format := req.URL.Query().Get("format")
+	if format != "" && !slices.Contains(dataFormats, format) {
+		http.Error(w, "format query parameter must be one of ["+strings.Join(dataFormats, ",")+"]", http.StatusBadRequest)
+
+		return
+	}
+
+	dashReq, ok := app.prepareDashboard(w, req)
+	if !ok {
+		return
+	}
+
+	ctxLogger := dashReq.logger
+
+	// For HEAD requests, return headers of the data without generating it
+	if req.Method == http.MethodHead {
+		if format == formatXLSX {
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	dataReport := report.New(
+		ctxLogger,
+		dashReq.conf,
+		app.httpClient,
+		app.chromeInstance,
+		dashReq.pools,
+		dashReq.dashboard,
+	)
+
+	generate := dataReport.GenerateNDJSON
+	if format == formatXLSX {
+		generate = dataReport.GenerateXLSX
+	}
+
+	if err := generate(req.Context(), w); err != nil {
+		ctxLogger.Error("error exporting data", "err", err)
+		http.Error(w, "error exporting data", http.StatusInternalServerError)
+
+		return
+	}
+
+	ctxLogger.Info("data exported", "format", cmp.Or(format, formatNDJSON))
+}
+
 // handleHealth is an example HTTP GET resource that returns an OK response.
 func (app *App) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Add("Content-Type", "text/plan")
@@ -391,5 +1186,6 @@ func (app *App) handleHealth(w http.ResponseWriter, _ *http.Request) {
 // registerRoutes takes a *http.ServeMux and registers some HTTP handlers.
 func (app *App) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/report", app.handleReport)
+	mux.HandleFunc("/data", app.handleData)
 	mux.HandleFunc("/healthz", app.handleHealth)
 }
@@ -2,14 +2,21 @@ package plugin
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/config"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/dashboard"
+	"github.com/mahendrapaipuri/grafana-dashboard-reporter-app/pkg/plugin/worker"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -110,3 +117,679 @@ func TestReportResource(t *testing.T) {
 		})
 	})
 }
+
+func TestDashboardModel(t *testing.T) {
+	Convey("When fetching dashboard model", t, func() {
+		var requestURI []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestURI = append(requestURI, r.URL.Path)
+
+			switch r.URL.Path {
+			case "/api/dashboards/uid/testDash":
+				w.Write([]byte(`{"meta": {"folderUid": "testFolder"}, "dashboard": {"uid": "testDash", "title": "current"}}`))
+			case "/api/dashboards/uid/testDash/versions/2":
+				w.Write([]byte(`{"id": 5, "version": 2, "data": {"uid": "testDash", "title": "old"}}`))
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		app := &App{httpClient: ts.Client()}
+
+		Convey("It should return current dashboard when version is not set", func() {
+			model, err := app.dashboardModel(context.Background(), ts.URL, "testDash", 0, http.Header{}, nil)
+
+			So(err, ShouldBeNil)
+			So(model.Dashboard.Title, ShouldEqual, "current")
+			So(model.Meta.FolderUID, ShouldEqual, "testFolder")
+			So(requestURI, ShouldHaveLength, 1)
+		})
+
+		Convey("It should return dashboard of requested version", func() {
+			model, err := app.dashboardModel(context.Background(), ts.URL, "testDash", 2, http.Header{}, nil)
+
+			So(err, ShouldBeNil)
+			So(model.Dashboard.Title, ShouldEqual, "old")
+			So(model.Meta.FolderUID, ShouldEqual, "testFolder")
+			So(requestURI, ShouldContain, "/api/dashboards/uid/testDash/versions/2")
+		})
+
+		Convey("It should return not found error for unknown version", func() {
+			_, err := app.dashboardModel(context.Background(), ts.URL, "testDash", 3, http.Header{}, nil)
+
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, errResourceNotFound), ShouldBeTrue)
+		})
+	})
+}
+
+func TestTimeRangeQuery(t *testing.T) {
+	Convey("When setting time range in query parameters", t, func() {
+		conf := &config.Config{DefaultTimeRange: []string{"now-7d", "now-1d"}}
+
+		Convey("Default time range should be applied when absent", func() {
+			values := timeRangeQuery(url.Values{"var-test": []string{"foo"}}, conf, dashboard.TimeRange{})
+
+			So(values.Get("from"), ShouldEqual, "now-7d")
+			So(values.Get("to"), ShouldEqual, "now-1d")
+			So(values.Get("var-test"), ShouldEqual, "foo")
+		})
+
+		Convey("Default time range should be applied to nil query", func() {
+			values := timeRangeQuery(nil, conf, dashboard.TimeRange{})
+
+			So(values.Get("from"), ShouldEqual, "now-7d")
+			So(values.Get("to"), ShouldEqual, "now-1d")
+		})
+
+		Convey("Time range from query parameters should be preserved", func() {
+			query := url.Values{"from": []string{"now-2h"}}
+			values := timeRangeQuery(query, conf, dashboard.TimeRange{})
+
+			So(values.Get("from"), ShouldEqual, "now-2h")
+			So(values.Get("to"), ShouldEqual, "now-1d")
+			So(query.Has("to"), ShouldBeFalse)
+		})
+
+		Convey("Saved time range of dashboard should be ignored by default", func() {
+			values := timeRangeQuery(nil, conf, dashboard.TimeRange{From: "now-6h", To: "now"})
+
+			So(values.Get("from"), ShouldEqual, "now-7d")
+			So(values.Get("to"), ShouldEqual, "now-1d")
+		})
+
+		Convey("Saved time range of dashboard should be applied when enabled", func() {
+			conf.UseDashboardSavedTime = true
+
+			var model dashboard.Model
+
+			err := json.Unmarshal([]byte(`{"dashboard": {"time": {"from": "2024-12-14T16:40:55.000Z", "to": "now"}}}`), &model)
+			So(err, ShouldBeNil)
+
+			values := timeRangeQuery(nil, conf, model.Dashboard.Time)
+
+			So(values.Get("from"), ShouldEqual, "1734194455000")
+			So(values.Get("to"), ShouldEqual, "now")
+
+			Convey("Time range from query parameters should take precedence", func() {
+				values := timeRangeQuery(url.Values{"from": []string{"now-2h"}}, conf, model.Dashboard.Time)
+
+				So(values.Get("from"), ShouldEqual, "now-2h")
+				So(values.Get("to"), ShouldEqual, "now")
+			})
+		})
+	})
+}
+
+func TestAnonymousAccess(t *testing.T) {
+	Convey("When Grafana allows anonymous access", t, func() {
+		var requestHeaders http.Header
+
+		// Server that rejects any request with credentials
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestHeaders = r.Header
+
+			if r.Header.Get(backend.OAuthIdentityTokenHeaderName) != "" || r.Header.Get(backend.CookiesHeaderName) != "" {
+				http.Error(w, "unexpected credentials", http.StatusBadRequest)
+
+				return
+			}
+
+			w.Write([]byte(`{"dashboard": {"uid": "testDash", "title": "public"}}`))
+		}))
+		defer ts.Close()
+
+		app := &App{httpClient: ts.Client()}
+		conf := &config.Config{AnonymousAccess: true, Token: "token"}
+		grafanaConfig := backend.NewGrafanaCfg(map[string]string{backend.AppURL: ts.URL})
+
+		req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash", nil)
+		req.Header.Set(backend.CookiesHeaderName, "cookie")
+
+		authHeader, err := app.authHeader(req, conf, grafanaConfig, log.NewNullLogger())
+
+		Convey("It should not forward any credentials", func() {
+			So(err, ShouldBeNil)
+			So(authHeader, ShouldBeEmpty)
+		})
+
+		model, err := app.dashboardModel(context.Background(), ts.URL, "testDash", 0, authHeader, nil)
+
+		Convey("It should fetch dashboard without credentials", func() {
+			So(err, ShouldBeNil)
+			So(model.Dashboard.Title, ShouldEqual, "public")
+			So(requestHeaders.Get(backend.OAuthIdentityTokenHeaderName), ShouldBeEmpty)
+		})
+
+		Convey("It should forward token when anonymous access is disabled", func() {
+			conf.AnonymousAccess = false
+			req.Header.Del(backend.CookiesHeaderName)
+
+			authHeader, err := app.authHeader(req, conf, grafanaConfig, log.NewNullLogger())
+
+			So(err, ShouldBeNil)
+			So(authHeader.Get(backend.OAuthIdentityTokenHeaderName), ShouldEqual, "Bearer token")
+		})
+
+		Convey("It should forward basic auth credentials of reverse proxy along with token", func() {
+			conf.AnonymousAccess = false
+			conf.BasicAuthUser = "proxy"
+			conf.BasicAuthPassword = "secret"
+			req.Header.Del(backend.CookiesHeaderName)
+
+			authHeader, err := app.authHeader(req, conf, grafanaConfig, log.NewNullLogger())
+
+			So(err, ShouldBeNil)
+			So(authHeader.Get(backend.OAuthIdentityTokenHeaderName), ShouldEqual, "Bearer token")
+			So(authHeader.Get("Proxy-Authorization"), ShouldEqual, "Basic cHJveHk6c2VjcmV0")
+		})
+
+		Convey("It should use Authorization header for basic auth with anonymous access", func() {
+			conf.BasicAuthUser = "proxy"
+			conf.BasicAuthPassword = "secret"
+
+			authHeader, err := app.authHeader(req, conf, grafanaConfig, log.NewNullLogger())
+
+			So(err, ShouldBeNil)
+			So(authHeader.Get(backend.OAuthIdentityTokenHeaderName), ShouldEqual, "Basic cHJveHk6c2VjcmV0")
+		})
+	})
+}
+
+func TestReportHeadRequest(t *testing.T) {
+	Convey("When the report handler is called with HEAD method", t, func() {
+		var requestURI []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestURI = append(requestURI, r.URL.Path)
+
+			switch r.URL.Path {
+			case "/api/dashboards/uid/testDash":
+				w.Write([]byte(`{"dashboard": {"uid": "testDash", "title": "My dashboard"}}`))
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+			DecryptedSecureJSONData: map[string]string{
+				config.SaToken: "token",
+			},
+		})
+		So(err, ShouldBeNil)
+
+		app := &App{httpClient: ts.Client(), conf: conf, grafanaSemVer: "v10.4.0"}
+
+		ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL,
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		Convey("It should return report headers without generating report", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=testDash", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/pdf")
+			So(w.Header().Get("Content-Disposition"), ShouldContainSubstring, "My%20dashboard.pdf")
+			So(w.Body.Len(), ShouldEqual, 0)
+			So(requestURI, ShouldResemble, []string{"/api/dashboards/uid/testDash"})
+		})
+
+		Convey("It should return absolute time range headers when enabled", func() {
+			app.conf.TimeRangeHeaders = true
+			defer func() { app.conf.TimeRangeHeaders = false }()
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=testDash&from=1734194455000&to=now&timezone=utc", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("X-Report-Time-From"), ShouldEqual, "2024-12-14T16:40:55Z")
+
+			to, err := time.Parse(time.RFC3339, w.Header().Get("X-Report-Time-To"))
+			So(err, ShouldBeNil)
+			So(to, ShouldHappenWithin, time.Minute, time.Now())
+		})
+
+		Convey("It should reject invalid time range when time range headers are enabled", func() {
+			app.conf.TimeRangeHeaders = true
+			defer func() { app.conf.TimeRangeHeaders = false }()
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=testDash&from=yesterday", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("It should not return time range headers by default", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=testDash", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Header().Get("X-Report-Time-From"), ShouldBeEmpty)
+		})
+
+		Convey("It should fail for unknown dashboard", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=unknown", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		})
+
+		Convey("It should reject request without dashboard UID", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("It should reject other methods", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/report?dashUid=testDash", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+
+		Convey("It should return NDJSON headers of data export", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/data?dashUid=testDash&format=ndjson", nil)
+			w := httptest.NewRecorder()
+
+			app.handleData(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+			So(w.Body.Len(), ShouldEqual, 0)
+		})
+
+		Convey("It should return XLSX headers of data export", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/data?dashUid=testDash&format=xlsx", nil)
+			w := httptest.NewRecorder()
+
+			app.handleData(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		})
+
+		Convey("It should reject unsupported data export formats", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/data?dashUid=testDash&format=xml", nil)
+			w := httptest.NewRecorder()
+
+			app.handleData(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(requestURI, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestReportRateLimit(t *testing.T) {
+	Convey("When the report handler is rate limited", t, func() {
+		app := &App{rateLimiter: newRateLimiter(1)}
+
+		request := func(login string) *httptest.ResponseRecorder {
+			ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{
+				OrgID: 1,
+				User:  &backend.User{Login: login},
+			})
+
+			// Request without dashUid so that it fails right after rate limit check
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/report", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			return w
+		}
+
+		Convey("Requests beyond the limit should be rejected", func() {
+			So(request("foo").Code, ShouldEqual, http.StatusBadRequest)
+
+			w := request("foo")
+			So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+			So(w.Header().Get("Retry-After"), ShouldEqual, "60")
+
+			So(request("bar").Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Service accounts should be exempted when configured", func() {
+			app.conf.RateLimitExemptServiceAccounts = true
+
+			So(request("sa-reporter").Code, ShouldEqual, http.StatusBadRequest)
+			So(request("sa-reporter").Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Service accounts should be limited when not exempted", func() {
+			So(request("sa-reporter").Code, ShouldEqual, http.StatusBadRequest)
+			So(request("sa-reporter").Code, ShouldEqual, http.StatusTooManyRequests)
+		})
+	})
+}
+
+func TestWorkerPoolsFor(t *testing.T) {
+	Convey("When selecting worker pools of a request", t, func() {
+		shared := worker.Pools{worker.Browser: &worker.Pool{}, worker.Renderer: &worker.Pool{}}
+		interactive := worker.Pools{worker.Browser: &worker.Pool{}, worker.Renderer: &worker.Pool{}}
+
+		user := backend.PluginContext{User: &backend.User{Login: "foo"}}
+		serviceAccount := backend.PluginContext{User: &backend.User{Login: "sa-1-reporter"}}
+
+		Convey("Shared pools should be used when interactive pools are not configured", func() {
+			app := &App{workerPools: shared}
+
+			So(app.workerPoolsFor(priorityInteractive, user)[worker.Browser], ShouldPointTo, shared[worker.Browser])
+			So(app.workerPoolsFor("", user)[worker.Browser], ShouldPointTo, shared[worker.Browser])
+		})
+
+		Convey("Pools should be selected based on priority", func() {
+			app := &App{workerPools: shared, interactivePools: interactive}
+
+			So(app.workerPoolsFor(priorityInteractive, serviceAccount)[worker.Browser], ShouldPointTo, interactive[worker.Browser])
+			So(app.workerPoolsFor(priorityBatch, user)[worker.Browser], ShouldPointTo, shared[worker.Browser])
+		})
+
+		Convey("Pools should be selected based on request source without priority", func() {
+			app := &App{workerPools: shared, interactivePools: interactive}
+
+			So(app.workerPoolsFor("", user)[worker.Browser], ShouldPointTo, interactive[worker.Browser])
+			So(app.workerPoolsFor("", serviceAccount)[worker.Browser], ShouldPointTo, shared[worker.Browser])
+		})
+	})
+}
+
+func TestResolutionsQueryParam(t *testing.T) {
+	Convey("When parsing resolutions query parameter", t, func() {
+		Convey("No scales should be returned when absent", func() {
+			scales, err := resolutionsQueryParam(url.Values{})
+
+			So(err, ShouldBeNil)
+			So(scales, ShouldBeNil)
+		})
+
+		Convey("Scales should be parsed and deduplicated", func() {
+			scales, err := resolutionsQueryParam(url.Values{"resolutions": []string{"2, 1,2,0.5"}})
+
+			So(err, ShouldBeNil)
+			So(scales, ShouldResemble, []float64{2, 1, 0.5})
+		})
+
+		Convey("Invalid scales should return error", func() {
+			for _, v := range []string{"", "foo", "0", "-1", "5", "1,2,3,4,0.5"} {
+				_, err := resolutionsQueryParam(url.Values{"resolutions": []string{v}})
+
+				So(errors.Is(err, errInvalidResolution), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestLogLevelQueryParam(t *testing.T) {
+	Convey("When parsing logLevel query parameter", t, func() {
+		Convey("No level should be returned when absent", func() {
+			_, ok, err := logLevelQueryParam(url.Values{})
+
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Level should be parsed case insensitively", func() {
+			level, ok, err := logLevelQueryParam(url.Values{"logLevel": []string{"Warn"}})
+
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(level, ShouldEqual, log.Warn)
+		})
+
+		Convey("Invalid level should return error", func() {
+			for _, v := range []string{"", "trace", "verbose"} {
+				_, _, err := logLevelQueryParam(url.Values{"logLevel": []string{v}})
+
+				So(errors.Is(err, errInvalidLogLevel), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestDiffVersionsQueryParam(t *testing.T) {
+	Convey("When parsing diffVersions query parameter", t, func() {
+		Convey("No versions should be returned when absent", func() {
+			_, _, ok, err := diffVersionsQueryParam(url.Values{})
+
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Versions should be parsed", func() {
+			from, to, ok, err := diffVersionsQueryParam(url.Values{"diffVersions": []string{"3, 7"}})
+
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(from, ShouldEqual, 3)
+			So(to, ShouldEqual, 7)
+		})
+
+		Convey("Invalid versions should return error", func() {
+			for _, v := range []string{"", "3", "0,2", "3,a", "1,2,3"} {
+				_, _, _, err := diffVersionsQueryParam(url.Values{"diffVersions": []string{v}})
+
+				So(errors.Is(err, errInvalidVersions), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestReportProfiles(t *testing.T) {
+	Convey("When report profile is requested", t, func() {
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+			JSONData: json.RawMessage(`{
+				"theme": "light",
+				"reportProfiles": {
+					"weekly-exec": {
+						"theme": "dark",
+						"layout": "grid",
+						"orientation": "landscape",
+						"includePanelIds": ["1", "4"],
+						"variables": {"env": "prod", "host": "server1"}
+					}
+				}
+			}`),
+		})
+		So(err, ShouldBeNil)
+
+		app := &App{conf: conf, grafanaSemVer: "v11.4.0"}
+
+		Convey("Profile settings should be applied and overridden by query parameters", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&profile=weekly-exec&orientation=portrait&var-host=server2", nil)
+			conf := app.conf
+
+			So(app.updateConfig(req, &conf), ShouldBeNil)
+			So(conf.Theme, ShouldEqual, "dark")
+			So(conf.Layout, ShouldEqual, "grid")
+			So(conf.Orientation, ShouldEqual, "portrait")
+			So(conf.IncludePanelIDs, ShouldResemble, []string{"panel-1", "panel-4"})
+			So(req.URL.Query().Get("var-env"), ShouldEqual, "prod")
+			So(req.URL.Query().Get("var-host"), ShouldEqual, "server2")
+			So(req.URL.Query().Get("dashUid"), ShouldEqual, "testDash")
+		})
+
+		Convey("Config should not be changed without profile", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash", nil)
+			conf := app.conf
+
+			So(app.updateConfig(req, &conf), ShouldBeNil)
+			So(conf.Theme, ShouldEqual, "light")
+			So(conf.IncludePanelIDs, ShouldBeEmpty)
+			So(req.URL.Query().Has("var-env"), ShouldBeFalse)
+		})
+
+		Convey("Unknown profile should return error", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&profile=ops-detail", nil)
+			conf := app.conf
+
+			err := app.updateConfig(req, &conf)
+			So(errors.Is(err, errInvalidProfile), ShouldBeTrue)
+			So(errors.Is(err, config.ErrUnknownProfile), ShouldBeTrue)
+		})
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	Convey("When timeout of request is overridden", t, func() {
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+			JSONData: json.RawMessage(`{"maxRequestTimeout": 300}`),
+		})
+		So(err, ShouldBeNil)
+
+		app := &App{conf: conf, grafanaSemVer: "v11.4.0"}
+
+		Convey("Timeout should be used for browser tabs", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&timeout=2m", nil)
+			conf := app.conf
+
+			So(app.updateConfig(req, &conf), ShouldBeNil)
+			So(conf.RequestTimeout, ShouldEqual, 2*time.Minute)
+			So(conf.TabTimeout(), ShouldEqual, 2*time.Minute)
+		})
+
+		Convey("HTTP client timeout should be used without timeout", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash", nil)
+			conf := app.conf
+
+			So(app.updateConfig(req, &conf), ShouldBeNil)
+			So(conf.TabTimeout(), ShouldEqual, conf.HTTPClientOptions.Timeouts.Timeout)
+		})
+
+		Convey("Invalid timeouts should return error", func() {
+			for _, timeout := range []string{"10", "-1m", "0s", "6m"} {
+				req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&timeout="+timeout, nil)
+				conf := app.conf
+
+				So(errors.Is(app.updateConfig(req, &conf), errInvalidTimeout), ShouldBeTrue)
+			}
+		})
+
+		Convey("Timeout above maximum should be rejected with bad request", func() {
+			ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+				backend.AppURL: "http://localhost:3000",
+			}))
+			ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/report?dashUid=testDash&timeout=1h", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "at most 300s")
+		})
+	})
+}
+
+func TestMarginQueryParams(t *testing.T) {
+	Convey("When page margins are overridden", t, func() {
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{})
+		So(err, ShouldBeNil)
+
+		app := &App{conf: conf, grafanaSemVer: "v11.4.0"}
+
+		Convey("Margins should be parsed", func() {
+			req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&marginTop=0&marginLeft=0.5", nil)
+			conf := app.conf
+
+			So(app.updateConfig(req, &conf), ShouldBeNil)
+			So(conf.MarginTop, ShouldEqual, 0)
+			So(conf.MarginLeft, ShouldEqual, 0.5)
+			So(conf.MarginRight, ShouldEqual, app.conf.MarginRight)
+		})
+
+		Convey("Invalid margins should return error", func() {
+			for _, margin := range []string{"abc", "-1", "NaN", "Inf", ""} {
+				req := httptest.NewRequest(http.MethodGet, "/report?dashUid=testDash&marginBottom="+margin, nil)
+				conf := app.conf
+
+				So(errors.Is(app.updateConfig(req, &conf), errInvalidMargin), ShouldBeTrue)
+			}
+		})
+
+		Convey("Invalid margins should be rejected with bad request", func() {
+			ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+				backend.AppURL: "http://localhost:3000",
+			}))
+			ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/report?dashUid=testDash&marginTop=1in", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "non-negative numbers of inches")
+		})
+	})
+}
+
+func TestSubPathAppURL(t *testing.T) {
+	Convey("When Grafana is served from a sub path", t, func() {
+		var requestURI []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestURI = append(requestURI, r.URL.Path)
+
+			if r.URL.Path != "/grafana/api/dashboards/uid/testDash" {
+				http.Error(w, "not found", http.StatusNotFound)
+
+				return
+			}
+
+			w.Write([]byte(`{"dashboard": {"uid": "testDash", "title": "My dashboard"}}`))
+		}))
+		defer ts.Close()
+
+		conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+			DecryptedSecureJSONData: map[string]string{
+				config.SaToken: "token",
+			},
+		})
+		So(err, ShouldBeNil)
+
+		app := &App{httpClient: ts.Client(), conf: conf, grafanaSemVer: "v10.4.0"}
+
+		ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+			backend.AppURL: ts.URL + "/grafana/",
+		}))
+		ctx = backend.WithPluginContext(ctx, backend.PluginContext{User: &backend.User{Login: "foo"}})
+
+		Convey("App URL should keep the sub path", func() {
+			appURL, err := app.grafanaAppURL(backend.GrafanaConfigFromContext(ctx))
+
+			So(err, ShouldBeNil)
+			So(appURL, ShouldEqual, ts.URL+"/grafana")
+		})
+
+		Convey("Grafana API requests should be made to the sub path", func() {
+			req := httptest.NewRequestWithContext(ctx, http.MethodHead, "/report?dashUid=testDash", nil)
+			w := httptest.NewRecorder()
+
+			app.handleReport(w, req)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(requestURI, ShouldResemble, []string{"/grafana/api/dashboards/uid/testDash"})
+		})
+	})
+}